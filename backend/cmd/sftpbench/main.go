@@ -0,0 +1,212 @@
+// Command sftpbench measures upload and download throughput of the SFTP
+// client against an in-process SFTP server, once sequentially and once per
+// large-file worker count, over a pipe with simulated latency.
+//
+//	go run ./cmd/sftpbench -size 256 -latency 20ms -workers 4,8,16
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	internalsftp "freessh-backend/internal/sftp"
+
+	"github.com/pkg/sftp"
+)
+
+func main() {
+	sizeMiB := flag.Int("size", 256, "test file size in MiB")
+	latency := flag.Duration("latency", 20*time.Millisecond, "one-way latency of the simulated link")
+	workerList := flag.String("workers", "4,8,16", "comma-separated worker counts for the large-file mode")
+	flag.Parse()
+
+	workers, err := parseWorkers(*workerList)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	dir, err := os.MkdirTemp("", "sftpbench")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	source := filepath.Join(dir, "source")
+	sum, err := writeRandomFile(source, int64(*sizeMiB)*1024*1024)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	client, err := connect(*latency)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Close()
+
+	fmt.Printf("file %d MiB, one-way latency %s\n\n", *sizeMiB, *latency)
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(table, "mode\tupload MiB/s\tdownload MiB/s\t")
+
+	for _, count := range append([]int{1}, workers...) {
+		client.SetChunkWorkers(count)
+		mode := "sequential"
+		if count > 1 {
+			mode = fmt.Sprintf("%d workers", count)
+		}
+
+		up, down, err := run(client, dir, source, sum, *sizeMiB)
+		if err != nil {
+			log.Fatalf("%s: %v", mode, err)
+		}
+		fmt.Fprintf(table, "%s\t%.1f\t%.1f\t\n", mode, up, down)
+	}
+	table.Flush()
+}
+
+// run uploads source and downloads it back, checks both copies and returns
+// the throughput of each direction in MiB/s.
+func run(client *internalsftp.Client, dir, source string, sum []byte, sizeMiB int) (float64, float64, error) {
+	remote := filepath.Join(dir, "remote")
+	local := filepath.Join(dir, "local")
+	defer os.Remove(remote)
+	defer os.Remove(local)
+
+	start := time.Now()
+	if err := client.Upload(source, remote, internalsftp.TransferOptions{}, nil, nil); err != nil {
+		return 0, 0, err
+	}
+	up := float64(sizeMiB) / time.Since(start).Seconds()
+	if err := verify(remote, sum); err != nil {
+		return 0, 0, fmt.Errorf("upload: %w", err)
+	}
+
+	start = time.Now()
+	if err := client.Download(remote, local, internalsftp.TransferOptions{}, nil, nil); err != nil {
+		return 0, 0, err
+	}
+	down := float64(sizeMiB) / time.Since(start).Seconds()
+	if err := verify(local, sum); err != nil {
+		return 0, 0, fmt.Errorf("download: %w", err)
+	}
+
+	return up, down, nil
+}
+
+// connect starts an in-process SFTP server serving the local file system and
+// returns a client talking to it through delayed pipes.
+func connect(latency time.Duration) (*internalsftp.Client, error) {
+	serverIn, clientOut := newDelayedPipe(latency)
+	clientIn, serverOut := newDelayedPipe(latency)
+
+	server, err := sftp.NewServer(struct {
+		io.Reader
+		io.WriteCloser
+	}{serverIn, serverOut})
+	if err != nil {
+		return nil, err
+	}
+	go server.Serve()
+
+	return internalsftp.NewPipeClient(clientIn, clientOut)
+}
+
+// packet is data written to a delayed pipe and when it is delivered.
+type packet struct {
+	data []byte
+	due  time.Time
+}
+
+// delayedPipe delivers each write after a fixed delay without holding up the
+// writer, like a link with that latency. The queue bounds the data in flight.
+type delayedPipe struct {
+	queue chan packet
+	delay time.Duration
+}
+
+func newDelayedPipe(delay time.Duration) (io.Reader, io.WriteCloser) {
+	reader, writer := io.Pipe()
+	pipe := &delayedPipe{
+		queue: make(chan packet, 16384),
+		delay: delay,
+	}
+
+	go func() {
+		for p := range pipe.queue {
+			time.Sleep(time.Until(p.due))
+			if _, err := writer.Write(p.data); err != nil {
+				break
+			}
+		}
+		writer.Close()
+	}()
+
+	return reader, pipe
+}
+
+func (p *delayedPipe) Write(data []byte) (int, error) {
+	p.queue <- packet{data: bytes.Clone(data), due: time.Now().Add(p.delay)}
+	return len(data), nil
+}
+
+func (p *delayedPipe) Close() error {
+	close(p.queue)
+	return nil
+}
+
+func writeRandomFile(path string, size int64) ([]byte, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.CopyN(io.MultiWriter(file, hash), rand.Reader, size); err != nil {
+		return nil, err
+	}
+	return hash.Sum(nil), nil
+}
+
+func verify(path string, sum []byte) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return err
+	}
+	if !bytes.Equal(hash.Sum(nil), sum) {
+		return fmt.Errorf("checksum mismatch for %s", path)
+	}
+	return nil
+}
+
+func parseWorkers(list string) ([]int, error) {
+	var workers []int
+	for _, field := range strings.Split(list, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		count, err := strconv.Atoi(field)
+		if err != nil || count < 2 {
+			return nil, fmt.Errorf("invalid worker count: %q", field)
+		}
+		workers = append(workers, count)
+	}
+	return workers, nil
+}
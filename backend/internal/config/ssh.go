@@ -6,4 +6,11 @@ const (
 	DefaultSSHPort    = 22
 	DefaultTimeout    = 30 * time.Second
 	DefaultKeepAlive  = 15 * time.Second
+
+	// DefaultKeepAliveMaxMissed is how many keepalives in a row may go
+	// unanswered before the connection is considered lost.
+	DefaultKeepAliveMaxMissed = 3
+
+	// DefaultExecTimeout bounds exec:run commands that set no timeout.
+	DefaultExecTimeout = 5 * time.Minute
 )
@@ -0,0 +1,23 @@
+package config
+
+import "time"
+
+const (
+	// DefaultTransferConcurrency is how many transfers run at once
+	// across all hosts.
+	DefaultTransferConcurrency = 5
+
+	// DefaultTransferPerHost is how many queued transfers run at once
+	// against a single connection.
+	DefaultTransferPerHost = 2
+
+	// DefaultTransferAttempts is how often a queued transfer is tried before
+	// it is marked failed.
+	DefaultTransferAttempts = 3
+
+	// TransferRetryDelay is the wait before the first retry of a failed
+	// queued transfer. It doubles with each attempt up to
+	// TransferRetryMaxDelay.
+	TransferRetryDelay    = 5 * time.Second
+	TransferRetryMaxDelay = 5 * time.Minute
+)
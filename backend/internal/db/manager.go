@@ -71,8 +71,10 @@ func ensureSchema(db *sql.DB) error {
 		return fmt.Errorf("failed to apply schema: %w", err)
 	}
 
-	if _, err := db.Exec(MigrationSQL); err != nil && !isDuplicateColumnError(err) {
-		return fmt.Errorf("failed to apply migration: %w", err)
+	for _, migration := range MigrationSQL {
+		if _, err := db.Exec(migration); err != nil && !isDuplicateColumnError(err) {
+			return fmt.Errorf("failed to apply migration: %w", err)
+		}
 	}
 
 	return nil
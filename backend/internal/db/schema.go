@@ -9,7 +9,8 @@ CREATE TABLE IF NOT EXISTS groups (
   id TEXT PRIMARY KEY NOT NULL,
   name TEXT NOT NULL,
   connection_count INTEGER DEFAULT 0,
-  created_at TEXT NOT NULL DEFAULT (datetime('now'))
+  created_at TEXT NOT NULL DEFAULT (datetime('now')),
+  environment TEXT
 );
 
 CREATE TABLE IF NOT EXISTS ssh_keys (
@@ -36,6 +37,15 @@ CREATE TABLE IF NOT EXISTS connections (
   password TEXT,
   'group' TEXT,
   profile TEXT,
+  jump_hosts TEXT,
+  forward_agent INTEGER DEFAULT 0,
+  auth_methods TEXT,
+  proxy TEXT,
+  proxy_command TEXT,
+  network TEXT,
+  crypto_policy TEXT,
+  forward_x11 INTEGER DEFAULT 0,
+  environment TEXT,
   FOREIGN KEY (key_id) REFERENCES ssh_keys (id) ON DELETE SET NULL
 );
 
@@ -43,7 +53,14 @@ CREATE TABLE IF NOT EXISTS snippets (
   id TEXT PRIMARY KEY NOT NULL,
   name TEXT NOT NULL,
   command TEXT NOT NULL,
-  created_at TEXT NOT NULL DEFAULT (datetime('now'))
+  created_at TEXT NOT NULL DEFAULT (datetime('now')),
+  params TEXT,
+  folder TEXT,
+  tags TEXT,
+  description TEXT,
+  variants TEXT,
+  usage_count INTEGER NOT NULL DEFAULT 0,
+  last_used_at TEXT
 );
 
 CREATE TABLE IF NOT EXISTS history (
@@ -79,9 +96,97 @@ CREATE TABLE IF NOT EXISTS known_hosts (
   hostname TEXT NOT NULL,
   port INTEGER NOT NULL,
   fingerprint TEXT NOT NULL,
-  publicKey TEXT NOT NULL
+  publicKey TEXT NOT NULL,
+  marker TEXT NOT NULL DEFAULT '',
+  key_type TEXT NOT NULL DEFAULT ''
+);
+
+-- Desktop only: fleet job records, with per-host results stored as JSON.
+CREATE TABLE IF NOT EXISTS fleet_jobs (
+  id TEXT PRIMARY KEY NOT NULL,
+  snippet_id TEXT,
+  snippet_name TEXT,
+  command TEXT NOT NULL,
+  status TEXT NOT NULL,
+  concurrency INTEGER NOT NULL DEFAULT 0,
+  total INTEGER NOT NULL DEFAULT 0,
+  succeeded INTEGER NOT NULL DEFAULT 0,
+  failed INTEGER NOT NULL DEFAULT 0,
+  cancelled INTEGER NOT NULL DEFAULT 0,
+  hosts TEXT,
+  created_at TEXT NOT NULL DEFAULT (datetime('now')),
+  finished_at TEXT
+);
+
+-- Desktop only: journal of unfinished single-file transfers that can resume.
+CREATE TABLE IF NOT EXISTS transfer_journal (
+  id TEXT PRIMARY KEY NOT NULL,
+  connection_id TEXT,
+  direction TEXT NOT NULL,
+  local_path TEXT NOT NULL,
+  remote_path TEXT NOT NULL,
+  size INTEGER NOT NULL DEFAULT 0,
+  transferred INTEGER NOT NULL DEFAULT 0,
+  source_mod_time INTEGER NOT NULL DEFAULT 0,
+  status TEXT NOT NULL,
+  error TEXT,
+  created_at TEXT NOT NULL DEFAULT (datetime('now')),
+  updated_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+-- Desktop only: persistent transfer queue.
+CREATE TABLE IF NOT EXISTS transfer_jobs (
+  id TEXT PRIMARY KEY NOT NULL,
+  connection_id TEXT NOT NULL,
+  connection_name TEXT,
+  direction TEXT NOT NULL,
+  local_path TEXT NOT NULL,
+  remote_path TEXT NOT NULL,
+  priority INTEGER NOT NULL DEFAULT 0,
+  position INTEGER NOT NULL DEFAULT 0,
+  state TEXT NOT NULL,
+  attempts INTEGER NOT NULL DEFAULT 0,
+  max_attempts INTEGER NOT NULL DEFAULT 0,
+  size INTEGER NOT NULL DEFAULT 0,
+  transferred INTEGER NOT NULL DEFAULT 0,
+  source_mod_time INTEGER NOT NULL DEFAULT 0,
+  rate_limit INTEGER NOT NULL DEFAULT 0,
+  verify INTEGER NOT NULL DEFAULT 0,
+  unverified TEXT,
+  error TEXT,
+  next_attempt_at TEXT,
+  created_at TEXT NOT NULL DEFAULT (datetime('now')),
+  started_at TEXT,
+  finished_at TEXT,
+  updated_at TEXT NOT NULL DEFAULT (datetime('now'))
 );
 `
 
-// MigrationSQL mirrors the lightweight migration in the mobile schema.
-const MigrationSQL = `ALTER TABLE connections ADD COLUMN passphrase TEXT;`
+// MigrationSQL lists lightweight column migrations for databases created by
+// older releases. The first entry mirrors the migration in the mobile schema.
+// Each statement runs on its own so an already-applied column is skipped.
+var MigrationSQL = []string{
+	`ALTER TABLE connections ADD COLUMN passphrase TEXT;`,
+	`ALTER TABLE connections ADD COLUMN jump_hosts TEXT;`,
+	`ALTER TABLE connections ADD COLUMN forward_agent INTEGER DEFAULT 0;`,
+	`ALTER TABLE connections ADD COLUMN auth_methods TEXT;`,
+	`ALTER TABLE connections ADD COLUMN proxy TEXT;`,
+	`ALTER TABLE connections ADD COLUMN proxy_command TEXT;`,
+	`ALTER TABLE connections ADD COLUMN network TEXT;`,
+	`ALTER TABLE connections ADD COLUMN crypto_policy TEXT;`,
+	`ALTER TABLE connections ADD COLUMN forward_x11 INTEGER DEFAULT 0;`,
+	`ALTER TABLE connections ADD COLUMN environment TEXT;`,
+	`ALTER TABLE groups ADD COLUMN environment TEXT;`,
+	`ALTER TABLE snippets ADD COLUMN params TEXT;`,
+	`ALTER TABLE snippets ADD COLUMN folder TEXT;`,
+	`ALTER TABLE snippets ADD COLUMN tags TEXT;`,
+	`ALTER TABLE snippets ADD COLUMN description TEXT;`,
+	`ALTER TABLE snippets ADD COLUMN variants TEXT;`,
+	`ALTER TABLE snippets ADD COLUMN usage_count INTEGER NOT NULL DEFAULT 0;`,
+	`ALTER TABLE snippets ADD COLUMN last_used_at TEXT;`,
+	`ALTER TABLE known_hosts ADD COLUMN marker TEXT NOT NULL DEFAULT '';`,
+	`ALTER TABLE known_hosts ADD COLUMN key_type TEXT NOT NULL DEFAULT '';`,
+	`ALTER TABLE transfer_jobs ADD COLUMN rate_limit INTEGER NOT NULL DEFAULT 0;`,
+	`ALTER TABLE transfer_jobs ADD COLUMN verify INTEGER NOT NULL DEFAULT 0;`,
+	`ALTER TABLE transfer_jobs ADD COLUMN unverified TEXT;`,
+}
@@ -3,6 +3,7 @@ package export
 import (
 	"fmt"
 	"freessh-backend/internal/models"
+	"freessh-backend/internal/ssh"
 	"strings"
 )
 
@@ -12,6 +13,11 @@ func ExportOpenSSH(connections []models.ConnectionConfig, keyFileStorage KeyFile
 	builder.WriteString("# FreeSSH OpenSSH Config Export\n")
 	builder.WriteString("# Generated by FreeSSH\n\n")
 
+	aliases := make(map[string]string, len(connections))
+	for _, conn := range connections {
+		aliases[conn.ID] = sanitizeHostAlias(conn.Name)
+	}
+
 	for _, conn := range connections {
 		// Host alias (connection name)
 		builder.WriteString(fmt.Sprintf("Host %s\n", sanitizeHostAlias(conn.Name)))
@@ -33,6 +39,55 @@ func ExportOpenSSH(connections []models.ConnectionConfig, keyFileStorage KeyFile
 			keyPath := fmt.Sprintf("~/.freessh/keys/%s.pem", conn.KeyID)
 			builder.WriteString(fmt.Sprintf("    IdentityFile %s\n", keyPath))
 		}
+
+		// Jump hosts (only those that are part of this export)
+		jumps := make([]string, 0, len(conn.JumpHosts))
+		for _, jumpID := range conn.JumpHosts {
+			if alias, ok := aliases[jumpID]; ok {
+				jumps = append(jumps, alias)
+			}
+		}
+		if len(jumps) > 0 {
+			builder.WriteString(fmt.Sprintf("    ProxyJump %s\n", strings.Join(jumps, ",")))
+		} else if conn.ProxyCommand != "" {
+			builder.WriteString(fmt.Sprintf("    ProxyCommand %s\n", conn.ProxyCommand))
+		}
+
+		if conn.ForwardAgent {
+			builder.WriteString("    ForwardAgent yes\n")
+		}
+		if conn.ForwardX11 {
+			builder.WriteString("    ForwardX11 yes\n")
+		}
+		if len(conn.Environment) > 0 {
+			pairs := make([]string, 0, len(conn.Environment))
+			for _, v := range conn.Environment {
+				pairs = append(pairs, v.Name+"="+quoteSetEnvValue(v.Value))
+			}
+			builder.WriteString(fmt.Sprintf("    SetEnv %s\n", strings.Join(pairs, " ")))
+		}
+
+		if !conn.Crypto.IsZero() {
+			if algorithms, err := ssh.CryptoAlgorithms(conn.Crypto); err == nil {
+				writeAlgorithmList(&builder, "KexAlgorithms", algorithms.KeyExchanges)
+				writeAlgorithmList(&builder, "Ciphers", algorithms.Ciphers)
+				writeAlgorithmList(&builder, "MACs", algorithms.MACs)
+				writeAlgorithmList(&builder, "HostKeyAlgorithms", algorithms.HostKeys)
+			}
+		}
+
+		// Only the settings OpenSSH has an equivalent for
+		if conn.Network != nil {
+			if conn.Network.ConnectTimeout > 0 {
+				builder.WriteString(fmt.Sprintf("    ConnectTimeout %d\n", conn.Network.ConnectTimeout))
+			}
+			if conn.Network.KeepAliveInterval > 0 {
+				builder.WriteString(fmt.Sprintf("    ServerAliveInterval %d\n", conn.Network.KeepAliveInterval))
+			}
+			if conn.Network.KeepAliveMaxMissed > 0 {
+				builder.WriteString(fmt.Sprintf("    ServerAliveCountMax %d\n", conn.Network.KeepAliveMaxMissed))
+			}
+		}
 		
 		builder.WriteString("\n")
 	}
@@ -40,6 +95,23 @@ func ExportOpenSSH(connections []models.ConnectionConfig, keyFileStorage KeyFile
 	return []byte(builder.String()), nil
 }
 
+func writeAlgorithmList(builder *strings.Builder, keyword string, algorithms []string) {
+	if len(algorithms) > 0 {
+		builder.WriteString(fmt.Sprintf("    %s %s\n", keyword, strings.Join(algorithms, ",")))
+	}
+}
+
+// quoteSetEnvValue double-quotes values that contain spaces or quotes so
+// OpenSSH reads them back as a single SetEnv entry.
+func quoteSetEnvValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \t\"\\") {
+		return value
+	}
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	return `"` + value + `"`
+}
+
 // sanitizeHostAlias removes spaces and special characters from connection names
 func sanitizeHostAlias(name string) string {
 	// Replace spaces with hyphens
@@ -0,0 +1,377 @@
+package fleet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"freessh-backend/internal/config"
+	"freessh-backend/internal/models"
+	"freessh-backend/internal/osdetect"
+	"freessh-backend/internal/session"
+	"freessh-backend/internal/storage"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultConcurrency is how many hosts a job runs on at once when the
+	// request does not say.
+	DefaultConcurrency = 10
+	// MaxConcurrency caps requested concurrency.
+	MaxConcurrency = 64
+
+	// maxOutputBytes is how much of each output stream is kept per host in
+	// the job record. Everything is still streamed while the job runs.
+	maxOutputBytes = 64 * 1024
+)
+
+// Events receives progress of a running job. Either callback may be nil.
+type Events struct {
+	Output func(models.FleetOutput)
+	Host   func(models.FleetHostUpdate)
+}
+
+// Manager runs commands on many saved connections at once and keeps a record
+// of each run.
+type Manager struct {
+	sessions    *session.Manager
+	connections *storage.ConnectionStorage
+	groups      *storage.GroupStorage
+	snippets    *storage.SnippetStorage
+	jobs        *storage.FleetJobStorage
+
+	running map[string]context.CancelFunc
+	mu      sync.Mutex
+}
+
+func NewManager(sessions *session.Manager, groups *storage.GroupStorage, snippets *storage.SnippetStorage, jobs *storage.FleetJobStorage) *Manager {
+	if err := jobs.MarkInterrupted(); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+
+	return &Manager{
+		sessions:    sessions,
+		connections: sessions.GetConnectionStorage(),
+		groups:      groups,
+		snippets:    snippets,
+		jobs:        jobs,
+		running:     make(map[string]context.CancelFunc),
+	}
+}
+
+// Run executes the request on every target host with bounded concurrency and
+// returns the finished job record. It blocks until all hosts are done or the
+// job is cancelled.
+func (m *Manager) Run(req models.FleetRunRequest, events Events) (*models.FleetJob, error) {
+	job, targets, plan, err := m.prepare(req)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	m.mu.Lock()
+	if _, exists := m.running[job.ID]; exists {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("fleet job %s is already running", job.ID)
+	}
+	m.running[job.ID] = cancel
+	m.mu.Unlock()
+
+	defer func() {
+		m.mu.Lock()
+		delete(m.running, job.ID)
+		m.mu.Unlock()
+	}()
+
+	if err := m.jobs.Save(*job); err != nil {
+		return nil, err
+	}
+
+	timeout := config.DefaultExecTimeout
+	if req.TimeoutSeconds > 0 {
+		timeout = time.Duration(req.TimeoutSeconds) * time.Second
+	}
+
+	slots := make(chan struct{}, job.Concurrency)
+	var wg sync.WaitGroup
+	for i := range job.Hosts {
+		wg.Add(1)
+		go func(result *models.FleetHostResult, target models.ConnectionConfig) {
+			defer wg.Done()
+
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+			case <-ctx.Done():
+			}
+			m.runHost(ctx, job.ID, plan, timeout, target, result, events)
+		}(&job.Hosts[i], targets[i])
+	}
+	wg.Wait()
+
+	job.FinishedAt = time.Now()
+	job.Summary = summarize(job.Hosts)
+	switch {
+	case ctx.Err() != nil:
+		job.Status = models.FleetCancelled
+	case job.Summary.Failed > 0:
+		job.Status = models.FleetFailed
+	default:
+		job.Status = models.FleetSucceeded
+	}
+
+	if err := m.jobs.Save(*job); err != nil {
+		return job, err
+	}
+	return job, nil
+}
+
+// Cancel stops a running job. Hosts that have not started are marked
+// cancelled and running commands are killed.
+func (m *Manager) Cancel(jobID string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if cancel, ok := m.running[jobID]; ok {
+		cancel()
+		return true
+	}
+	return false
+}
+
+func (m *Manager) List() []models.FleetJob {
+	return m.jobs.List()
+}
+
+func (m *Manager) Get(id string) (*models.FleetJob, error) {
+	return m.jobs.Get(id)
+}
+
+func (m *Manager) Delete(id string) error {
+	m.mu.Lock()
+	_, running := m.running[id]
+	m.mu.Unlock()
+	if running {
+		return fmt.Errorf("fleet job %s is still running", id)
+	}
+	return m.jobs.Delete(id)
+}
+
+// prepare resolves the command and target connections of req into a new job
+// with every host pending. targets[i] is the connection of job.Hosts[i].
+func (m *Manager) prepare(req models.FleetRunRequest) (*models.FleetJob, []models.ConnectionConfig, *commandPlan, error) {
+	if m.connections == nil {
+		return nil, nil, nil, fmt.Errorf("connection storage not available")
+	}
+
+	job := &models.FleetJob{
+		ID:          req.JobID,
+		Command:     strings.TrimSpace(req.Command),
+		Status:      models.FleetRunning,
+		Concurrency: req.Concurrency,
+		CreatedAt:   time.Now(),
+	}
+	if job.ID == "" {
+		job.ID = uuid.New().String()
+	}
+	if job.Concurrency <= 0 {
+		job.Concurrency = DefaultConcurrency
+	}
+	if job.Concurrency > MaxConcurrency {
+		job.Concurrency = MaxConcurrency
+	}
+
+	plan := &commandPlan{command: job.Command, values: req.Values}
+	if req.SnippetID != "" {
+		snippet, err := m.snippets.Get(req.SnippetID)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		job.SnippetID = snippet.ID
+		job.SnippetName = snippet.Name
+		if job.Command == "" {
+			job.Command = strings.TrimSpace(snippet.Command)
+			plan = &commandPlan{command: job.Command, snippet: snippet, values: req.Values}
+		}
+	}
+	if job.Command == "" && !plan.hasVariants() {
+		return nil, nil, nil, fmt.Errorf("command is required")
+	}
+
+	targets, err := m.resolveTargets(req)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if len(targets) == 0 {
+		return nil, nil, nil, fmt.Errorf("no connections selected")
+	}
+
+	// Render for every host up front so a missing or invalid parameter
+	// fails the job before anything runs. Hosts whose OS has a variant are
+	// rendered again once it is detected.
+	job.Hosts = make([]models.FleetHostResult, len(targets))
+	for i, target := range targets {
+		command, err := plan.validate(target)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		job.Hosts[i] = models.FleetHostResult{
+			ConnectionID:   target.ID,
+			ConnectionName: target.Name,
+			Host:           target.Host,
+			Command:        command,
+			Status:         models.FleetPending,
+			ExitCode:       -1,
+		}
+	}
+	job.Summary.Total = len(targets)
+
+	if job.SnippetID != "" {
+		if err := m.snippets.RecordUse(job.SnippetID, job.CreatedAt); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+
+	return job, targets, plan, nil
+}
+
+// resolveTargets returns the connections of req.ConnectionIDs followed by
+// those of req.GroupID, each once.
+func (m *Manager) resolveTargets(req models.FleetRunRequest) ([]models.ConnectionConfig, error) {
+	seen := make(map[string]bool)
+	var targets []models.ConnectionConfig
+
+	for _, id := range req.ConnectionIDs {
+		if seen[id] {
+			continue
+		}
+		connection, err := m.connections.Get(id)
+		if err != nil {
+			return nil, err
+		}
+		seen[id] = true
+		targets = append(targets, *connection)
+	}
+
+	if req.GroupID != "" {
+		group, err := m.groups.Get(req.GroupID)
+		if err != nil {
+			return nil, err
+		}
+		for _, connection := range m.connections.List() {
+			if connection.Group != group.Name || seen[connection.ID] {
+				continue
+			}
+			seen[connection.ID] = true
+			targets = append(targets, connection)
+		}
+	}
+
+	return targets, nil
+}
+
+// runHost runs the command of plan on target and records the outcome in
+// result. ctx is the job's context; timeout bounds this host alone.
+func (m *Manager) runHost(ctx context.Context, jobID string, plan *commandPlan, timeout time.Duration, target models.ConnectionConfig, result *models.FleetHostResult, events Events) {
+	report := func() {
+		if events.Host != nil {
+			events.Host(models.FleetHostUpdate{JobID: jobID, Host: *result})
+		}
+	}
+
+	if ctx.Err() != nil {
+		result.Status = models.FleetCancelled
+		result.Error = "job cancelled"
+		report()
+		return
+	}
+
+	result.Status = models.FleetRunning
+	result.StartedAt = time.Now()
+	report()
+
+	defer func() {
+		result.FinishedAt = time.Now()
+		result.DurationMs = result.FinishedAt.Sub(result.StartedAt).Milliseconds()
+		report()
+	}()
+
+	// The host's timeout covers connecting as well as running the command
+	hostCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	client, release, err := m.sessions.OpenTransport(hostCtx, target)
+	if err != nil {
+		switch {
+		case ctx.Err() != nil:
+			result.Status = models.FleetCancelled
+			result.Error = "job cancelled"
+		case errors.Is(err, context.DeadlineExceeded):
+			result.Status = models.FleetFailed
+			result.Error = fmt.Sprintf("timed out connecting after %s", timeout)
+		default:
+			result.Status = models.FleetFailed
+			result.Error = fmt.Sprintf("failed to connect: %v", err)
+		}
+		return
+	}
+	defer release()
+
+	if plan.hasVariants() {
+		osType, _ := osdetect.DetectOS(client.GetSSHClient())
+		command, err := plan.render(target, string(osType))
+		if err != nil {
+			result.Status = models.FleetFailed
+			result.Error = err.Error()
+			return
+		}
+		result.Command = command
+	}
+
+	stdout := newOutputWriter(jobID, target.ID, models.ExecStdout, events.Output)
+	stderr := newOutputWriter(jobID, target.ID, models.ExecStderr, events.Output)
+	status, err := client.Exec(hostCtx, result.Command, nil, stdout, stderr)
+
+	result.ExitCode = status.Code
+	result.Signal = status.Signal
+	result.Stdout = stdout.String()
+	result.Stderr = stderr.String()
+	result.Truncated = stdout.truncated || stderr.truncated
+
+	switch {
+	case ctx.Err() != nil:
+		result.Status = models.FleetCancelled
+		result.Error = "job cancelled"
+	case errors.Is(err, context.DeadlineExceeded):
+		result.Status = models.FleetFailed
+		result.Error = fmt.Sprintf("command timed out after %s", timeout)
+	case err != nil:
+		result.Status = models.FleetFailed
+		result.Error = err.Error()
+	case status.Code != 0:
+		result.Status = models.FleetFailed
+	default:
+		result.Status = models.FleetSucceeded
+	}
+}
+
+func summarize(hosts []models.FleetHostResult) models.FleetSummary {
+	summary := models.FleetSummary{Total: len(hosts)}
+	for _, host := range hosts {
+		switch host.Status {
+		case models.FleetSucceeded:
+			summary.Succeeded++
+		case models.FleetFailed:
+			summary.Failed++
+		case models.FleetCancelled:
+			summary.Cancelled++
+		}
+	}
+	return summary
+}
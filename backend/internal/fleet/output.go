@@ -0,0 +1,52 @@
+package fleet
+
+import (
+	"bytes"
+	"freessh-backend/internal/models"
+	"strings"
+)
+
+// outputWriter streams one output stream of a host and keeps its first
+// maxOutputBytes for the job record.
+type outputWriter struct {
+	jobID        string
+	connectionID string
+	stream       models.ExecStream
+	emit         func(models.FleetOutput)
+
+	kept      strings.Builder
+	truncated bool
+}
+
+func newOutputWriter(jobID, connectionID string, stream models.ExecStream, emit func(models.FleetOutput)) *outputWriter {
+	return &outputWriter{
+		jobID:        jobID,
+		connectionID: connectionID,
+		stream:       stream,
+		emit:         emit,
+	}
+}
+
+func (w *outputWriter) Write(p []byte) (int, error) {
+	if w.emit != nil {
+		w.emit(models.FleetOutput{
+			JobID:        w.jobID,
+			ConnectionID: w.connectionID,
+			Stream:       w.stream,
+			Data:         bytes.Clone(p),
+		})
+	}
+
+	kept := p
+	if remaining := maxOutputBytes - w.kept.Len(); len(kept) > remaining {
+		w.truncated = true
+		kept = kept[:max(remaining, 0)]
+	}
+	w.kept.Write(kept)
+
+	return len(p), nil
+}
+
+func (w *outputWriter) String() string {
+	return w.kept.String()
+}
@@ -0,0 +1,43 @@
+package fleet
+
+import (
+	"bytes"
+	"encoding/json"
+	"freessh-backend/internal/models"
+	"testing"
+)
+
+func TestOutputWriterKeepsChunkBytes(t *testing.T) {
+	var chunks []models.FleetOutput
+	w := newOutputWriter("job", "conn", models.ExecStdout, func(chunk models.FleetOutput) {
+		chunks = append(chunks, chunk)
+	})
+
+	// "é" split across two writes, followed by bytes that are not UTF-8
+	writes := [][]byte{{'a', 0xc3}, {0xa9, 0xff, 0x00}}
+	for _, p := range writes {
+		buf := bytes.Clone(p)
+		w.Write(buf)
+		clear(buf)
+	}
+
+	if len(chunks) != len(writes) {
+		t.Fatalf("emitted %d chunks, want %d", len(chunks), len(writes))
+	}
+	for i, chunk := range chunks {
+		encoded, err := json.Marshal(chunk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded models.FleetOutput
+		if err := json.Unmarshal(encoded, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(decoded.Data, writes[i]) {
+			t.Errorf("chunk %d = %x after a JSON round trip, want %x", i, decoded.Data, writes[i])
+		}
+	}
+	if got := w.String(); got != "aé\xff\x00" {
+		t.Errorf("kept output = %q", got)
+	}
+}
@@ -0,0 +1,53 @@
+package fleet
+
+import (
+	"fmt"
+	"freessh-backend/internal/models"
+	"freessh-backend/internal/snippets"
+	"strings"
+)
+
+// commandPlan renders the command of a job for each host. snippet is set
+// when the command comes from a snippet, whose OS variants then apply.
+type commandPlan struct {
+	command string
+	snippet *models.Snippet
+	values  map[string]string
+}
+
+func (p *commandPlan) hasVariants() bool {
+	return p.snippet != nil && len(p.snippet.Variants) > 0
+}
+
+// render fills in the command for target running osType, which may be empty
+// when the OS is not known.
+func (p *commandPlan) render(target models.ConnectionConfig, osType string) (string, error) {
+	if p.snippet == nil {
+		return snippets.Render(p.command, osType, nil, p.values, snippets.BuiltinValues(target))
+	}
+
+	command := snippets.CommandFor(*p.snippet, osType)
+	if strings.TrimSpace(command) == "" {
+		if osType == "" {
+			osType = "an unknown OS"
+		}
+		return "", fmt.Errorf("snippet has no command for %s", osType)
+	}
+	return snippets.Render(command, osType, p.snippet.Params, p.values, snippets.BuiltinValues(target))
+}
+
+// validate renders every command target may end up running and returns the
+// default one.
+func (p *commandPlan) validate(target models.ConnectionConfig) (string, error) {
+	if p.hasVariants() {
+		for osType := range p.snippet.Variants {
+			if _, err := p.render(target, osType); err != nil {
+				return "", fmt.Errorf("%s variant: %w", osType, err)
+			}
+		}
+		if strings.TrimSpace(p.command) == "" {
+			return "", nil
+		}
+	}
+	return p.render(target, "")
+}
@@ -0,0 +1,156 @@
+package fleet
+
+import (
+	"freessh-backend/internal/models"
+	"strings"
+	"testing"
+)
+
+func TestCommandPlanValidate(t *testing.T) {
+	target := models.ConnectionConfig{Name: "web 1", Host: "web1.example.com", Port: 22, Username: "deploy"}
+
+	tests := []struct {
+		name    string
+		plan    commandPlan
+		want    string
+		wantErr string
+	}{
+		{
+			name: "plain command",
+			plan: commandPlan{command: "uptime"},
+			want: "uptime",
+		},
+		{
+			name: "built-in variables",
+			plan: commandPlan{command: "echo {{user}}@{{host}} {{connection.name}}"},
+			want: "echo deploy@web1.example.com 'web 1'",
+		},
+		{
+			name: "values",
+			plan: commandPlan{command: "systemctl restart {{service}}", values: map[string]string{"service": "nginx"}},
+			want: "systemctl restart nginx",
+		},
+		{
+			name:    "missing value",
+			plan:    commandPlan{command: "systemctl restart {{service}}"},
+			wantErr: `missing value for parameter "service"`,
+		},
+		{
+			name: "snippet without variants",
+			plan: commandPlan{
+				command: "df -h {{path}}",
+				snippet: &models.Snippet{Command: "df -h {{path}}", Params: []models.SnippetParam{{Name: "path", Default: "/"}}},
+			},
+			want: "df -h /",
+		},
+		{
+			name: "snippet with variants",
+			plan: commandPlan{
+				command: "uptime",
+				snippet: &models.Snippet{
+					Command:  "uptime",
+					Variants: map[string]string{"windows": "systeminfo", "linux": "uptime -p"},
+				},
+			},
+			want: "uptime",
+		},
+		{
+			name: "variants only",
+			plan: commandPlan{
+				snippet: &models.Snippet{Variants: map[string]string{"windows": "systeminfo", "linux": "uptime -p"}},
+			},
+			want: "",
+		},
+		{
+			name: "variant quoted for Windows",
+			plan: commandPlan{
+				command: "ls {{dir}}",
+				values:  map[string]string{"dir": "my files"},
+				snippet: &models.Snippet{
+					Command:  "ls {{dir}}",
+					Variants: map[string]string{"windows": "dir {{dir}}"},
+				},
+			},
+			want: "ls 'my files'",
+		},
+		{
+			name: "broken variant",
+			plan: commandPlan{
+				command: "ls {{dir}}",
+				values:  map[string]string{"dir": "100%"},
+				snippet: &models.Snippet{
+					Command:  "ls {{dir}}",
+					Variants: map[string]string{"windows": "dir {{dir}}"},
+				},
+			},
+			wantErr: "windows variant",
+		},
+		{
+			name: "empty variant",
+			plan: commandPlan{
+				command: "uptime",
+				snippet: &models.Snippet{Command: "uptime", Variants: map[string]string{"macos": " "}},
+			},
+			wantErr: "snippet has no command for macos",
+		},
+		{
+			name: "broken default command",
+			plan: commandPlan{
+				command: "echo `{{msg}}`",
+				values:  map[string]string{"msg": "hi"},
+				snippet: &models.Snippet{Command: "echo `{{msg}}`", Variants: map[string]string{"linux": "echo {{msg}}"}},
+			},
+			wantErr: "backticks",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.plan.validate(target)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("validate error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("validate error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("validate = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommandPlanRenderPicksVariant(t *testing.T) {
+	target := models.ConnectionConfig{Host: "web1.example.com", Port: 22}
+	plan := commandPlan{
+		command: "uptime",
+		snippet: &models.Snippet{
+			Command:  "uptime",
+			Variants: map[string]string{"windows": "systeminfo", "linux": "uptime -p", "debian": "uptime -s"},
+		},
+	}
+
+	tests := []struct {
+		osType string
+		want   string
+	}{
+		{"", "uptime"},
+		{"windows", "systeminfo"},
+		{"ubuntu", "uptime -p"},
+		{"debian", "uptime -s"},
+		{"freebsd", "uptime"},
+	}
+
+	for _, tt := range tests {
+		got, err := plan.render(target, tt.osType)
+		if err != nil {
+			t.Fatalf("render(%q) error: %v", tt.osType, err)
+		}
+		if got != tt.want {
+			t.Errorf("render(%q) = %q, want %q", tt.osType, got, tt.want)
+		}
+	}
+}
@@ -78,6 +78,25 @@ func (m *Manager) Delete(id string) error {
 	return m.groupStorage.Delete(id)
 }
 
+// SetEnvironment replaces the variables inherited by the group's connections.
+func (m *Manager) SetEnvironment(id string, vars []models.EnvVar) (*models.Group, error) {
+	if err := models.ValidateEnvironment(vars); err != nil {
+		return nil, err
+	}
+
+	group, err := m.groupStorage.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	group.Environment = vars
+	if err := m.groupStorage.Update(*group); err != nil {
+		return nil, err
+	}
+
+	return group, nil
+}
+
 func (m *Manager) Get(id string) (*models.Group, error) {
 	return m.groupStorage.Get(id)
 }
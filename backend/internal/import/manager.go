@@ -228,10 +228,10 @@ func (m *Manager) importFreeSSH(data []byte) (*ImportResult, error) {
 			if host == nil {
 				continue
 			}
-			existingKnownHosts[fmt.Sprintf("%s|%d", host.Hostname, host.Port)] = host.Fingerprint
+			existingKnownHosts[knownHostKey(host)] = host.Fingerprint
 		}
 		for _, host := range importData.KnownHosts {
-			key := fmt.Sprintf("%s|%d", host.Hostname, host.Port)
+			key := knownHostKey(&host)
 			if existingFingerprint, exists := existingKnownHosts[key]; exists {
 				if existingFingerprint == host.Fingerprint {
 					result.Errors = append(result.Errors, fmt.Sprintf("Known host %s:%d already exists, skipping", host.Hostname, host.Port))
@@ -260,6 +260,7 @@ func (m *Manager) importOpenSSH(data []byte) (*ImportResult, error) {
 	}
 
 	result := &ImportResult{}
+	imported := make(map[string]models.ConnectionConfig)
 
 	for _, host := range hosts {
 		conn := ConvertOpenSSHToConnection(host)
@@ -283,8 +284,50 @@ func (m *Manager) importOpenSSH(data []byte) (*ImportResult, error) {
 			result.Errors = append(result.Errors, fmt.Sprintf("Failed to import connection %s: %v", conn.Name, err))
 		} else {
 			result.ConnectionsImported++
+			if len(host.ProxyJump) > 0 {
+				imported[host.Alias] = conn
+			}
+		}
+	}
+
+	// Resolve ProxyJump aliases once every host has been saved
+	if len(imported) > 0 {
+		idsByName := make(map[string]string)
+		for _, existing := range m.connectionStorage.List() {
+			idsByName[existing.Name] = existing.ID
+		}
+
+		for _, host := range hosts {
+			conn, ok := imported[host.Alias]
+			if !ok {
+				continue
+			}
+			for _, jump := range host.ProxyJump {
+				jumpID, found := idsByName[jump]
+				if !found {
+					result.Errors = append(result.Errors, fmt.Sprintf("Jump host %s for %s not found, skipping", jump, conn.Name))
+					continue
+				}
+				conn.JumpHosts = append(conn.JumpHosts, jumpID)
+			}
+			if len(conn.JumpHosts) == 0 {
+				continue
+			}
+			if err := m.connectionStorage.Save(conn); err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("Failed to set jump hosts for %s: %v", conn.Name, err))
+			}
 		}
 	}
 
 	return result, nil
 }
+
+// knownHostKey identifies a known host entry for de-duplication. Plain entries
+// are unique per key type; marker entries may repeat a host pattern with
+// different keys, so their fingerprint is included.
+func knownHostKey(host *models.KnownHost) string {
+	if host.Marker != "" {
+		return fmt.Sprintf("@%s|%s|%s", host.Marker, host.Hostname, host.Fingerprint)
+	}
+	return fmt.Sprintf("%s|%d|%s", host.Hostname, host.Port, host.KeyType)
+}
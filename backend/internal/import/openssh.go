@@ -6,10 +6,12 @@ import (
 	"freessh-backend/internal/models"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 
 	"github.com/google/uuid"
+	sshpkg "golang.org/x/crypto/ssh"
 )
 
 type OpenSSHHost struct {
@@ -18,6 +20,15 @@ type OpenSSHHost struct {
 	Port         int
 	User         string
 	IdentityFile string
+	ProxyJump    []string
+	ProxyCommand string
+	ForwardAgent bool
+	ForwardX11   bool
+	SetEnv       []models.EnvVar
+	// Network holds ConnectTimeout and ServerAlive* settings, if any.
+	Network models.NetworkOptions
+	// Crypto holds KexAlgorithms, Ciphers, MACs and HostKeyAlgorithms.
+	Crypto models.CryptoPolicy
 }
 
 func ParseOpenSSHConfig(data []byte) ([]OpenSSHHost, error) {
@@ -76,6 +87,65 @@ func ParseOpenSSHConfig(data []byte) ([]OpenSSHHost, error) {
 				}
 				currentHost.IdentityFile = value
 			}
+		case "proxyjump":
+			if currentHost != nil && !strings.EqualFold(value, "none") {
+				for _, jump := range strings.Split(value, ",") {
+					if jump = strings.TrimSpace(jump); jump != "" {
+						currentHost.ProxyJump = append(currentHost.ProxyJump, jump)
+					}
+				}
+			}
+		case "proxycommand":
+			if currentHost != nil && !strings.EqualFold(value, "none") {
+				// Keep the command's own spacing and quoting
+				currentHost.ProxyCommand = strings.TrimSpace(line[len(parts[0]):])
+			}
+		case "connecttimeout":
+			if currentHost != nil {
+				if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+					currentHost.Network.ConnectTimeout = seconds
+				}
+			}
+		case "serveraliveinterval":
+			if currentHost != nil {
+				if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+					currentHost.Network.KeepAliveInterval = seconds
+				}
+			}
+		case "serveralivecountmax":
+			if currentHost != nil {
+				if count, err := strconv.Atoi(value); err == nil && count > 0 {
+					currentHost.Network.KeepAliveMaxMissed = count
+				}
+			}
+		case "kexalgorithms":
+			if currentHost != nil {
+				currentHost.Crypto.KeyExchanges = parseAlgorithmList(value, sshpkg.SupportedAlgorithms().KeyExchanges, sshpkg.InsecureAlgorithms().KeyExchanges)
+			}
+		case "ciphers":
+			if currentHost != nil {
+				currentHost.Crypto.Ciphers = parseAlgorithmList(value, sshpkg.SupportedAlgorithms().Ciphers, sshpkg.InsecureAlgorithms().Ciphers)
+			}
+		case "macs":
+			if currentHost != nil {
+				currentHost.Crypto.MACs = parseAlgorithmList(value, sshpkg.SupportedAlgorithms().MACs, sshpkg.InsecureAlgorithms().MACs)
+			}
+		case "hostkeyalgorithms":
+			if currentHost != nil {
+				currentHost.Crypto.HostKeyAlgorithms = parseAlgorithmList(value, sshpkg.SupportedAlgorithms().HostKeys, sshpkg.InsecureAlgorithms().HostKeys)
+			}
+		case "forwardagent":
+			if currentHost != nil {
+				currentHost.ForwardAgent = strings.EqualFold(value, "yes")
+			}
+		case "forwardx11":
+			if currentHost != nil {
+				currentHost.ForwardX11 = strings.EqualFold(value, "yes")
+			}
+		case "setenv":
+			if currentHost != nil {
+				currentHost.SetEnv = append(currentHost.SetEnv, parseSetEnv(strings.TrimSpace(line[len(parts[0]):]))...)
+			}
 		}
 	}
 
@@ -99,6 +169,21 @@ func ConvertOpenSSHToConnection(host OpenSSHHost) models.ConnectionConfig {
 		Port:       host.Port,
 		Username:   host.User,
 		AuthMethod: models.AuthPassword, // Default to password
+
+		ProxyCommand: host.ProxyCommand,
+		ForwardAgent: host.ForwardAgent,
+		ForwardX11:   host.ForwardX11,
+		Environment:  models.MergeEnvironment(nil, host.SetEnv),
+	}
+
+	if host.Network != (models.NetworkOptions{}) {
+		network := host.Network
+		conn.Network = &network
+	}
+
+	if !host.Crypto.IsZero() {
+		crypto := host.Crypto
+		conn.Crypto = &crypto
 	}
 
 	// If identity file is specified, use public key auth
@@ -109,3 +194,88 @@ func ConvertOpenSSHToConnection(host OpenSSHHost) models.ConnectionConfig {
 
 	return conn
 }
+
+// parseSetEnv splits a SetEnv value into NAME=value pairs. Values may be
+// double-quoted to include spaces. Malformed entries are dropped.
+func parseSetEnv(value string) []models.EnvVar {
+	var vars []models.EnvVar
+	var token strings.Builder
+	inQuotes := false
+	flush := func() {
+		name, val, ok := strings.Cut(token.String(), "=")
+		token.Reset()
+		if !ok || models.ValidateEnvironment([]models.EnvVar{{Name: name}}) != nil {
+			return
+		}
+		vars = append(vars, models.EnvVar{Name: name, Value: val})
+	}
+
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case c == '\\' && inQuotes && i+1 < len(value):
+			i++
+			token.WriteByte(value[i])
+		case c == '"':
+			inQuotes = !inQuotes
+		case (c == ' ' || c == '\t') && !inQuotes:
+			if token.Len() > 0 {
+				flush()
+			}
+		default:
+			token.WriteByte(c)
+		}
+	}
+	if token.Len() > 0 {
+		flush()
+	}
+	return vars
+}
+
+// parseAlgorithmList expands an OpenSSH algorithm list. A leading "+" appends
+// to defaults, "-" removes from them and "^" moves the entries to the front.
+// Names the SSH library does not implement (neither in defaults nor in
+// insecure) are dropped.
+func parseAlgorithmList(value string, defaults, insecure []string) []string {
+	if value == "" {
+		return nil
+	}
+
+	modifier := value[0]
+	if modifier == '+' || modifier == '-' || modifier == '^' {
+		value = value[1:]
+	}
+
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if slices.Contains(defaults, name) || slices.Contains(insecure, name) {
+			names = append(names, name)
+		}
+	}
+
+	switch modifier {
+	case '+':
+		return append(append([]string{}, defaults...), names...)
+	case '-':
+		result := make([]string, 0, len(defaults))
+		for _, name := range defaults {
+			if !slices.Contains(names, name) {
+				result = append(result, name)
+			}
+		}
+		return result
+	case '^':
+		result := append([]string{}, names...)
+		for _, name := range defaults {
+			if !slices.Contains(names, name) {
+				result = append(result, name)
+			}
+		}
+		return result
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	return names
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"freessh-backend/internal/models"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// authPromptTimeout is longer than the host key timeout to leave room for
+// fetching a one-time code.
+const authPromptTimeout = 2 * time.Minute
+
+type AuthPromptHelper struct {
+	promptChannels map[string]chan models.AuthPromptResponse
+	mu             sync.Mutex
+}
+
+func NewAuthPromptHelper() *AuthPromptHelper {
+	return &AuthPromptHelper{
+		promptChannels: make(map[string]chan models.AuthPromptResponse),
+	}
+}
+
+func (h *AuthPromptHelper) CreatePromptCallback(writer ResponseWriter) func(*models.AuthPrompt) ([]string, error) {
+	return func(prompt *models.AuthPrompt) ([]string, error) {
+		prompt.ID = uuid.New().String()
+
+		responseChan := make(chan models.AuthPromptResponse, 1)
+		h.mu.Lock()
+		h.promptChannels[prompt.ID] = responseChan
+		h.mu.Unlock()
+
+		defer func() {
+			h.mu.Lock()
+			delete(h.promptChannels, prompt.ID)
+			h.mu.Unlock()
+		}()
+
+		if err := writer.WriteMessage(&models.IPCMessage{
+			Type: models.MsgAuthPrompt,
+			Data: prompt,
+		}); err != nil {
+			return nil, err
+		}
+
+		select {
+		case response := <-responseChan:
+			if response.Cancelled {
+				return nil, fmt.Errorf("authentication cancelled by user")
+			}
+			return response.Answers, nil
+		case <-time.After(authPromptTimeout):
+			return nil, fmt.Errorf("authentication prompt timeout")
+		}
+	}
+}
+
+func (h *AuthPromptHelper) HandlePromptResponse(msg *models.IPCMessage) error {
+	jsonData, err := json.Marshal(msg.Data)
+	if err != nil {
+		return fmt.Errorf("invalid prompt response data: %w", err)
+	}
+
+	var response models.AuthPromptResponse
+	if err := json.Unmarshal(jsonData, &response); err != nil {
+		return fmt.Errorf("failed to parse prompt response: %w", err)
+	}
+
+	h.mu.Lock()
+	responseChan, exists := h.promptChannels[response.ID]
+	h.mu.Unlock()
+
+	if !exists {
+		return fmt.Errorf("no pending auth prompt %s", response.ID)
+	}
+
+	select {
+	case responseChan <- response:
+	default:
+		return fmt.Errorf("auth prompt %s already answered", response.ID)
+	}
+	return nil
+}
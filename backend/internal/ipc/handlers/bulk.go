@@ -49,7 +49,7 @@ func (h *BulkHandler) handleBulkDownload(msg *models.IPCMessage, writer Response
 		return fmt.Errorf("failed to parse bulk download request: %w", err)
 	}
 
-	results, err := h.manager.BulkDownload(msg.SessionID, req.RemotePaths, req.LocalBaseDir, func(progress models.BulkProgress) {
+	results, err := h.manager.BulkDownload(msg.SessionID, req.RemotePaths, req.LocalBaseDir, req.TransferID, req.RateLimit, func(progress models.BulkProgress) {
 		go writer.WriteMessage(&models.IPCMessage{
 			Type:      models.MsgBulkProgress,
 			SessionID: msg.SessionID,
@@ -79,7 +79,7 @@ func (h *BulkHandler) handleBulkUpload(msg *models.IPCMessage, writer ResponseWr
 		return fmt.Errorf("failed to parse bulk upload request: %w", err)
 	}
 
-	results, err := h.manager.BulkUpload(msg.SessionID, req.LocalPaths, req.RemoteBaseDir, func(progress models.BulkProgress) {
+	results, err := h.manager.BulkUpload(msg.SessionID, req.LocalPaths, req.RemoteBaseDir, req.TransferID, req.RateLimit, func(progress models.BulkProgress) {
 		go writer.WriteMessage(&models.IPCMessage{
 			Type:      models.MsgBulkProgress,
 			SessionID: msg.SessionID,
@@ -12,17 +12,19 @@ import (
 type ConnectionHandler struct {
 	manager            *session.Manager
 	verificationHelper *HostKeyVerificationHelper
+	promptHelper       *AuthPromptHelper
 	keyStorage         *storage.KeyStorage
 	keyFileStorage     *storage.KeyFileStorage
 }
 
-func NewConnectionHandler(manager *session.Manager, verificationHelper *HostKeyVerificationHelper) *ConnectionHandler {
+func NewConnectionHandler(manager *session.Manager, verificationHelper *HostKeyVerificationHelper, promptHelper *AuthPromptHelper) *ConnectionHandler {
 	keyStorage, _ := storage.NewKeyStorage()
 	keyFileStorage, _ := storage.NewKeyFileStorage()
 
 	return &ConnectionHandler{
 		manager:            manager,
 		verificationHelper: verificationHelper,
+		promptHelper:       promptHelper,
 		keyStorage:         keyStorage,
 		keyFileStorage:     keyFileStorage,
 	}
@@ -120,6 +122,9 @@ func (h *ConnectionHandler) handleUpdate(msg *models.IPCMessage, writer Response
 		return fmt.Errorf("failed to parse connection config: %w", err)
 	}
 	config.Profile = models.NormalizeSessionProfile(config.Profile)
+	if err := models.ValidateEnvironment(config.Environment); err != nil {
+		return err
+	}
 
 	// Migrate embedded key to key storage if present
 	if h.keyStorage != nil && h.keyFileStorage != nil {
@@ -162,7 +167,8 @@ func (h *ConnectionHandler) handleConnect(msg *models.IPCMessage, writer Respons
 	}
 
 	verificationCallback := h.verificationHelper.CreateVerificationCallback(writer)
-	session, err := h.manager.CreateSessionWithVerification(config, verificationCallback)
+	promptCallback := h.promptHelper.CreatePromptCallback(writer)
+	session, err := h.manager.CreateSessionWithPrompts(config, verificationCallback, promptCallback)
 	if err != nil {
 		return err
 	}
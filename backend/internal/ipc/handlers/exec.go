@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"freessh-backend/internal/models"
+	"freessh-backend/internal/session"
+
+	"github.com/google/uuid"
+)
+
+type ExecHandler struct {
+	manager *session.Manager
+}
+
+func NewExecHandler(manager *session.Manager) *ExecHandler {
+	return &ExecHandler{
+		manager: manager,
+	}
+}
+
+func (h *ExecHandler) CanHandle(msgType models.MessageType) bool {
+	switch msgType {
+	case models.MsgExecRun, models.MsgExecCancel:
+		return true
+	}
+	return false
+}
+
+func (h *ExecHandler) Handle(msg *models.IPCMessage, writer ResponseWriter) error {
+	switch msg.Type {
+	case models.MsgExecRun:
+		return h.handleRun(msg, writer)
+	case models.MsgExecCancel:
+		return h.handleCancel(msg, writer)
+	default:
+		return fmt.Errorf("unsupported message type: %s", msg.Type)
+	}
+}
+
+// handleRun streams exec:output chunks while the command runs and finishes
+// with an exec:run message carrying its result.
+func (h *ExecHandler) handleRun(msg *models.IPCMessage, writer ResponseWriter) error {
+	jsonData, err := json.Marshal(msg.Data)
+	if err != nil {
+		return fmt.Errorf("invalid data: %w", err)
+	}
+
+	var req models.ExecRequest
+	if err := json.Unmarshal(jsonData, &req); err != nil {
+		return fmt.Errorf("failed to parse exec request: %w", err)
+	}
+	if req.SessionID == "" {
+		req.SessionID = msg.SessionID
+	}
+	if req.ExecID == "" {
+		req.ExecID = uuid.New().String()
+	}
+
+	result, err := h.manager.RunCommand(req, func(chunk models.ExecOutput) {
+		_ = writer.WriteMessage(&models.IPCMessage{
+			Type:      models.MsgExecOutput,
+			SessionID: req.SessionID,
+			Data:      chunk,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	return writer.WriteMessage(&models.IPCMessage{
+		Type:      models.MsgExecRun,
+		SessionID: req.SessionID,
+		Data:      result,
+	})
+}
+
+func (h *ExecHandler) handleCancel(msg *models.IPCMessage, writer ResponseWriter) error {
+	jsonData, err := json.Marshal(msg.Data)
+	if err != nil {
+		return fmt.Errorf("invalid data: %w", err)
+	}
+
+	var req struct {
+		ExecID string `json:"exec_id"`
+	}
+	if err := json.Unmarshal(jsonData, &req); err != nil {
+		return fmt.Errorf("failed to parse cancel request: %w", err)
+	}
+
+	cancelled := h.manager.CancelCommand(req.ExecID)
+
+	return writer.WriteMessage(&models.IPCMessage{
+		Type: models.MsgExecCancel,
+		Data: map[string]interface{}{"exec_id": req.ExecID, "cancelled": cancelled},
+	})
+}
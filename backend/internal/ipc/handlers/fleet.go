@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"freessh-backend/internal/fleet"
+	"freessh-backend/internal/models"
+	"freessh-backend/internal/session"
+	"freessh-backend/internal/storage"
+)
+
+type FleetHandler struct {
+	manager *fleet.Manager
+}
+
+func NewFleetHandler(sessions *session.Manager, groupStorage *storage.GroupStorage, snippetStorage *storage.SnippetStorage, jobStorage *storage.FleetJobStorage) *FleetHandler {
+	return &FleetHandler{
+		manager: fleet.NewManager(sessions, groupStorage, snippetStorage, jobStorage),
+	}
+}
+
+func (h *FleetHandler) CanHandle(msgType models.MessageType) bool {
+	switch msgType {
+	case models.MsgFleetRun, models.MsgFleetCancel, models.MsgFleetList, models.MsgFleetGet, models.MsgFleetDelete:
+		return true
+	}
+	return false
+}
+
+func (h *FleetHandler) Handle(msg *models.IPCMessage, writer ResponseWriter) error {
+	switch msg.Type {
+	case models.MsgFleetRun:
+		return h.handleRun(msg, writer)
+	case models.MsgFleetCancel:
+		return h.handleCancel(msg, writer)
+	case models.MsgFleetList:
+		return h.handleList(writer)
+	case models.MsgFleetGet:
+		return h.handleGet(msg, writer)
+	case models.MsgFleetDelete:
+		return h.handleDelete(msg, writer)
+	default:
+		return fmt.Errorf("unsupported message type: %s", msg.Type)
+	}
+}
+
+// handleRun streams fleet:host and fleet:output messages while the job runs
+// and finishes with a fleet:run message carrying the job record.
+func (h *FleetHandler) handleRun(msg *models.IPCMessage, writer ResponseWriter) error {
+	jsonData, err := json.Marshal(msg.Data)
+	if err != nil {
+		return fmt.Errorf("invalid data: %w", err)
+	}
+
+	var req models.FleetRunRequest
+	if err := json.Unmarshal(jsonData, &req); err != nil {
+		return fmt.Errorf("failed to parse fleet run request: %w", err)
+	}
+
+	job, err := h.manager.Run(req, fleet.Events{
+		Output: func(chunk models.FleetOutput) {
+			_ = writer.WriteMessage(&models.IPCMessage{
+				Type: models.MsgFleetOutput,
+				Data: chunk,
+			})
+		},
+		Host: func(update models.FleetHostUpdate) {
+			_ = writer.WriteMessage(&models.IPCMessage{
+				Type: models.MsgFleetHost,
+				Data: update,
+			})
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return writer.WriteMessage(&models.IPCMessage{
+		Type: models.MsgFleetRun,
+		Data: job,
+	})
+}
+
+func (h *FleetHandler) handleCancel(msg *models.IPCMessage, writer ResponseWriter) error {
+	id, err := parseFleetJobID(msg)
+	if err != nil {
+		return err
+	}
+
+	cancelled := h.manager.Cancel(id)
+
+	return writer.WriteMessage(&models.IPCMessage{
+		Type: models.MsgFleetCancel,
+		Data: map[string]interface{}{"job_id": id, "cancelled": cancelled},
+	})
+}
+
+func (h *FleetHandler) handleList(writer ResponseWriter) error {
+	return writer.WriteMessage(&models.IPCMessage{
+		Type: models.MsgFleetList,
+		Data: h.manager.List(),
+	})
+}
+
+func (h *FleetHandler) handleGet(msg *models.IPCMessage, writer ResponseWriter) error {
+	id, err := parseFleetJobID(msg)
+	if err != nil {
+		return err
+	}
+
+	job, err := h.manager.Get(id)
+	if err != nil {
+		return err
+	}
+
+	return writer.WriteMessage(&models.IPCMessage{
+		Type: models.MsgFleetGet,
+		Data: job,
+	})
+}
+
+func (h *FleetHandler) handleDelete(msg *models.IPCMessage, writer ResponseWriter) error {
+	id, err := parseFleetJobID(msg)
+	if err != nil {
+		return err
+	}
+
+	if err := h.manager.Delete(id); err != nil {
+		return err
+	}
+
+	return writer.WriteMessage(&models.IPCMessage{
+		Type: models.MsgFleetDelete,
+		Data: map[string]string{"status": "deleted", "job_id": id},
+	})
+}
+
+func parseFleetJobID(msg *models.IPCMessage) (string, error) {
+	jsonData, err := json.Marshal(msg.Data)
+	if err != nil {
+		return "", fmt.Errorf("invalid data: %w", err)
+	}
+
+	var req struct {
+		JobID string `json:"job_id"`
+	}
+	if err := json.Unmarshal(jsonData, &req); err != nil {
+		return "", fmt.Errorf("failed to parse request: %w", err)
+	}
+	if req.JobID == "" {
+		return "", fmt.Errorf("job_id is required")
+	}
+	return req.JobID, nil
+}
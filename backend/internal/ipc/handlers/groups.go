@@ -22,7 +22,8 @@ func (h *GroupHandler) CanHandle(msgType models.MessageType) bool {
 	return msgType == models.MsgGroupList ||
 		msgType == models.MsgGroupCreate ||
 		msgType == models.MsgGroupRename ||
-		msgType == models.MsgGroupDelete
+		msgType == models.MsgGroupDelete ||
+		msgType == models.MsgGroupSetEnvironment
 }
 
 func (h *GroupHandler) Handle(msg *models.IPCMessage, writer ResponseWriter) error {
@@ -35,6 +36,8 @@ func (h *GroupHandler) Handle(msg *models.IPCMessage, writer ResponseWriter) err
 		return h.handleRename(msg, writer)
 	case models.MsgGroupDelete:
 		return h.handleDelete(msg, writer)
+	case models.MsgGroupSetEnvironment:
+		return h.handleSetEnvironment(msg, writer)
 	default:
 		return fmt.Errorf("unsupported message type: %s", msg.Type)
 	}
@@ -130,3 +133,30 @@ func (h *GroupHandler) handleDelete(msg *models.IPCMessage, writer ResponseWrite
 		},
 	})
 }
+
+func (h *GroupHandler) handleSetEnvironment(msg *models.IPCMessage, writer ResponseWriter) error {
+	jsonData, err := json.Marshal(msg.Data)
+	if err != nil {
+		return fmt.Errorf("invalid environment data: %w", err)
+	}
+
+	var data struct {
+		ID          string          `json:"id"`
+		Environment []models.EnvVar `json:"environment"`
+	}
+	if err := json.Unmarshal(jsonData, &data); err != nil {
+		return fmt.Errorf("failed to parse environment data: %w", err)
+	}
+
+	group, err := h.manager.SetEnvironment(data.ID, data.Environment)
+	if err != nil {
+		return err
+	}
+
+	return writer.WriteMessage(&models.IPCMessage{
+		Type: models.MsgGroupSetEnvironment,
+		Data: map[string]interface{}{
+			"group": group,
+		},
+	})
+}
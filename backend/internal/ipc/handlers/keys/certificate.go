@@ -0,0 +1,131 @@
+package keys
+
+import (
+	"encoding/json"
+	"fmt"
+	"freessh-backend/internal/ipc/handlers"
+	"freessh-backend/internal/keygen"
+	"freessh-backend/internal/models"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func (h *Handler) handleAttachCertificate(msg *models.IPCMessage, writer handlers.ResponseWriter) error {
+	if h.fileStorage == nil {
+		return fmt.Errorf("key file storage not available")
+	}
+
+	dataMap, ok := msg.Data.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("invalid data format")
+	}
+
+	keyID, ok := dataMap["key_id"].(string)
+	if !ok {
+		return fmt.Errorf("key_id required")
+	}
+	certificate, _ := dataMap["certificate"].(string)
+
+	// An empty certificate detaches the current one
+	if certificate == "" {
+		if err := h.fileStorage.DeleteCertificate(keyID); err != nil {
+			return err
+		}
+		return writer.WriteMessage(&models.IPCMessage{
+			Type: models.MsgKeyAttachCertificate,
+			Data: map[string]string{"status": "detached", "key_id": keyID},
+		})
+	}
+
+	if err := h.attachCertificate(keyID, certificate); err != nil {
+		return err
+	}
+
+	return writer.WriteMessage(&models.IPCMessage{
+		Type: models.MsgKeyAttachCertificate,
+		Data: map[string]string{"status": "attached", "key_id": keyID},
+	})
+}
+
+func (h *Handler) handleSignCertificate(msg *models.IPCMessage, writer handlers.ResponseWriter) error {
+	if h.fileStorage == nil {
+		return fmt.Errorf("key file storage not available")
+	}
+
+	jsonData, err := json.Marshal(msg.Data)
+	if err != nil {
+		return fmt.Errorf("invalid data: %w", err)
+	}
+
+	var req models.CertificateSignRequest
+	if err := json.Unmarshal(jsonData, &req); err != nil {
+		return fmt.Errorf("failed to parse sign request: %w", err)
+	}
+
+	if req.CAKeyID == "" {
+		return fmt.Errorf("ca_key_id required")
+	}
+
+	certType := uint32(ssh.UserCert)
+	switch req.CertType {
+	case "", "user":
+	case "host":
+		certType = ssh.HostCert
+	default:
+		return fmt.Errorf("invalid cert_type: %s", req.CertType)
+	}
+
+	publicKey := req.PublicKey
+	if req.KeyID != "" {
+		key, err := h.storage.Get(req.KeyID)
+		if err != nil {
+			return err
+		}
+		publicKey = key.PublicKey
+	}
+	if publicKey == "" {
+		return fmt.Errorf("public_key or key_id required")
+	}
+
+	caPrivateKey, err := h.fileStorage.GetPrivateKey(req.CAKeyID)
+	if err != nil {
+		return fmt.Errorf("CA private key not found: %w", err)
+	}
+
+	certificate, err := keygen.SignCertificate(caPrivateKey, req.CAPassphrase, publicKey, keygen.CertificateOptions{
+		CertType:        certType,
+		Identity:        req.Identity,
+		Principals:      req.Principals,
+		ValidAfter:      req.ValidAfter,
+		ValidBefore:     req.ValidBefore,
+		CriticalOptions: req.CriticalOptions,
+		Extensions:      req.Extensions,
+	})
+	if err != nil {
+		return err
+	}
+
+	if req.KeyID != "" {
+		if err := h.attachCertificate(req.KeyID, certificate); err != nil {
+			return err
+		}
+	}
+
+	return writer.WriteMessage(&models.IPCMessage{
+		Type: models.MsgKeySignCertificate,
+		Data: map[string]string{"certificate": certificate, "key_id": req.KeyID},
+	})
+}
+
+func (h *Handler) attachCertificate(keyID string, certificate string) error {
+	key, err := h.storage.Get(keyID)
+	if err != nil {
+		return err
+	}
+
+	if err := keygen.ValidateCertificate(certificate, key.PublicKey); err != nil {
+		return err
+	}
+
+	return h.fileStorage.SaveCertificate(keyID, certificate)
+}
@@ -83,6 +83,7 @@ func (h *Handler) handleDelete(msg *models.IPCMessage, writer handlers.ResponseW
 	// Delete private key file
 	if h.fileStorage != nil {
 		_ = h.fileStorage.DeletePrivateKey(id) // Ignore error if file doesn't exist
+		_ = h.fileStorage.DeleteCertificate(id)
 	}
 
 	if err := h.storage.Delete(id); err != nil {
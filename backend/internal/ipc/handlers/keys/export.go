@@ -44,7 +44,7 @@ func (h *Handler) handleExport(msg *models.IPCMessage, writer handlers.ResponseW
 	}
 
 	// Export key to the connection
-	if err := ssh.ExportKeyToConnection(*config, key.PublicKey, privateKey, key.Name); err != nil {
+	if err := ssh.ExportKeyToConnection(*config, h.manager.ProxyFor(*config), key.PublicKey, privateKey, key.Name); err != nil {
 		return err
 	}
 
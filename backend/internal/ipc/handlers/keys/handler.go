@@ -34,7 +34,8 @@ func NewHandler(manager *session.Manager) *Handler {
 
 func (h *Handler) CanHandle(msgType models.MessageType) bool {
 	switch msgType {
-	case models.MsgKeyList, models.MsgKeySave, models.MsgKeyImport, models.MsgKeyUpdate, models.MsgKeyDelete, models.MsgKeyExport:
+	case models.MsgKeyList, models.MsgKeySave, models.MsgKeyImport, models.MsgKeyUpdate, models.MsgKeyDelete, models.MsgKeyExport,
+		models.MsgKeyAttachCertificate, models.MsgKeySignCertificate:
 		return true
 	}
 	return false
@@ -58,6 +59,10 @@ func (h *Handler) Handle(msg *models.IPCMessage, writer handlers.ResponseWriter)
 		return h.handleDelete(msg, writer)
 	case models.MsgKeyExport:
 		return h.handleExport(msg, writer)
+	case models.MsgKeyAttachCertificate:
+		return h.handleAttachCertificate(msg, writer)
+	case models.MsgKeySignCertificate:
+		return h.handleSignCertificate(msg, writer)
 	default:
 		return fmt.Errorf("unsupported message type: %s", msg.Type)
 	}
@@ -1,14 +1,13 @@
 package handlers
 
 import (
-	"bufio"
 	"encoding/base64"
 	"fmt"
+	"freessh-backend/internal/knownhosts"
 	"freessh-backend/internal/models"
 	"freessh-backend/internal/storage"
 	"os"
 	"path/filepath"
-	"strings"
 
 	"golang.org/x/crypto/ssh"
 )
@@ -25,7 +24,8 @@ func NewKnownHostsHandler(storage *storage.KnownHostStorage) *KnownHostsHandler
 
 func (h *KnownHostsHandler) CanHandle(msgType models.MessageType) bool {
 	switch msgType {
-	case models.MsgKnownHostList, models.MsgKnownHostRemove, models.MsgKnownHostTrust, models.MsgKnownHostImport:
+	case models.MsgKnownHostList, models.MsgKnownHostRemove, models.MsgKnownHostTrust, models.MsgKnownHostImport,
+		models.MsgKnownHostExport:
 		return true
 	}
 	return false
@@ -41,6 +41,8 @@ func (h *KnownHostsHandler) Handle(msg *models.IPCMessage, writer ResponseWriter
 		return h.handleTrust(msg, writer)
 	case models.MsgKnownHostImport:
 		return h.handleImport(msg, writer)
+	case models.MsgKnownHostExport:
+		return h.handleExport(msg, writer)
 	default:
 		return fmt.Errorf("unsupported message type: %s", msg.Type)
 	}
@@ -85,19 +87,38 @@ func (h *KnownHostsHandler) handleTrust(msg *models.IPCMessage, writer ResponseW
 	port, _ := dataMap["port"].(float64)
 	fingerprint, _ := dataMap["fingerprint"].(string)
 	publicKey, _ := dataMap["publicKey"].(string)
+	marker, _ := dataMap["marker"].(string)
+
+	// Marker entries take host patterns and a public key instead of a port
+	if marker != "" {
+		host, err := markerEntry(marker, hostname, publicKey)
+		if err != nil {
+			return err
+		}
+		if err := h.storage.Add(host); err != nil {
+			return err
+		}
+		return writer.WriteMessage(&models.IPCMessage{
+			Type: models.MsgKnownHostTrust,
+			Data: map[string]string{"status": "trusted", "hostname": hostname, "marker": marker},
+		})
+	}
 
 	if hostname == "" || port == 0 || fingerprint == "" {
 		return fmt.Errorf("hostname, port, and fingerprint required")
 	}
 
+	keyType, _ := dataMap["keyType"].(string)
+
 	host := &models.KnownHost{
 		Hostname:    hostname,
 		Port:        int(port),
 		Fingerprint: fingerprint,
 		PublicKey:   publicKey,
+		KeyType:     keyType,
 	}
 
-	if err := h.storage.Add(host); err != nil {
+	if err := h.storage.SaveHostKey(host); err != nil {
 		return err
 	}
 
@@ -108,83 +129,136 @@ func (h *KnownHostsHandler) handleTrust(msg *models.IPCMessage, writer ResponseW
 }
 
 func (h *KnownHostsHandler) handleImport(msg *models.IPCMessage, writer ResponseWriter) error {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return fmt.Errorf("failed to get home directory: %w", err)
+	// Content may be passed directly (e.g. from a file picker); otherwise the
+	// user's OpenSSH known_hosts file is read
+	var content string
+	if dataMap, ok := msg.Data.(map[string]interface{}); ok {
+		content, _ = dataMap["data"].(string)
 	}
 
-	knownHostsPath := filepath.Join(homeDir, ".ssh", "known_hosts")
-	file, err := os.Open(knownHostsPath)
-	if err != nil {
-		return fmt.Errorf("failed to open known_hosts file: %w", err)
-	}
-	defer file.Close()
-
-	imported := 0
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
+	if content == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("failed to get home directory: %w", err)
 		}
 
-		parts := strings.Fields(line)
-		if len(parts) < 3 {
-			continue
+		knownHostsPath := filepath.Join(homeDir, ".ssh", "known_hosts")
+		data, err := os.ReadFile(knownHostsPath)
+		if err != nil {
+			return fmt.Errorf("failed to open known_hosts file: %w", err)
 		}
+		content = string(data)
+	}
+
+	entries, parseErrs := knownhosts.Parse([]byte(content))
+	importErrors := make([]string, 0, len(parseErrs))
+	for _, err := range parseErrs {
+		importErrors = append(importErrors, err.Error())
+	}
 
-		// Parse hostname (may include port like [hostname]:port)
-		hostPart := parts[0]
-		hostname := hostPart
-		port := 22
-
-		if strings.HasPrefix(hostPart, "[") && strings.Contains(hostPart, "]:") {
-			// Format: [hostname]:port
-			endBracket := strings.Index(hostPart, "]:")
-			if endBracket > 0 {
-				hostname = hostPart[1:endBracket]
-				fmt.Sscanf(hostPart[endBracket+2:], "%d", &port)
+	imported := 0
+	for _, entry := range entries {
+		for _, host := range knownhosts.ToKnownHosts(entry) {
+			if h.exists(&host) {
+				continue
+			}
+			if err := h.storage.Add(&host); err != nil {
+				importErrors = append(importErrors, err.Error())
+				continue
 			}
+			imported++
 		}
+	}
 
-		// Parse public key
-		keyData := parts[2]
+	return writer.WriteMessage(&models.IPCMessage{
+		Type: models.MsgKnownHostImport,
+		Data: map[string]interface{}{"status": "imported", "count": imported, "errors": importErrors},
+	})
+}
 
-		keyBytes, err := base64.StdEncoding.DecodeString(keyData)
-		if err != nil {
-			continue
-		}
+func (h *KnownHostsHandler) handleExport(msg *models.IPCMessage, writer ResponseWriter) error {
+	hashed := false
+	if dataMap, ok := msg.Data.(map[string]interface{}); ok {
+		hashed, _ = dataMap["hashed"].(bool)
+	}
 
-		pubKey, err := ssh.ParsePublicKey(keyBytes)
+	var entries []knownhosts.Entry
+	skipped := make([]string, 0)
+	for _, host := range h.storage.GetAll() {
+		entry, err := knownhosts.FromKnownHost(*host, hashed)
 		if err != nil {
+			// Hosts trusted by fingerprint only cannot be written out
+			skipped = append(skipped, err.Error())
 			continue
 		}
+		entries = append(entries, entry)
+	}
 
-		fingerprint := ssh.FingerprintSHA256(pubKey)
+	return writer.WriteMessage(&models.IPCMessage{
+		Type: models.MsgKnownHostExport,
+		Data: models.KnownHostsExportResponse{
+			Data:     string(knownhosts.Marshal(entries)),
+			Filename: "known_hosts",
+			Count:    len(entries),
+			Skipped:  skipped,
+		},
+	})
+}
 
-		// Check if already exists
-		if h.storage.Get(hostname, port) != nil {
-			continue
-		}
+// exists reports whether an equivalent record is already stored: the same
+// key type for a literal host, or the same key for patterns and markers.
+func (h *KnownHostsHandler) exists(host *models.KnownHost) bool {
+	if host.Marker == "" && host.Port != 0 {
+		return hasKeyType(h.storage.GetKeys(host.Hostname, host.Port), host.KeyType)
+	}
 
-		host := &models.KnownHost{
-			Hostname:    hostname,
-			Port:        port,
-			Fingerprint: fingerprint,
-			PublicKey:   keyData,
+	candidates := h.storage.GetPatternEntries()
+	if host.Marker != "" {
+		candidates = h.storage.GetByMarker(host.Marker)
+	}
+	for _, existing := range candidates {
+		if existing.Hostname == host.Hostname && existing.Fingerprint == host.Fingerprint {
+			return true
 		}
+	}
+	return false
+}
 
-		if err := h.storage.Add(host); err == nil {
-			imported++
-		}
+// markerEntry builds a @cert-authority or @revoked entry. publicKey may be in
+// authorized_keys format or the bare base64 blob used in known_hosts.
+func markerEntry(marker string, patterns string, publicKey string) (*models.KnownHost, error) {
+	if marker != models.KnownHostMarkerCertAuthority && marker != models.KnownHostMarkerRevoked {
+		return nil, fmt.Errorf("unsupported known host marker: %s", marker)
+	}
+	if patterns == "" || publicKey == "" {
+		return nil, fmt.Errorf("host patterns and publicKey required")
 	}
 
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("error reading known_hosts: %w", err)
+	pubKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(publicKey))
+	if err != nil {
+		keyBytes, decodeErr := base64.StdEncoding.DecodeString(publicKey)
+		if decodeErr != nil {
+			return nil, fmt.Errorf("invalid public key: %w", err)
+		}
+		if pubKey, err = ssh.ParsePublicKey(keyBytes); err != nil {
+			return nil, fmt.Errorf("invalid public key: %w", err)
+		}
 	}
 
-	return writer.WriteMessage(&models.IPCMessage{
-		Type: models.MsgKnownHostImport,
-		Data: map[string]interface{}{"status": "imported", "count": imported},
-	})
+	return &models.KnownHost{
+		Hostname:    patterns,
+		Port:        0,
+		Fingerprint: ssh.FingerprintSHA256(pubKey),
+		PublicKey:   base64.StdEncoding.EncodeToString(pubKey.Marshal()),
+		Marker:      marker,
+	}, nil
+}
+
+func hasKeyType(hosts []*models.KnownHost, keyType string) bool {
+	for _, host := range hosts {
+		if host.KeyType == keyType || host.KeyType == "" {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"freessh-backend/internal/models"
+	"freessh-backend/internal/settings"
+	"freessh-backend/internal/ssh"
+)
+
+type NetworkSettingsHandler struct {
+	storage *settings.NetworkSettingsStorage
+}
+
+func NewNetworkSettingsHandler(storage *settings.NetworkSettingsStorage) *NetworkSettingsHandler {
+	return &NetworkSettingsHandler{
+		storage: storage,
+	}
+}
+
+func (h *NetworkSettingsHandler) CanHandle(msgType models.MessageType) bool {
+	return msgType == models.MsgNetworkSettingsGet || msgType == models.MsgNetworkSettingsUpdate
+}
+
+func (h *NetworkSettingsHandler) Handle(msg *models.IPCMessage, writer ResponseWriter) error {
+	if h.storage == nil {
+		return fmt.Errorf("network settings storage not available")
+	}
+
+	switch msg.Type {
+	case models.MsgNetworkSettingsGet:
+		return h.handleGet(writer)
+	case models.MsgNetworkSettingsUpdate:
+		return h.handleUpdate(msg, writer)
+	default:
+		return fmt.Errorf("unsupported message type: %s", msg.Type)
+	}
+}
+
+func (h *NetworkSettingsHandler) handleGet(writer ResponseWriter) error {
+	settings := h.storage.Get()
+	return writer.WriteMessage(&models.IPCMessage{
+		Type: models.MsgNetworkSettingsGet,
+		Data: settings,
+	})
+}
+
+func (h *NetworkSettingsHandler) handleUpdate(msg *models.IPCMessage, writer ResponseWriter) error {
+	jsonData, err := json.Marshal(msg.Data)
+	if err != nil {
+		return fmt.Errorf("invalid settings data: %w", err)
+	}
+
+	var networkSettings settings.NetworkSettings
+	if err := json.Unmarshal(jsonData, &networkSettings); err != nil {
+		return fmt.Errorf("failed to parse settings: %w", err)
+	}
+
+	if _, err := ssh.CryptoAlgorithms(networkSettings.Crypto); err != nil {
+		return err
+	}
+
+	if err := h.storage.Update(networkSettings); err != nil {
+		return err
+	}
+
+	return writer.WriteMessage(&models.IPCMessage{
+		Type: models.MsgNetworkSettingsUpdate,
+		Data: networkSettings,
+	})
+}
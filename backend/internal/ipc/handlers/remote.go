@@ -52,7 +52,10 @@ func (h *RemoteHandler) handleRemoteTransfer(msg *models.IPCMessage, writer Resp
 		return fmt.Errorf("failed to parse remote transfer request: %w", err)
 	}
 
-	transferID := uuid.New().String()
+	transferID := req.TransferID
+	if transferID == "" {
+		transferID = uuid.New().String()
+	}
 
 	err = h.manager.RemoteTransfer(
 		req.SourceSessionID,
@@ -73,6 +76,7 @@ func (h *RemoteHandler) handleRemoteTransfer(msg *models.IPCMessage, writer Resp
 			})
 		},
 		transferID,
+		req.RateLimit,
 	)
 
 	if err != nil {
@@ -100,7 +104,10 @@ func (h *RemoteHandler) handleBulkRemoteTransfer(msg *models.IPCMessage, writer
 		return fmt.Errorf("failed to parse bulk remote transfer request: %w", err)
 	}
 
-	transferID := uuid.New().String()
+	transferID := req.TransferID
+	if transferID == "" {
+		transferID = uuid.New().String()
+	}
 
 	results := h.manager.BulkRemoteTransfer(
 		req.SourceSessionID,
@@ -114,6 +121,7 @@ func (h *RemoteHandler) handleBulkRemoteTransfer(msg *models.IPCMessage, writer
 			})
 		},
 		transferID,
+		req.RateLimit,
 	)
 
 	return writer.WriteMessage(&models.IPCMessage{
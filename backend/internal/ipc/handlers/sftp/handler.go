@@ -23,7 +23,8 @@ func (h *Handler) CanHandle(msgType models.MessageType) bool {
 	case models.MsgSFTPList, models.MsgSFTPUpload, models.MsgSFTPDownload,
 		models.MsgSFTPDelete, models.MsgSFTPMkdir, models.MsgSFTPRename,
 		models.MsgSFTPCancel, models.MsgSFTPReadFile, models.MsgSFTPWriteFile,
-		models.MsgSFTPChmod:
+		models.MsgSFTPChmod, models.MsgSFTPPartialList, models.MsgSFTPResume,
+		models.MsgSFTPPartialDiscard:
 		return true
 	}
 	return false
@@ -51,6 +52,12 @@ func (h *Handler) Handle(msg *models.IPCMessage, writer handlers.ResponseWriter)
 		return h.handleWriteFile(msg, writer)
 	case models.MsgSFTPChmod:
 		return h.handleChmod(msg, writer)
+	case models.MsgSFTPPartialList:
+		return h.handlePartialList(msg, writer)
+	case models.MsgSFTPResume:
+		return h.handleResume(msg, writer)
+	case models.MsgSFTPPartialDiscard:
+		return h.handlePartialDiscard(msg, writer)
 	default:
 		return fmt.Errorf("unsupported message type: %s", msg.Type)
 	}
@@ -30,7 +30,7 @@ func (h *Handler) handleUpload(msg *models.IPCMessage, writer handlers.ResponseW
 		}
 	}()
 
-	err = h.manager.UploadFile(msg.SessionID, req.LocalPath, req.RemotePath, progressChan)
+	err = h.manager.UploadFile(msg.SessionID, req, progressChan)
 	close(progressChan)
 
 	if err != nil {
@@ -67,7 +67,7 @@ func (h *Handler) handleDownload(msg *models.IPCMessage, writer handlers.Respons
 		}
 	}()
 
-	err = h.manager.DownloadFile(msg.SessionID, req.RemotePath, req.LocalPath, progressChan)
+	err = h.manager.DownloadFile(msg.SessionID, req, progressChan)
 	close(progressChan)
 
 	if err != nil {
@@ -92,7 +92,7 @@ func (h *Handler) handleCancel(msg *models.IPCMessage, writer handlers.ResponseW
 		return fmt.Errorf("failed to parse cancel request: %w", err)
 	}
 
-	cancelled := h.manager.CancelTransfer(req.TransferID)
+	cancelled := h.manager.CancelTransfer(req.TransferID, req.KeepPartial)
 
 	return writer.WriteMessage(&models.IPCMessage{
 		Type:      models.MsgSFTPCancel,
@@ -100,3 +100,85 @@ func (h *Handler) handleCancel(msg *models.IPCMessage, writer handlers.ResponseW
 		Data:      map[string]interface{}{"transfer_id": req.TransferID, "cancelled": cancelled},
 	})
 }
+
+func (h *Handler) handlePartialList(msg *models.IPCMessage, writer handlers.ResponseWriter) error {
+	var req models.PartialListRequest
+	if msg.Data != nil {
+		jsonData, err := json.Marshal(msg.Data)
+		if err != nil {
+			return fmt.Errorf("invalid data: %w", err)
+		}
+		if err := json.Unmarshal(jsonData, &req); err != nil {
+			return fmt.Errorf("failed to parse partial list request: %w", err)
+		}
+	}
+
+	return writer.WriteMessage(&models.IPCMessage{
+		Type:      models.MsgSFTPPartialList,
+		SessionID: msg.SessionID,
+		Data:      h.manager.ListPartialTransfers(req.ConnectionID),
+	})
+}
+
+func (h *Handler) handleResume(msg *models.IPCMessage, writer handlers.ResponseWriter) error {
+	jsonData, err := json.Marshal(msg.Data)
+	if err != nil {
+		return fmt.Errorf("invalid data: %w", err)
+	}
+
+	var req models.ResumeRequest
+	if err := json.Unmarshal(jsonData, &req); err != nil {
+		return fmt.Errorf("failed to parse resume request: %w", err)
+	}
+
+	progressChan := make(chan models.TransferProgress, 10)
+
+	go func() {
+		for progress := range progressChan {
+			writer.WriteMessage(&models.IPCMessage{
+				Type:      models.MsgSFTPProgress,
+				SessionID: msg.SessionID,
+				Data:      progress,
+			})
+		}
+	}()
+
+	entry, err := h.manager.ResumeTransfer(msg.SessionID, req, progressChan)
+	close(progressChan)
+
+	if err != nil {
+		return err
+	}
+
+	return writer.WriteMessage(&models.IPCMessage{
+		Type:      models.MsgSFTPResume,
+		SessionID: msg.SessionID,
+		Data: map[string]string{
+			"status":      "completed",
+			"transfer_id": entry.ID,
+			"direction":   string(entry.Direction),
+		},
+	})
+}
+
+func (h *Handler) handlePartialDiscard(msg *models.IPCMessage, writer handlers.ResponseWriter) error {
+	jsonData, err := json.Marshal(msg.Data)
+	if err != nil {
+		return fmt.Errorf("invalid data: %w", err)
+	}
+
+	var req models.PartialDiscardRequest
+	if err := json.Unmarshal(jsonData, &req); err != nil {
+		return fmt.Errorf("failed to parse discard request: %w", err)
+	}
+
+	if err := h.manager.DiscardPartialTransfer(msg.SessionID, req.TransferID, req.DeleteFile); err != nil {
+		return err
+	}
+
+	return writer.WriteMessage(&models.IPCMessage{
+		Type:      models.MsgSFTPPartialDiscard,
+		SessionID: msg.SessionID,
+		Data:      map[string]string{"status": "discarded", "transfer_id": req.TransferID},
+	})
+}
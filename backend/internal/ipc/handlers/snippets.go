@@ -4,23 +4,30 @@ import (
 	"encoding/json"
 	"fmt"
 	"freessh-backend/internal/models"
+	"freessh-backend/internal/session"
 	"freessh-backend/internal/snippets"
 	"freessh-backend/internal/storage"
+	"strings"
+	"time"
 )
 
 type SnippetHandler struct {
-	manager *snippets.Manager
+	manager  *snippets.Manager
+	sessions *session.Manager
 }
 
-func NewSnippetHandler(snippetStorage *storage.SnippetStorage) *SnippetHandler {
+func NewSnippetHandler(snippetStorage *storage.SnippetStorage, sessions *session.Manager) *SnippetHandler {
 	return &SnippetHandler{
-		manager: snippets.NewManager(snippetStorage),
+		manager:  snippets.NewManager(snippetStorage),
+		sessions: sessions,
 	}
 }
 
 func (h *SnippetHandler) CanHandle(msgType models.MessageType) bool {
 	switch msgType {
-	case models.MsgSnippetList, models.MsgSnippetCreate, models.MsgSnippetUpdate, models.MsgSnippetDelete:
+	case models.MsgSnippetList, models.MsgSnippetCreate, models.MsgSnippetUpdate, models.MsgSnippetDelete,
+		models.MsgSnippetParams, models.MsgSnippetRender, models.MsgSnippetRecordUse,
+		models.MsgSnippetExportPack, models.MsgSnippetImportPack:
 		return true
 	}
 	return false
@@ -29,29 +36,47 @@ func (h *SnippetHandler) CanHandle(msgType models.MessageType) bool {
 func (h *SnippetHandler) Handle(msg *models.IPCMessage, writer ResponseWriter) error {
 	switch msg.Type {
 	case models.MsgSnippetList:
-		return h.handleList(writer)
+		return h.handleList(msg, writer)
 	case models.MsgSnippetCreate:
 		return h.handleCreate(msg, writer)
 	case models.MsgSnippetUpdate:
 		return h.handleUpdate(msg, writer)
 	case models.MsgSnippetDelete:
 		return h.handleDelete(msg, writer)
+	case models.MsgSnippetParams:
+		return h.handleParams(msg, writer)
+	case models.MsgSnippetRender:
+		return h.handleRender(msg, writer)
+	case models.MsgSnippetRecordUse:
+		return h.handleRecordUse(msg, writer)
+	case models.MsgSnippetExportPack:
+		return h.handleExportPack(msg, writer)
+	case models.MsgSnippetImportPack:
+		return h.handleImportPack(msg, writer)
 	default:
 		return fmt.Errorf("unsupported message type: %s", msg.Type)
 	}
 }
 
-func (h *SnippetHandler) handleList(writer ResponseWriter) error {
-	snippets, err := h.manager.List()
+func (h *SnippetHandler) handleList(msg *models.IPCMessage, writer ResponseWriter) error {
+	jsonData, err := json.Marshal(msg.Data)
+	if err != nil {
+		return fmt.Errorf("invalid data: %w", err)
+	}
+
+	var req models.SnippetListRequest
+	if err := json.Unmarshal(jsonData, &req); err != nil {
+		return fmt.Errorf("failed to parse request: %w", err)
+	}
+
+	response, err := h.manager.List(req)
 	if err != nil {
 		return err
 	}
 
 	return writer.WriteMessage(&models.IPCMessage{
 		Type: models.MsgSnippetList,
-		Data: models.SnippetListResponse{
-			Snippets: snippets,
-		},
+		Data: response,
 	})
 }
 
@@ -66,7 +91,15 @@ func (h *SnippetHandler) handleCreate(msg *models.IPCMessage, writer ResponseWri
 		return fmt.Errorf("failed to parse request: %w", err)
 	}
 
-	snippet, err := h.manager.Create(req.Name, req.Command)
+	snippet, err := h.manager.Create(models.Snippet{
+		Name:        req.Name,
+		Command:     req.Command,
+		Params:      req.Params,
+		Folder:      req.Folder,
+		Tags:        req.Tags,
+		Description: req.Description,
+		Variants:    req.Variants,
+	})
 	if err != nil {
 		return err
 	}
@@ -90,7 +123,7 @@ func (h *SnippetHandler) handleUpdate(msg *models.IPCMessage, writer ResponseWri
 		return fmt.Errorf("failed to parse request: %w", err)
 	}
 
-	snippet, err := h.manager.Update(req.ID, req.Name, req.Command)
+	snippet, err := h.manager.Edit(req)
 	if err != nil {
 		return err
 	}
@@ -125,3 +158,201 @@ func (h *SnippetHandler) handleDelete(msg *models.IPCMessage, writer ResponseWri
 		},
 	})
 }
+
+func (h *SnippetHandler) handleParams(msg *models.IPCMessage, writer ResponseWriter) error {
+	jsonData, err := json.Marshal(msg.Data)
+	if err != nil {
+		return fmt.Errorf("invalid data: %w", err)
+	}
+
+	var req models.SnippetParamsRequest
+	if err := json.Unmarshal(jsonData, &req); err != nil {
+		return fmt.Errorf("failed to parse request: %w", err)
+	}
+
+	if req.SessionID == "" {
+		req.SessionID = msg.SessionID
+	}
+
+	params, err := h.manager.Params(req.SnippetID, req.Command, h.osType(req.SessionID, req.OS))
+	if err != nil {
+		return err
+	}
+
+	return writer.WriteMessage(&models.IPCMessage{
+		Type: models.MsgSnippetParams,
+		Data: models.SnippetParamsResponse{
+			Params: params,
+		},
+	})
+}
+
+func (h *SnippetHandler) handleRender(msg *models.IPCMessage, writer ResponseWriter) error {
+	jsonData, err := json.Marshal(msg.Data)
+	if err != nil {
+		return fmt.Errorf("invalid data: %w", err)
+	}
+
+	var req models.SnippetRenderRequest
+	if err := json.Unmarshal(jsonData, &req); err != nil {
+		return fmt.Errorf("failed to parse request: %w", err)
+	}
+	if req.SessionID == "" {
+		req.SessionID = msg.SessionID
+	}
+
+	builtins, err := h.builtinValues(req.SessionID, req.ConnectionID)
+	if err != nil {
+		return err
+	}
+
+	command, err := h.manager.Render(req.SnippetID, req.Command, h.osType(req.SessionID, req.OS), req.Values, builtins)
+	if err != nil {
+		return err
+	}
+
+	return writer.WriteMessage(&models.IPCMessage{
+		Type:      models.MsgSnippetRender,
+		SessionID: req.SessionID,
+		Data: models.SnippetRenderResponse{
+			Command: command,
+		},
+	})
+}
+
+// osType returns the OS a snippet variant is picked for: the one requested,
+// else the one detected for the session.
+func (h *SnippetHandler) osType(sessionID, requested string) string {
+	if requested != "" || sessionID == "" || h.sessions == nil {
+		return requested
+	}
+	activeSession, err := h.sessions.GetSession(sessionID)
+	if err != nil {
+		return ""
+	}
+	return activeSession.Session.OSType
+}
+
+// builtinValues returns the built-in template variables of the session's
+// connection, or of the saved connection, or nil when neither is given.
+func (h *SnippetHandler) builtinValues(sessionID, connectionID string) (map[string]string, error) {
+	if h.sessions == nil {
+		return nil, nil
+	}
+
+	if sessionID != "" {
+		activeSession, err := h.sessions.GetSession(sessionID)
+		if err != nil {
+			return nil, err
+		}
+		if activeSession.SSHClient != nil {
+			return snippets.BuiltinValues(activeSession.Config), nil
+		}
+	}
+
+	if connectionID != "" {
+		connectionStorage := h.sessions.GetConnectionStorage()
+		if connectionStorage == nil {
+			return nil, fmt.Errorf("connection storage not available")
+		}
+		config, err := connectionStorage.Get(connectionID)
+		if err != nil {
+			return nil, err
+		}
+		return snippets.BuiltinValues(*config), nil
+	}
+
+	return nil, nil
+}
+
+func (h *SnippetHandler) handleRecordUse(msg *models.IPCMessage, writer ResponseWriter) error {
+	jsonData, err := json.Marshal(msg.Data)
+	if err != nil {
+		return fmt.Errorf("invalid data: %w", err)
+	}
+
+	var req models.SnippetRecordUseRequest
+	if err := json.Unmarshal(jsonData, &req); err != nil {
+		return fmt.Errorf("failed to parse request: %w", err)
+	}
+
+	if err := h.manager.RecordUse(req.ID); err != nil {
+		return err
+	}
+
+	snippet, err := h.manager.Get(req.ID)
+	if err != nil {
+		return err
+	}
+
+	return writer.WriteMessage(&models.IPCMessage{
+		Type: models.MsgSnippetRecordUse,
+		Data: models.SnippetUpdateResponse{
+			Snippet: *snippet,
+		},
+	})
+}
+
+func (h *SnippetHandler) handleExportPack(msg *models.IPCMessage, writer ResponseWriter) error {
+	jsonData, err := json.Marshal(msg.Data)
+	if err != nil {
+		return fmt.Errorf("invalid data: %w", err)
+	}
+
+	var req models.SnippetExportPackRequest
+	if err := json.Unmarshal(jsonData, &req); err != nil {
+		return fmt.Errorf("failed to parse request: %w", err)
+	}
+
+	data, err := h.manager.ExportPack(req)
+	if err != nil {
+		return err
+	}
+
+	name := req.Name
+	if name == "" {
+		name = "snippets"
+	}
+	filename := fmt.Sprintf("freessh-snippet-pack-%s-%s.json", sanitizePackName(name), time.Now().Format("2006-01-02"))
+
+	return writer.WriteMessage(&models.IPCMessage{
+		Type: models.MsgSnippetExportPack,
+		Data: models.SnippetExportPackResponse{
+			Data:     string(data),
+			Filename: filename,
+		},
+	})
+}
+
+func (h *SnippetHandler) handleImportPack(msg *models.IPCMessage, writer ResponseWriter) error {
+	jsonData, err := json.Marshal(msg.Data)
+	if err != nil {
+		return fmt.Errorf("invalid data: %w", err)
+	}
+
+	var req models.SnippetImportPackRequest
+	if err := json.Unmarshal(jsonData, &req); err != nil {
+		return fmt.Errorf("failed to parse request: %w", err)
+	}
+
+	result, err := h.manager.ImportPack(req)
+	if err != nil {
+		return err
+	}
+
+	return writer.WriteMessage(&models.IPCMessage{
+		Type: models.MsgSnippetImportPack,
+		Data: result,
+	})
+}
+
+// sanitizePackName keeps letters, digits, dashes and underscores so the pack
+// name is safe in a filename.
+func sanitizePackName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_' {
+			return r
+		}
+		return '-'
+	}, strings.TrimSpace(name))
+}
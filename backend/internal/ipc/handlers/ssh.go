@@ -10,17 +10,20 @@ import (
 type SSHHandler struct {
 	manager            *session.Manager
 	verificationHelper *HostKeyVerificationHelper
+	promptHelper       *AuthPromptHelper
 }
 
-func NewSSHHandler(manager *session.Manager, verificationHelper *HostKeyVerificationHelper) *SSHHandler {
+func NewSSHHandler(manager *session.Manager, verificationHelper *HostKeyVerificationHelper, promptHelper *AuthPromptHelper) *SSHHandler {
 	return &SSHHandler{
 		manager:            manager,
 		verificationHelper: verificationHelper,
+		promptHelper:       promptHelper,
 	}
 }
 
 func (h *SSHHandler) CanHandle(msgType models.MessageType) bool {
-	return msgType == models.MsgConnect || msgType == models.MsgDisconnect || msgType == models.MsgHostKeyVerifyResponse
+	return msgType == models.MsgConnect || msgType == models.MsgDisconnect || msgType == models.MsgHostKeyVerifyResponse ||
+		msgType == models.MsgAuthPromptResponse
 }
 
 func (h *SSHHandler) Handle(msg *models.IPCMessage, writer ResponseWriter) error {
@@ -31,6 +34,8 @@ func (h *SSHHandler) Handle(msg *models.IPCMessage, writer ResponseWriter) error
 		return h.handleDisconnect(msg, writer)
 	case models.MsgHostKeyVerifyResponse:
 		return h.handleVerifyResponse(msg, writer)
+	case models.MsgAuthPromptResponse:
+		return h.promptHelper.HandlePromptResponse(msg)
 	default:
 		return fmt.Errorf("unsupported message type: %s", msg.Type)
 	}
@@ -48,7 +53,8 @@ func (h *SSHHandler) handleConnect(msg *models.IPCMessage, writer ResponseWriter
 	}
 
 	verificationCallback := h.verificationHelper.CreateVerificationCallback(writer)
-	session, err := h.manager.CreateSessionWithVerification(req.Config, verificationCallback)
+	promptCallback := h.promptHelper.CreatePromptCallback(writer)
+	session, err := h.manager.CreateSessionWithPrompts(req.Config, verificationCallback, promptCallback)
 	if err != nil {
 		return err
 	}
@@ -160,6 +160,15 @@ func (h *TerminalHandler) StartOutputStreaming(sessionID string, writer Response
 					SessionID: sessionID,
 					Data:      map[string]string{"output": output},
 				})
+			case event, ok := <-activeSession.StatusChan:
+				if !ok {
+					return
+				}
+				_ = writer.WriteMessage(&models.IPCMessage{
+					Type:      models.MsgSessionStatus,
+					SessionID: sessionID,
+					Data:      event,
+				})
 			case err, ok := <-activeSession.ErrorChan:
 				if !ok {
 					return
@@ -170,6 +179,7 @@ func (h *TerminalHandler) StartOutputStreaming(sessionID string, writer Response
 					_ = h.manager.CloseSession(sessionID)
 
 					status := "error"
+					reason := "stream_error"
 					errorMessage := err.Error()
 					lowerError := strings.ToLower(errorMessage)
 					if strings.Contains(lowerError, "closed") ||
@@ -177,6 +187,9 @@ func (h *TerminalHandler) StartOutputStreaming(sessionID string, writer Response
 						strings.Contains(lowerError, "reconnect failed") {
 						status = "disconnected"
 					}
+					if strings.Contains(lowerError, "reconnect failed") {
+						reason = "reconnect_failed"
+					}
 
 					_ = writer.WriteMessage(&models.IPCMessage{
 						Type:      models.MsgSessionStatus,
@@ -184,7 +197,7 @@ func (h *TerminalHandler) StartOutputStreaming(sessionID string, writer Response
 						Data: map[string]string{
 							"status": status,
 							"error":  errorMessage,
-							"reason": "stream_error",
+							"reason": reason,
 						},
 					})
 					return
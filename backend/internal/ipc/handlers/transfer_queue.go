@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"freessh-backend/internal/models"
+	"freessh-backend/internal/settings"
+	"freessh-backend/internal/transferqueue"
+)
+
+type TransferQueueHandler struct {
+	queue *transferqueue.Manager
+}
+
+// NewTransferQueueHandler serves the transfer queue messages. Job changes
+// happen outside of any request, so they are pushed as transfer_queue:update
+// messages through events.
+func NewTransferQueueHandler(queue *transferqueue.Manager, events ResponseWriter) *TransferQueueHandler {
+	if queue != nil {
+		queue.SetListener(func(job models.TransferJob) {
+			_ = events.WriteMessage(&models.IPCMessage{
+				Type: models.MsgTransferQueueUpdate,
+				Data: job,
+			})
+		})
+	}
+
+	return &TransferQueueHandler{
+		queue: queue,
+	}
+}
+
+func (h *TransferQueueHandler) CanHandle(msgType models.MessageType) bool {
+	switch msgType {
+	case models.MsgTransferQueueEnqueue, models.MsgTransferQueueList, models.MsgTransferQueuePause,
+		models.MsgTransferQueueResume, models.MsgTransferQueueRetry, models.MsgTransferQueueRemove,
+		models.MsgTransferQueueReorder, models.MsgTransferQueuePriority,
+		models.MsgTransferSettingsGet, models.MsgTransferSettingsUpdate, models.MsgTransferRateLimit:
+		return true
+	}
+	return false
+}
+
+func (h *TransferQueueHandler) Handle(msg *models.IPCMessage, writer ResponseWriter) error {
+	if h.queue == nil {
+		return fmt.Errorf("transfer queue not available")
+	}
+
+	switch msg.Type {
+	case models.MsgTransferQueueEnqueue:
+		return h.handleEnqueue(msg, writer)
+	case models.MsgTransferQueueList:
+		return h.handleList(msg, writer)
+	case models.MsgTransferQueuePause:
+		return h.handleJob(msg, writer, h.queue.Pause)
+	case models.MsgTransferQueueResume:
+		return h.handleJob(msg, writer, h.queue.Resume)
+	case models.MsgTransferQueueRetry:
+		return h.handleJob(msg, writer, h.queue.Retry)
+	case models.MsgTransferQueueRemove:
+		return h.handleRemove(msg, writer)
+	case models.MsgTransferQueueReorder:
+		return h.handleReorder(msg, writer)
+	case models.MsgTransferQueuePriority:
+		return h.handlePriority(msg, writer)
+	case models.MsgTransferSettingsGet:
+		return h.handleSettingsGet(writer)
+	case models.MsgTransferSettingsUpdate:
+		return h.handleSettingsUpdate(msg, writer)
+	case models.MsgTransferRateLimit:
+		return h.handleRateLimit(msg, writer)
+	default:
+		return fmt.Errorf("unsupported message type: %s", msg.Type)
+	}
+}
+
+func (h *TransferQueueHandler) handleEnqueue(msg *models.IPCMessage, writer ResponseWriter) error {
+	jsonData, err := json.Marshal(msg.Data)
+	if err != nil {
+		return fmt.Errorf("invalid data: %w", err)
+	}
+
+	var req models.TransferEnqueueRequest
+	if err := json.Unmarshal(jsonData, &req); err != nil {
+		return fmt.Errorf("failed to parse enqueue request: %w", err)
+	}
+
+	job, err := h.queue.Enqueue(req, msg.SessionID)
+	if err != nil {
+		return err
+	}
+
+	return writer.WriteMessage(&models.IPCMessage{
+		Type:      models.MsgTransferQueueEnqueue,
+		SessionID: msg.SessionID,
+		Data:      job,
+	})
+}
+
+func (h *TransferQueueHandler) handleList(msg *models.IPCMessage, writer ResponseWriter) error {
+	var req models.TransferQueueListRequest
+	if msg.Data != nil {
+		jsonData, err := json.Marshal(msg.Data)
+		if err != nil {
+			return fmt.Errorf("invalid data: %w", err)
+		}
+		if err := json.Unmarshal(jsonData, &req); err != nil {
+			return fmt.Errorf("failed to parse list request: %w", err)
+		}
+	}
+
+	return writer.WriteMessage(&models.IPCMessage{
+		Type: models.MsgTransferQueueList,
+		Data: h.queue.List(req),
+	})
+}
+
+// handleJob serves the messages that act on a single job and reply with it.
+func (h *TransferQueueHandler) handleJob(msg *models.IPCMessage, writer ResponseWriter, action func(jobID string) (*models.TransferJob, error)) error {
+	jsonData, err := json.Marshal(msg.Data)
+	if err != nil {
+		return fmt.Errorf("invalid data: %w", err)
+	}
+
+	var req models.TransferJobRequest
+	if err := json.Unmarshal(jsonData, &req); err != nil {
+		return fmt.Errorf("failed to parse transfer job request: %w", err)
+	}
+
+	job, err := action(req.JobID)
+	if err != nil {
+		return err
+	}
+
+	return writer.WriteMessage(&models.IPCMessage{
+		Type: msg.Type,
+		Data: job,
+	})
+}
+
+func (h *TransferQueueHandler) handleRemove(msg *models.IPCMessage, writer ResponseWriter) error {
+	jsonData, err := json.Marshal(msg.Data)
+	if err != nil {
+		return fmt.Errorf("invalid data: %w", err)
+	}
+
+	var req models.TransferJobRequest
+	if err := json.Unmarshal(jsonData, &req); err != nil {
+		return fmt.Errorf("failed to parse transfer job request: %w", err)
+	}
+
+	if err := h.queue.Remove(req.JobID); err != nil {
+		return err
+	}
+
+	return writer.WriteMessage(&models.IPCMessage{
+		Type: models.MsgTransferQueueRemove,
+		Data: map[string]string{"status": "removed", "job_id": req.JobID},
+	})
+}
+
+func (h *TransferQueueHandler) handleReorder(msg *models.IPCMessage, writer ResponseWriter) error {
+	jsonData, err := json.Marshal(msg.Data)
+	if err != nil {
+		return fmt.Errorf("invalid data: %w", err)
+	}
+
+	var req models.TransferReorderRequest
+	if err := json.Unmarshal(jsonData, &req); err != nil {
+		return fmt.Errorf("failed to parse reorder request: %w", err)
+	}
+
+	jobs, err := h.queue.Reorder(req.JobIDs)
+	if err != nil {
+		return err
+	}
+
+	return writer.WriteMessage(&models.IPCMessage{
+		Type: models.MsgTransferQueueReorder,
+		Data: jobs,
+	})
+}
+
+func (h *TransferQueueHandler) handlePriority(msg *models.IPCMessage, writer ResponseWriter) error {
+	jsonData, err := json.Marshal(msg.Data)
+	if err != nil {
+		return fmt.Errorf("invalid data: %w", err)
+	}
+
+	var req models.TransferPriorityRequest
+	if err := json.Unmarshal(jsonData, &req); err != nil {
+		return fmt.Errorf("failed to parse priority request: %w", err)
+	}
+
+	job, err := h.queue.SetPriority(req.JobID, req.Priority)
+	if err != nil {
+		return err
+	}
+
+	return writer.WriteMessage(&models.IPCMessage{
+		Type: models.MsgTransferQueuePriority,
+		Data: job,
+	})
+}
+
+func (h *TransferQueueHandler) handleSettingsGet(writer ResponseWriter) error {
+	return writer.WriteMessage(&models.IPCMessage{
+		Type: models.MsgTransferSettingsGet,
+		Data: h.queue.Settings(),
+	})
+}
+
+func (h *TransferQueueHandler) handleSettingsUpdate(msg *models.IPCMessage, writer ResponseWriter) error {
+	jsonData, err := json.Marshal(msg.Data)
+	if err != nil {
+		return fmt.Errorf("invalid settings data: %w", err)
+	}
+
+	var transferSettings settings.TransferSettings
+	if err := json.Unmarshal(jsonData, &transferSettings); err != nil {
+		return fmt.Errorf("failed to parse settings: %w", err)
+	}
+
+	if err := h.queue.UpdateSettings(transferSettings); err != nil {
+		return err
+	}
+
+	return writer.WriteMessage(&models.IPCMessage{
+		Type: models.MsgTransferSettingsUpdate,
+		Data: transferSettings,
+	})
+}
+
+func (h *TransferQueueHandler) handleRateLimit(msg *models.IPCMessage, writer ResponseWriter) error {
+	jsonData, err := json.Marshal(msg.Data)
+	if err != nil {
+		return fmt.Errorf("invalid data: %w", err)
+	}
+
+	var req models.RateLimitRequest
+	if err := json.Unmarshal(jsonData, &req); err != nil {
+		return fmt.Errorf("failed to parse rate limit request: %w", err)
+	}
+
+	if err := h.queue.SetRateLimit(req); err != nil {
+		return err
+	}
+
+	return writer.WriteMessage(&models.IPCMessage{
+		Type: models.MsgTransferRateLimit,
+		Data: req,
+	})
+}
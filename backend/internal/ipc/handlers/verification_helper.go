@@ -59,14 +59,22 @@ func (h *HostKeyVerificationHelper) CreateVerificationCallback(writer ResponseWr
 				return fmt.Errorf("known host storage unavailable")
 			}
 
-			// User approved - save the host
+			// User approved a changed key - drop the one it replaces
+			if verification.OldFingerprint != "" {
+				if err := h.knownHostStorage.DeleteByFingerprint(verification.Hostname, verification.Port, verification.OldFingerprint); err != nil {
+					return err
+				}
+			}
+
+			// User approved - save the host key for its type
 			host := &models.KnownHost{
 				Hostname:    verification.Hostname,
 				Port:        verification.Port,
 				Fingerprint: verification.Fingerprint,
-				PublicKey:   "", // Will be set by TrustHost if needed
+				PublicKey:   verification.PublicKey,
+				KeyType:     verification.KeyType,
 			}
-			return h.knownHostStorage.Add(host)
+			return h.knownHostStorage.SaveHostKey(host)
 		case <-time.After(60 * time.Second):
 			return fmt.Errorf("host key verification timeout")
 		}
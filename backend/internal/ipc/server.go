@@ -10,6 +10,7 @@ import (
 	"freessh-backend/internal/session"
 	"freessh-backend/internal/settings"
 	"freessh-backend/internal/storage"
+	"freessh-backend/internal/transferqueue"
 	"freessh-backend/internal/workspace"
 	"log"
 )
@@ -53,13 +54,18 @@ func NewServer() *Server {
 		log.Printf("Warning: Failed to initialize log settings storage: %v", err)
 	}
 
+	networkSettingsStorage, err := settings.NewNetworkSettingsStorage()
+	if err != nil {
+		log.Printf("Warning: Failed to initialize network settings storage: %v", err)
+	}
+
 	// Initialize history storage
 	historyStorage, err := storage.NewHistoryStorage()
 	if err != nil {
 		log.Fatalf("Failed to initialize history storage: %v", err)
 	}
 
-	manager := session.NewManager(logSettingsStorage)
+	manager := session.NewManager(logSettingsStorage, networkSettingsStorage)
 	workspaceManager := workspace.NewManager(config.FeatureDetachableWorkspaces)
 	workspaceStateStore, err := workspace.NewStateStore()
 	if err != nil {
@@ -71,22 +77,68 @@ func NewServer() *Server {
 		}
 	}
 	terminalHandler := handlers.NewTerminalHandler(manager, historyStorage)
+	writer := NewWriter()
+
+	// The transfer queue starts with the app so jobs left by the last run
+	// continue without waiting for the UI.
+	var transferQueue *transferqueue.Manager
+	transferJobStorage, err := storage.NewTransferJobStorage()
+	if err != nil {
+		log.Printf("Warning: Failed to initialize transfer queue storage: %v", err)
+	} else {
+		transferSettingsStorage, settingsErr := settings.NewTransferSettingsStorage()
+		if settingsErr != nil {
+			log.Printf("Warning: Failed to initialize transfer settings storage: %v", settingsErr)
+		}
+		transferQueue = transferqueue.NewManager(manager, transferJobStorage, transferSettingsStorage)
+	}
+	transferQueueHandler := handlers.NewTransferQueueHandler(transferQueue, writer)
+	if transferQueue != nil {
+		transferQueue.Start()
+	}
 
-	// Create shared verification helper
+	// Create shared verification and auth prompt helpers
 	verificationHelper := handlers.NewHostKeyVerificationHelper()
+	promptHelper := handlers.NewAuthPromptHelper()
 
 	return &Server{
 		reader:          NewReader(),
-		writer:          NewWriter(),
+		writer:          writer,
 		terminalHandler: terminalHandler,
 		handlers: []handlers.Handler{
-			handlers.NewSSHHandler(manager, verificationHelper),
+			handlers.NewSSHHandler(manager, verificationHelper, promptHelper),
 			terminalHandler,
 			handlers.NewSessionHandler(manager, historyStorage),
-			handlers.NewConnectionHandler(manager, verificationHelper),
+			handlers.NewConnectionHandler(manager, verificationHelper, promptHelper),
 			sftp.NewHandler(manager),
 			handlers.NewBulkHandler(manager),
 			handlers.NewRemoteHandler(manager),
+			handlers.NewExecHandler(manager),
+			transferQueueHandler,
+			handlers.NewLazyHandler(
+				[]models.MessageType{
+					models.MsgFleetRun,
+					models.MsgFleetCancel,
+					models.MsgFleetList,
+					models.MsgFleetGet,
+					models.MsgFleetDelete,
+				},
+				func() (handlers.Handler, error) {
+					groupStorage, groupErr := storage.NewGroupStorage()
+					if groupErr != nil {
+						return nil, fmt.Errorf("failed to initialize group storage: %w", groupErr)
+					}
+					snippetStorage, snippetErr := storage.NewSnippetStorage()
+					if snippetErr != nil {
+						return nil, fmt.Errorf("failed to initialize snippet storage: %w", snippetErr)
+					}
+					jobStorage, jobErr := storage.NewFleetJobStorage()
+					if jobErr != nil {
+						return nil, fmt.Errorf("failed to initialize fleet job storage: %w", jobErr)
+					}
+					return handlers.NewFleetHandler(manager, groupStorage, snippetStorage, jobStorage), nil
+				},
+			),
 			handlers.NewPortForwardHandler(manager),
 			handlers.NewLazyHandler(
 				[]models.MessageType{
@@ -113,6 +165,7 @@ func NewServer() *Server {
 					models.MsgKnownHostRemove,
 					models.MsgKnownHostTrust,
 					models.MsgKnownHostImport,
+					models.MsgKnownHostExport,
 				},
 				func() (handlers.Handler, error) {
 					knownHostStorage, storageErr := storage.NewKnownHostStorage()
@@ -128,6 +181,7 @@ func NewServer() *Server {
 					models.MsgGroupCreate,
 					models.MsgGroupRename,
 					models.MsgGroupDelete,
+					models.MsgGroupSetEnvironment,
 				},
 				func() (handlers.Handler, error) {
 					groupStorage, storageErr := storage.NewGroupStorage()
@@ -139,19 +193,25 @@ func NewServer() *Server {
 			),
 			handlers.NewLogHandler(),
 			handlers.NewLogSettingsHandler(logSettingsStorage),
+			handlers.NewNetworkSettingsHandler(networkSettingsStorage),
 			handlers.NewLazyHandler(
 				[]models.MessageType{
 					models.MsgSnippetList,
 					models.MsgSnippetCreate,
 					models.MsgSnippetUpdate,
 					models.MsgSnippetDelete,
+					models.MsgSnippetParams,
+					models.MsgSnippetRender,
+					models.MsgSnippetRecordUse,
+					models.MsgSnippetExportPack,
+					models.MsgSnippetImportPack,
 				},
 				func() (handlers.Handler, error) {
 					snippetStorage, storageErr := storage.NewSnippetStorage()
 					if storageErr != nil {
 						return nil, fmt.Errorf("failed to initialize snippet storage: %w", storageErr)
 					}
-					return handlers.NewSnippetHandler(snippetStorage), nil
+					return handlers.NewSnippetHandler(snippetStorage, manager), nil
 				},
 			),
 			handlers.NewHistoryHandler(historyStorage),
@@ -0,0 +1,135 @@
+package keygen
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// CertificateOptions describes an OpenSSH certificate to issue.
+type CertificateOptions struct {
+	CertType        uint32 // ssh.UserCert or ssh.HostCert
+	Identity        string
+	Principals      []string
+	ValidAfter      time.Time // zero means valid from the epoch
+	ValidBefore     time.Time // zero means never expires
+	CriticalOptions map[string]string
+	Extensions      map[string]string
+}
+
+// defaultUserExtensions matches what ssh-keygen grants to user certificates.
+var defaultUserExtensions = map[string]string{
+	"permit-X11-forwarding":   "",
+	"permit-agent-forwarding": "",
+	"permit-port-forwarding":  "",
+	"permit-pty":              "",
+	"permit-user-rc":          "",
+}
+
+// SignCertificate signs publicKey (authorized_keys format) with the CA private
+// key and returns the certificate in authorized_keys format.
+func SignCertificate(caPrivateKeyPEM string, caPassphrase string, publicKey string, opts CertificateOptions) (string, error) {
+	caSigner, err := parseSigner(caPrivateKeyPEM, caPassphrase)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse CA key: %w", err)
+	}
+
+	pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(publicKey))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse public key: %w", err)
+	}
+	// Re-signing an existing certificate certifies the key underneath it.
+	if cert, ok := pub.(*ssh.Certificate); ok {
+		pub = cert.Key
+	}
+
+	if opts.CertType != ssh.UserCert && opts.CertType != ssh.HostCert {
+		return "", fmt.Errorf("invalid certificate type: %d", opts.CertType)
+	}
+	if !opts.ValidBefore.IsZero() && !opts.ValidBefore.After(opts.ValidAfter) {
+		return "", fmt.Errorf("certificate validity window is empty")
+	}
+
+	serial, err := randomSerial()
+	if err != nil {
+		return "", err
+	}
+
+	extensions := opts.Extensions
+	if extensions == nil && opts.CertType == ssh.UserCert {
+		extensions = defaultUserExtensions
+	}
+
+	cert := &ssh.Certificate{
+		Key:             pub,
+		Serial:          serial,
+		CertType:        opts.CertType,
+		KeyId:           opts.Identity,
+		ValidPrincipals: opts.Principals,
+		ValidAfter:      0,
+		ValidBefore:     ssh.CertTimeInfinity,
+		Permissions: ssh.Permissions{
+			CriticalOptions: opts.CriticalOptions,
+			Extensions:      extensions,
+		},
+	}
+	if !opts.ValidAfter.IsZero() {
+		cert.ValidAfter = uint64(opts.ValidAfter.Unix())
+	}
+	if !opts.ValidBefore.IsZero() {
+		cert.ValidBefore = uint64(opts.ValidBefore.Unix())
+	}
+
+	if err := cert.SignCert(rand.Reader, caSigner); err != nil {
+		return "", fmt.Errorf("failed to sign certificate: %w", err)
+	}
+
+	encoded := strings.TrimSuffix(string(ssh.MarshalAuthorizedKey(cert)), "\n")
+	if opts.Identity != "" {
+		encoded += " " + opts.Identity
+	}
+	return encoded + "\n", nil
+}
+
+// ValidateCertificate checks that certificate is an OpenSSH certificate for
+// publicKey.
+func ValidateCertificate(certificate string, publicKey string) error {
+	parsed, _, _, _, err := ssh.ParseAuthorizedKey([]byte(certificate))
+	if err != nil {
+		return fmt.Errorf("failed to parse certificate: %w", err)
+	}
+	cert, ok := parsed.(*ssh.Certificate)
+	if !ok {
+		return fmt.Errorf("not a certificate")
+	}
+
+	pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(publicKey))
+	if err != nil {
+		return fmt.Errorf("failed to parse public key: %w", err)
+	}
+	if !bytes.Equal(cert.Key.Marshal(), pub.Marshal()) {
+		return fmt.Errorf("certificate does not match key")
+	}
+
+	return nil
+}
+
+func parseSigner(privateKeyPEM string, passphrase string) (ssh.Signer, error) {
+	if passphrase != "" {
+		return ssh.ParsePrivateKeyWithPassphrase([]byte(privateKeyPEM), []byte(passphrase))
+	}
+	return ssh.ParsePrivateKey([]byte(privateKeyPEM))
+}
+
+func randomSerial() (uint64, error) {
+	var buf [8]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return 0, fmt.Errorf("failed to generate serial: %w", err)
+	}
+	return binary.BigEndian.Uint64(buf[:]), nil
+}
@@ -0,0 +1,186 @@
+package knownhosts
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"freessh-backend/internal/models"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Entry is one line of an OpenSSH known_hosts file.
+type Entry struct {
+	Marker  string   // "", models.KnownHostMarkerCertAuthority or models.KnownHostMarkerRevoked
+	Hosts   []string // literal hosts, patterns or hashed hosts
+	Key     ssh.PublicKey
+	Comment string
+}
+
+// Parse reads known_hosts data. Lines that cannot be parsed are skipped and
+// reported with their line number.
+func Parse(data []byte) ([]Entry, []error) {
+	var entries []Entry
+	var errs []error
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		entry, err := parseLine(line)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %w", lineNumber, err))
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return entries, errs
+}
+
+func parseLine(line string) (Entry, error) {
+	var entry Entry
+	fields := strings.Fields(line)
+
+	if strings.HasPrefix(fields[0], "@") {
+		entry.Marker = strings.TrimPrefix(fields[0], "@")
+		if entry.Marker != models.KnownHostMarkerCertAuthority && entry.Marker != models.KnownHostMarkerRevoked {
+			return entry, fmt.Errorf("unknown marker @%s", entry.Marker)
+		}
+		fields = fields[1:]
+	}
+
+	if len(fields) < 3 {
+		return entry, fmt.Errorf("expected hosts, key type and key")
+	}
+
+	keyBytes, err := base64.StdEncoding.DecodeString(fields[2])
+	if err != nil {
+		return entry, fmt.Errorf("invalid key encoding: %w", err)
+	}
+	key, err := ssh.ParsePublicKey(keyBytes)
+	if err != nil {
+		return entry, fmt.Errorf("invalid key: %w", err)
+	}
+
+	for _, host := range strings.Split(fields[0], ",") {
+		if host != "" {
+			entry.Hosts = append(entry.Hosts, host)
+		}
+	}
+	if len(entry.Hosts) == 0 {
+		return entry, fmt.Errorf("missing hosts")
+	}
+
+	entry.Key = key
+	entry.Comment = strings.Join(fields[3:], " ")
+	return entry, nil
+}
+
+// String formats the entry as a known_hosts line without a trailing newline.
+func (e Entry) String() string {
+	var builder strings.Builder
+	if e.Marker != "" {
+		builder.WriteString("@" + e.Marker + " ")
+	}
+	builder.WriteString(strings.Join(e.Hosts, ","))
+	builder.WriteString(" ")
+	builder.WriteString(strings.TrimSpace(string(ssh.MarshalAuthorizedKey(e.Key))))
+	if e.Comment != "" {
+		builder.WriteString(" " + e.Comment)
+	}
+	return builder.String()
+}
+
+// Marshal formats entries as a known_hosts file.
+func Marshal(entries []Entry) []byte {
+	var builder strings.Builder
+	for _, entry := range entries {
+		builder.WriteString(entry.String())
+		builder.WriteString("\n")
+	}
+	return []byte(builder.String())
+}
+
+// ToKnownHosts converts an entry into FreeSSH known host records. Literal
+// hosts become one record each. Patterns and hashed hosts are kept verbatim
+// with port 0 and matched at verification time; marker entries keep their
+// whole host list.
+func ToKnownHosts(entry Entry) []models.KnownHost {
+	record := func(hostname string, port int) models.KnownHost {
+		return models.KnownHost{
+			Hostname:    hostname,
+			Port:        port,
+			Fingerprint: ssh.FingerprintSHA256(entry.Key),
+			PublicKey:   base64.StdEncoding.EncodeToString(entry.Key.Marshal()),
+			KeyType:     entry.Key.Type(),
+			Marker:      entry.Marker,
+		}
+	}
+
+	if entry.Marker != "" {
+		return []models.KnownHost{record(strings.Join(entry.Hosts, ","), 0)}
+	}
+
+	var hosts []models.KnownHost
+	var patterns []string
+	for _, host := range entry.Hosts {
+		switch {
+		case IsHashed(host):
+			hosts = append(hosts, record(host, 0))
+		case IsPattern(host):
+			// Negations only make sense alongside the rest of the list
+			patterns = append(patterns, host)
+		default:
+			hostname, port := ParseAddress(host)
+			hosts = append(hosts, record(hostname, port))
+		}
+	}
+	if len(patterns) > 0 {
+		hosts = append(hosts, record(strings.Join(patterns, ","), 0))
+	}
+	return hosts
+}
+
+// FromKnownHost builds a file entry from a FreeSSH record. With hash set,
+// literal hosts are written hashed; patterns and marker host lists are kept
+// as they are since they cannot be hashed.
+func FromKnownHost(host models.KnownHost, hash bool) (Entry, error) {
+	if host.PublicKey == "" {
+		return Entry{}, fmt.Errorf("%s has no stored public key", host.Hostname)
+	}
+	keyBytes, err := base64.StdEncoding.DecodeString(host.PublicKey)
+	if err != nil {
+		return Entry{}, fmt.Errorf("%s: invalid key encoding: %w", host.Hostname, err)
+	}
+	key, err := ssh.ParsePublicKey(keyBytes)
+	if err != nil {
+		return Entry{}, fmt.Errorf("%s: invalid key: %w", host.Hostname, err)
+	}
+
+	entry := Entry{Marker: host.Marker, Key: key}
+	if host.Marker != "" || host.Port == 0 {
+		entry.Hosts = strings.Split(host.Hostname, ",")
+		return entry, nil
+	}
+
+	address := Address(host.Hostname, host.Port)
+	if hash {
+		if address, err = HashHost(address); err != nil {
+			return Entry{}, fmt.Errorf("failed to hash %s: %w", host.Hostname, err)
+		}
+	}
+	entry.Hosts = []string{address}
+	return entry, nil
+}
@@ -0,0 +1,48 @@
+package knownhosts
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"strings"
+)
+
+// hashMagic prefixes hashed hosts, as written by "ssh-keygen -H" and
+// HashKnownHosts.
+const hashMagic = "|1|"
+
+func IsHashed(host string) bool {
+	return strings.HasPrefix(host, hashMagic)
+}
+
+// HashHost hashes a known_hosts address ("host" or "[host]:port") with a
+// fresh random salt.
+func HashHost(address string) (string, error) {
+	salt := make([]byte, sha1.Size)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	return hashWithSalt(salt, address), nil
+}
+
+// MatchHashed reports whether a "|1|salt|hash" entry was produced from
+// address. Hashes cannot be reversed, so entries are only ever matched
+// against a known address at verification time.
+func MatchHashed(entry string, address string) bool {
+	parts := strings.Split(strings.TrimPrefix(entry, hashMagic), "|")
+	if len(parts) != 2 {
+		return false
+	}
+	salt, err := base64.StdEncoding.DecodeString(parts[0])
+	if err != nil {
+		return false
+	}
+	return hmac.Equal([]byte(hashWithSalt(salt, address)), []byte(entry))
+}
+
+func hashWithSalt(salt []byte, address string) string {
+	mac := hmac.New(sha1.New, salt)
+	mac.Write([]byte(address))
+	return hashMagic + base64.StdEncoding.EncodeToString(salt) + "|" + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,103 @@
+package knownhosts
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Address formats hostname the way known_hosts stores it: bare for port 22,
+// "[host]:port" otherwise.
+func Address(hostname string, port int) string {
+	if port == 22 || port == 0 {
+		return hostname
+	}
+	return "[" + hostname + "]:" + strconv.Itoa(port)
+}
+
+// ParseAddress splits a known_hosts host ("host" or "[host]:port") into its
+// hostname and port.
+func ParseAddress(host string) (string, int) {
+	if strings.HasPrefix(host, "[") {
+		if end := strings.Index(host, "]:"); end > 0 {
+			if port, err := strconv.Atoi(host[end+2:]); err == nil {
+				return host[1:end], port
+			}
+		}
+	}
+	return host, 22
+}
+
+// IsPattern reports whether a single known_hosts host field is a wildcard,
+// negation or hashed entry rather than a literal host.
+func IsPattern(host string) bool {
+	return IsHashed(host) || strings.ContainsAny(host, "*?!")
+}
+
+// Match reports whether hostname:port matches a comma-separated known_hosts
+// host list. Entries may be hashed, "*" and "?" are wildcards and a leading
+// "!" negates; any matching negation rejects the host.
+func Match(patterns string, hostname string, port int) bool {
+	address := Address(hostname, port)
+	candidate := strings.ToLower(address)
+
+	matched := false
+	for _, pattern := range strings.Split(patterns, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+
+		if IsHashed(pattern) {
+			if MatchHashed(pattern, address) {
+				matched = true
+			}
+			continue
+		}
+
+		negated := strings.HasPrefix(pattern, "!")
+		if negated {
+			pattern = pattern[1:]
+		}
+
+		if wildcardMatch(strings.ToLower(pattern), candidate) {
+			if negated {
+				return false
+			}
+			matched = true
+		}
+	}
+	return matched
+}
+
+// wildcardMatch matches s against pattern, where "*" matches any run of
+// characters and "?" matches exactly one.
+func wildcardMatch(pattern, s string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 0 && pattern[0] == '*' {
+				pattern = pattern[1:]
+			}
+			if pattern == "" {
+				return true
+			}
+			for i := 0; i <= len(s); i++ {
+				if wildcardMatch(pattern, s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if s == "" {
+				return false
+			}
+		default:
+			if s == "" || s[0] != pattern[0] {
+				return false
+			}
+		}
+		pattern = pattern[1:]
+		s = s[1:]
+	}
+	return s == ""
+}
@@ -0,0 +1,128 @@
+package knownhosts
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		host     string
+		hostname string
+		port     int
+	}{
+		{"example.com", "example.com", 22},
+		{"[example.com]:2222", "example.com", 2222},
+		{"[10.0.0.1]:22", "10.0.0.1", 22},
+		{"[::1]:2200", "::1", 2200},
+		{"[example.com]", "[example.com]", 22},
+		{"[example.com]:port", "[example.com]:port", 22},
+		{"192.168.1.10", "192.168.1.10", 22},
+	}
+
+	for _, tt := range tests {
+		hostname, port := ParseAddress(tt.host)
+		if hostname != tt.hostname || port != tt.port {
+			t.Errorf("ParseAddress(%q) = %q, %d; want %q, %d", tt.host, hostname, port, tt.hostname, tt.port)
+		}
+	}
+}
+
+func TestAddressRoundTrip(t *testing.T) {
+	for _, port := range []int{22, 2222} {
+		hostname, got := ParseAddress(Address("example.com", port))
+		if hostname != "example.com" || got != port {
+			t.Errorf("ParseAddress(Address(example.com, %d)) = %q, %d", port, hostname, got)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	hashed := hashWithSalt(bytes.Repeat([]byte{7}, 20), "[git.example.com]:2222")
+
+	tests := []struct {
+		name     string
+		patterns string
+		hostname string
+		port     int
+		want     bool
+	}{
+		{"literal", "example.com", "example.com", 22, true},
+		{"literal is case insensitive", "Example.COM", "example.com", 22, true},
+		{"literal other host", "example.com", "example.org", 22, false},
+		{"literal needs the port", "example.com", "example.com", 2222, false},
+		{"bracketed port", "[example.com]:2222", "example.com", 2222, true},
+		{"bracketed other port", "[example.com]:2222", "example.com", 22, false},
+		{"star", "*.example.com", "web.example.com", 22, true},
+		{"star matches nothing before the dot", "*.example.com", "example.com", 22, false},
+		{"star spans dots", "*.example.com", "a.b.example.com", 22, true},
+		{"question mark", "web?.example.com", "web1.example.com", 22, true},
+		{"question mark is one character", "web?.example.com", "web12.example.com", 22, false},
+		{"list", "a.example.com,b.example.com", "b.example.com", 22, true},
+		{"list with spaces", "a.example.com, b.example.com", "b.example.com", 22, true},
+		{"negation rejects", "*.example.com,!secret.example.com", "secret.example.com", 22, false},
+		{"negation order does not matter", "!secret.example.com,*.example.com", "secret.example.com", 22, false},
+		{"negation leaves others", "*.example.com,!secret.example.com", "web.example.com", 22, true},
+		{"negation alone matches nothing", "!secret.example.com", "web.example.com", 22, false},
+		{"wildcard port", "[*.example.com]:*", "web.example.com", 2222, true},
+		{"hashed", hashed, "git.example.com", 2222, true},
+		{"hashed other port", hashed, "git.example.com", 22, false},
+		{"hashed in a list", "other.example.com," + hashed, "git.example.com", 2222, true},
+		{"empty", "", "example.com", 22, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Match(tt.patterns, tt.hostname, tt.port); got != tt.want {
+				t.Errorf("Match(%q, %q, %d) = %v, want %v", tt.patterns, tt.hostname, tt.port, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchHashed(t *testing.T) {
+	entry, err := HashHost("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		entry   string
+		address string
+		want    bool
+	}{
+		{"same address", entry, "example.com", true},
+		{"other address", entry, "example.org", false},
+		{"address with port", entry, "[example.com]:2222", false},
+		{"not hashed", "example.com", "example.com", false},
+		{"missing hash", "|1|c2FsdA==", "example.com", false},
+		{"bad salt", "|1|!!!|c2FsdA==", "example.com", false},
+		{"tampered hash", entry[:len(entry)-4] + "AAA=", "example.com", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MatchHashed(tt.entry, tt.address); got != tt.want {
+				t.Errorf("MatchHashed(%q, %q) = %v, want %v", tt.entry, tt.address, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHashHostUsesFreshSalt(t *testing.T) {
+	first, err := HashHost("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := HashHost("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == second {
+		t.Errorf("HashHost returned %q twice", first)
+	}
+	if !IsHashed(first) || !IsPattern(first) {
+		t.Errorf("%q is not recognised as hashed", first)
+	}
+}
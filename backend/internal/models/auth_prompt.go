@@ -0,0 +1,24 @@
+package models
+
+// AuthPrompt is a keyboard-interactive challenge (PAM, TOTP, password
+// fallback) relayed to the UI while a connection is being established.
+type AuthPrompt struct {
+	ID          string               `json:"id"`
+	Hostname    string               `json:"hostname"`
+	Port        int                  `json:"port"`
+	Username    string               `json:"username"`
+	Name        string               `json:"name,omitempty"`
+	Instruction string               `json:"instruction,omitempty"`
+	Questions   []AuthPromptQuestion `json:"questions"`
+}
+
+type AuthPromptQuestion struct {
+	Prompt string `json:"prompt"`
+	Echo   bool   `json:"echo"`
+}
+
+type AuthPromptResponse struct {
+	ID        string   `json:"id"`
+	Answers   []string `json:"answers"`
+	Cancelled bool     `json:"cancelled,omitempty"`
+}
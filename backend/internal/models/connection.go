@@ -5,6 +5,9 @@ type AuthMethod string
 const (
 	AuthPassword  AuthMethod = "password"
 	AuthPublicKey AuthMethod = "publickey"
+	AuthAgent     AuthMethod = "agent"
+
+	AuthKeyboardInteractive AuthMethod = "keyboard-interactive"
 )
 
 type ConnectionConfig struct {
@@ -19,7 +22,72 @@ type ConnectionConfig struct {
 	Group      string          `json:"group,omitempty"`
 	Profile    *SessionProfile `json:"profile,omitempty"`
 
+	// AuthMethods is an ordered list of methods offered during the handshake.
+	// When empty, AuthMethod is used on its own.
+	AuthMethods []AuthMethod `json:"auth_methods,omitempty"`
+
+	// JumpHosts lists saved connection IDs to tunnel through, in order,
+	// before reaching Host (equivalent to OpenSSH ProxyJump).
+	JumpHosts []string `json:"jump_hosts,omitempty"`
+
+	// Proxy overrides the global proxy for this connection. A proxy of type
+	// "none" connects directly even when a global proxy is configured.
+	Proxy *ProxyConfig `json:"proxy,omitempty"`
+
+	// ProxyCommand is run locally and the SSH session is carried over its
+	// stdin/stdout, as in OpenSSH. %h, %p and %r expand to the host, port and
+	// username. It replaces Proxy and is ignored when JumpHosts is set.
+	ProxyCommand string `json:"proxy_command,omitempty"`
+
+	// Network overrides the global timeout, keepalive and reconnect settings.
+	Network *NetworkOptions `json:"network,omitempty"`
+
+	// Crypto replaces the global crypto policy for this connection.
+	Crypto *CryptoPolicy `json:"crypto,omitempty"`
+
+	// Environment is sent to the remote shell, on top of the variables
+	// inherited from the connection's group.
+	Environment []EnvVar `json:"environment,omitempty"`
+
+	// ForwardAgent exposes the local ssh-agent to the remote shell.
+	ForwardAgent bool `json:"forward_agent,omitempty"`
+
+	// ForwardX11 forwards X11 clients started in the remote shell to the
+	// local $DISPLAY.
+	ForwardX11 bool `json:"forward_x11,omitempty"`
+
 	// Runtime-only fields (not persisted to JSON)
 	Password   string `json:"-"`
 	Passphrase string `json:"-"`
+	// Certificate is the OpenSSH certificate attached to the stored key.
+	Certificate string `json:"-"`
+}
+
+// EffectiveAuthMethods returns the methods to offer, in order, without
+// duplicates.
+func (c ConnectionConfig) EffectiveAuthMethods() []AuthMethod {
+	if len(c.AuthMethods) == 0 {
+		return []AuthMethod{c.AuthMethod}
+	}
+
+	methods := make([]AuthMethod, 0, len(c.AuthMethods))
+	seen := make(map[AuthMethod]bool, len(c.AuthMethods))
+	for _, method := range c.AuthMethods {
+		if method == "" || seen[method] {
+			continue
+		}
+		seen[method] = true
+		methods = append(methods, method)
+	}
+	return methods
+}
+
+// UsesAuthMethod reports whether method is among the effective auth methods.
+func (c ConnectionConfig) UsesAuthMethod(method AuthMethod) bool {
+	for _, m := range c.EffectiveAuthMethods() {
+		if m == method {
+			return true
+		}
+	}
+	return false
 }
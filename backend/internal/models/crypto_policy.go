@@ -0,0 +1,39 @@
+package models
+
+type CryptoPreset string
+
+const (
+	// CryptoPresetModern allows only algorithms without known weaknesses.
+	CryptoPresetModern CryptoPreset = "modern"
+	// CryptoPresetCompatible uses the SSH library defaults.
+	CryptoPresetCompatible CryptoPreset = "compatible"
+	// CryptoPresetLegacy adds SHA-1, CBC and other deprecated algorithms for
+	// old network gear.
+	CryptoPresetLegacy CryptoPreset = "legacy"
+)
+
+// CryptoPolicy selects the algorithms offered during the SSH handshake. A
+// non-empty list replaces the preset's list for that category.
+type CryptoPolicy struct {
+	Preset            CryptoPreset `json:"preset,omitempty"`
+	Ciphers           []string     `json:"ciphers,omitempty"`
+	KeyExchanges      []string     `json:"key_exchanges,omitempty"`
+	MACs              []string     `json:"macs,omitempty"`
+	HostKeyAlgorithms []string     `json:"host_key_algorithms,omitempty"`
+}
+
+// IsZero reports whether p leaves every choice to the defaults.
+func (p *CryptoPolicy) IsZero() bool {
+	return p == nil || (p.Preset == "" && len(p.Ciphers) == 0 && len(p.KeyExchanges) == 0 &&
+		len(p.MACs) == 0 && len(p.HostKeyAlgorithms) == 0)
+}
+
+// NegotiatedAlgorithms records the algorithms agreed with the server.
+type NegotiatedAlgorithms struct {
+	KeyExchange          string `json:"key_exchange"`
+	HostKey              string `json:"host_key"`
+	CipherClientToServer string `json:"cipher_client_to_server"`
+	CipherServerToClient string `json:"cipher_server_to_client"`
+	MACClientToServer    string `json:"mac_client_to_server,omitempty"`
+	MACServerToClient    string `json:"mac_server_to_client,omitempty"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// EnvVar is an environment variable set in remote shells.
+type EnvVar struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidEnvName reports whether a POSIX shell can export a variable called name.
+func ValidEnvName(name string) bool {
+	return envNamePattern.MatchString(name)
+}
+
+// ValidateEnvironment rejects variables whose names a POSIX shell could not
+// export.
+func ValidateEnvironment(vars []EnvVar) error {
+	for _, v := range vars {
+		if !ValidEnvName(v.Name) {
+			return fmt.Errorf("invalid environment variable name: %q", v.Name)
+		}
+	}
+	return nil
+}
+
+// MergeEnvironment returns base with the variables of override applied on
+// top, replacing those with the same name and keeping first-seen order.
+func MergeEnvironment(base, override []EnvVar) []EnvVar {
+	if len(base) == 0 && len(override) == 0 {
+		return nil
+	}
+
+	merged := make([]EnvVar, 0, len(base)+len(override))
+	index := make(map[string]int)
+	for _, list := range [][]EnvVar{base, override} {
+		for _, v := range list {
+			if i, ok := index[v.Name]; ok {
+				merged[i].Value = v.Value
+				continue
+			}
+			index[v.Name] = len(merged)
+			merged = append(merged, v)
+		}
+	}
+	return merged
+}
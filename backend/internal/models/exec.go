@@ -0,0 +1,43 @@
+package models
+
+// ExecRequest runs a single command on a session's connection in its own
+// channel, without a PTY and outside the interactive shell.
+type ExecRequest struct {
+	// ExecID identifies the run for exec:cancel; generated when empty.
+	ExecID    string `json:"exec_id,omitempty"`
+	SessionID string `json:"session_id"`
+	Command   string `json:"command"`
+	// Stdin is written to the command and then closed, if set.
+	Stdin string `json:"stdin,omitempty"`
+	// TimeoutSeconds kills the command after this long; 0 uses the default.
+	TimeoutSeconds int `json:"timeout_seconds,omitempty"`
+}
+
+// ExecStream names the output stream of an ExecOutput chunk.
+type ExecStream string
+
+const (
+	ExecStdout ExecStream = "stdout"
+	ExecStderr ExecStream = "stderr"
+)
+
+// ExecOutput is a chunk of a running command's output. Data carries the raw
+// bytes, base64-encoded in JSON, since output may be binary and a chunk may
+// end in the middle of a multi-byte character.
+type ExecOutput struct {
+	ExecID string     `json:"exec_id"`
+	Stream ExecStream `json:"stream"`
+	Data   []byte     `json:"data"`
+}
+
+// ExecResult is how a command ended. ExitCode is -1 when the server reported
+// no exit status, for example because the command was killed by a signal.
+type ExecResult struct {
+	ExecID     string `json:"exec_id"`
+	ExitCode   int    `json:"exit_code"`
+	Signal     string `json:"signal,omitempty"`
+	TimedOut   bool   `json:"timed_out,omitempty"`
+	Cancelled  bool   `json:"cancelled,omitempty"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
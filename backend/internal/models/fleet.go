@@ -0,0 +1,91 @@
+package models
+
+import "time"
+
+// FleetJobStatus is the state of a fleet job or of one host within it.
+type FleetJobStatus string
+
+const (
+	FleetPending   FleetJobStatus = "pending"
+	FleetRunning   FleetJobStatus = "running"
+	FleetSucceeded FleetJobStatus = "succeeded"
+	FleetFailed    FleetJobStatus = "failed"
+	FleetCancelled FleetJobStatus = "cancelled"
+)
+
+// FleetRunRequest runs one command on many saved connections. The command is
+// taken from SnippetID when Command is empty. Hosts are ConnectionIDs plus
+// every connection in GroupID.
+type FleetRunRequest struct {
+	// JobID identifies the run for fleet:cancel; generated when empty.
+	JobID         string   `json:"job_id,omitempty"`
+	SnippetID     string   `json:"snippet_id,omitempty"`
+	Command       string   `json:"command,omitempty"`
+	ConnectionIDs []string `json:"connection_ids,omitempty"`
+	GroupID       string   `json:"group_id,omitempty"`
+	// Values fills the snippet's parameters; built-in variables are filled
+	// per host.
+	Values map[string]string `json:"values,omitempty"`
+	// Concurrency bounds how many hosts run at once; 0 uses the default.
+	Concurrency int `json:"concurrency,omitempty"`
+	// TimeoutSeconds bounds each host, including connecting; 0 uses the
+	// exec default.
+	TimeoutSeconds int `json:"timeout_seconds,omitempty"`
+}
+
+// FleetHostResult is the outcome of a fleet job on one host. Output is kept
+// up to a fixed size per stream; Truncated is set when some was dropped.
+type FleetHostResult struct {
+	ConnectionID   string `json:"connection_id"`
+	ConnectionName string `json:"connection_name"`
+	Host           string `json:"host"`
+	// Command is the command as rendered for this host.
+	Command    string         `json:"command,omitempty"`
+	Status     FleetJobStatus `json:"status"`
+	ExitCode   int            `json:"exit_code"`
+	Signal     string         `json:"signal,omitempty"`
+	Stdout     string         `json:"stdout,omitempty"`
+	Stderr     string         `json:"stderr,omitempty"`
+	Truncated  bool           `json:"truncated,omitempty"`
+	Error      string         `json:"error,omitempty"`
+	StartedAt  time.Time      `json:"started_at,omitempty"`
+	FinishedAt time.Time      `json:"finished_at,omitempty"`
+	DurationMs int64          `json:"duration_ms,omitempty"`
+}
+
+// FleetSummary counts the hosts of a job by outcome.
+type FleetSummary struct {
+	Total     int `json:"total"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+	Cancelled int `json:"cancelled"`
+}
+
+// FleetJob is the stored record of a fleet run.
+type FleetJob struct {
+	ID          string            `json:"id"`
+	SnippetID   string            `json:"snippet_id,omitempty"`
+	SnippetName string            `json:"snippet_name,omitempty"`
+	Command     string            `json:"command"`
+	Status      FleetJobStatus    `json:"status"`
+	Concurrency int               `json:"concurrency"`
+	Summary     FleetSummary      `json:"summary"`
+	Hosts       []FleetHostResult `json:"hosts"`
+	CreatedAt   time.Time         `json:"created_at"`
+	FinishedAt  time.Time         `json:"finished_at,omitempty"`
+}
+
+// FleetOutput is a chunk of output from one host of a running job. Data is
+// base64-encoded in JSON, like ExecOutput.
+type FleetOutput struct {
+	JobID        string     `json:"job_id"`
+	ConnectionID string     `json:"connection_id"`
+	Stream       ExecStream `json:"stream"`
+	Data         []byte     `json:"data"`
+}
+
+// FleetHostUpdate reports a host of a running job changing status.
+type FleetHostUpdate struct {
+	JobID string          `json:"job_id"`
+	Host  FleetHostResult `json:"host"`
+}
@@ -7,4 +7,6 @@ type Group struct {
 	Name            string    `json:"name"`
 	ConnectionCount int       `json:"connection_count"`
 	CreatedAt       time.Time `json:"created_at"`
+	// Environment is inherited by every connection in the group.
+	Environment []EnvVar `json:"environment,omitempty"`
 }
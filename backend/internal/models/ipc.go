@@ -28,6 +28,10 @@ const (
 	MsgLogSettingsGet    MessageType = "log_settings:get"
 	MsgLogSettingsUpdate MessageType = "log_settings:update"
 
+	// Network settings messages
+	MsgNetworkSettingsGet    MessageType = "network_settings:get"
+	MsgNetworkSettingsUpdate MessageType = "network_settings:update"
+
 	// SFTP messages
 	MsgSFTPList      MessageType = "sftp:list"
 	MsgSFTPUpload    MessageType = "sftp:upload"
@@ -41,6 +45,25 @@ const (
 	MsgSFTPWriteFile MessageType = "sftp:writefile"
 	MsgSFTPChmod     MessageType = "sftp:chmod"
 
+	// Partial transfer journal messages
+	MsgSFTPPartialList    MessageType = "sftp:partial_list"
+	MsgSFTPResume         MessageType = "sftp:resume"
+	MsgSFTPPartialDiscard MessageType = "sftp:partial_discard"
+
+	// Transfer queue messages
+	MsgTransferQueueEnqueue   MessageType = "transfer_queue:enqueue"
+	MsgTransferQueueList      MessageType = "transfer_queue:list"
+	MsgTransferQueuePause     MessageType = "transfer_queue:pause"
+	MsgTransferQueueResume    MessageType = "transfer_queue:resume"
+	MsgTransferQueueRetry     MessageType = "transfer_queue:retry"
+	MsgTransferQueueRemove    MessageType = "transfer_queue:remove"
+	MsgTransferQueueReorder   MessageType = "transfer_queue:reorder"
+	MsgTransferQueuePriority  MessageType = "transfer_queue:set_priority"
+	MsgTransferQueueUpdate    MessageType = "transfer_queue:update"
+	MsgTransferSettingsGet    MessageType = "transfer_settings:get"
+	MsgTransferSettingsUpdate MessageType = "transfer_settings:update"
+	MsgTransferRateLimit      MessageType = "transfer:rate_limit"
+
 	// Bulk operations messages
 	MsgBulkDownload MessageType = "bulk:download"
 	MsgBulkUpload   MessageType = "bulk:upload"
@@ -71,10 +94,15 @@ const (
 	MsgKeychainDelete MessageType = "keychain:delete"
 
 	// Snippet messages
-	MsgSnippetList   MessageType = "snippet:list"
-	MsgSnippetCreate MessageType = "snippet:create"
-	MsgSnippetUpdate MessageType = "snippet:update"
-	MsgSnippetDelete MessageType = "snippet:delete"
+	MsgSnippetList       MessageType = "snippet:list"
+	MsgSnippetCreate     MessageType = "snippet:create"
+	MsgSnippetUpdate     MessageType = "snippet:update"
+	MsgSnippetDelete     MessageType = "snippet:delete"
+	MsgSnippetParams     MessageType = "snippet:params"
+	MsgSnippetRender     MessageType = "snippet:render"
+	MsgSnippetRecordUse  MessageType = "snippet:record_use"
+	MsgSnippetExportPack MessageType = "snippet:export_pack"
+	MsgSnippetImportPack MessageType = "snippet:import_pack"
 
 	// History messages
 	MsgHistoryList  MessageType = "history:list"
@@ -101,19 +129,43 @@ const (
 	MsgKeyDelete MessageType = "key:delete"
 	MsgKeyExport MessageType = "key:export"
 
+	// Key certificate messages
+	MsgKeyAttachCertificate MessageType = "key:attach_certificate"
+	MsgKeySignCertificate   MessageType = "key:sign_certificate"
+
 	// Known hosts messages
 	MsgKnownHostList         MessageType = "known_host:list"
 	MsgKnownHostRemove       MessageType = "known_host:remove"
 	MsgKnownHostTrust        MessageType = "known_host:trust"
 	MsgKnownHostImport       MessageType = "known_host:import"
+	MsgKnownHostExport       MessageType = "known_host:export"
 	MsgHostKeyVerify         MessageType = "host_key:verify"
 	MsgHostKeyVerifyResponse MessageType = "host_key:verify_response"
 
+	// Keyboard-interactive authentication messages
+	MsgAuthPrompt         MessageType = "auth:prompt"
+	MsgAuthPromptResponse MessageType = "auth:prompt_response"
+
 	// Group messages
-	MsgGroupList   MessageType = "group:list"
-	MsgGroupCreate MessageType = "group:create"
-	MsgGroupRename MessageType = "group:rename"
-	MsgGroupDelete MessageType = "group:delete"
+	MsgGroupList           MessageType = "group:list"
+	MsgGroupCreate         MessageType = "group:create"
+	MsgGroupRename         MessageType = "group:rename"
+	MsgGroupDelete         MessageType = "group:delete"
+	MsgGroupSetEnvironment MessageType = "group:set_environment"
+
+	// Exec messages
+	MsgExecRun    MessageType = "exec:run"
+	MsgExecOutput MessageType = "exec:output"
+	MsgExecCancel MessageType = "exec:cancel"
+
+	// Fleet execution messages
+	MsgFleetRun    MessageType = "fleet:run"
+	MsgFleetOutput MessageType = "fleet:output"
+	MsgFleetHost   MessageType = "fleet:host"
+	MsgFleetCancel MessageType = "fleet:cancel"
+	MsgFleetList   MessageType = "fleet:list"
+	MsgFleetGet    MessageType = "fleet:get"
+	MsgFleetDelete MessageType = "fleet:delete"
 )
 
 type IPCMessage struct {
@@ -136,14 +188,20 @@ type ResizeData struct {
 	Cols int `json:"cols"`
 }
 
+// BulkDownloadRequest and BulkUploadRequest may carry a TransferID chosen by
+// the caller, so their rate limit can be changed while they run.
 type BulkDownloadRequest struct {
 	RemotePaths  []string `json:"remote_paths"`
 	LocalBaseDir string   `json:"local_base_dir"`
+	TransferID   string   `json:"transfer_id,omitempty"`
+	RateLimit    int64    `json:"rate_limit,omitempty"`
 }
 
 type BulkUploadRequest struct {
 	LocalPaths    []string `json:"local_paths"`
 	RemoteBaseDir string   `json:"remote_base_dir"`
+	TransferID    string   `json:"transfer_id,omitempty"`
+	RateLimit     int64    `json:"rate_limit,omitempty"`
 }
 
 type BulkDeleteRequest struct {
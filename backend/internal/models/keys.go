@@ -10,3 +10,20 @@ type SSHKey struct {
 	PublicKey string    `json:"publicKey"`
 	CreatedAt time.Time `json:"createdAt"`
 }
+
+// CertificateSignRequest asks a stored CA key to sign a user or host key.
+// Either PublicKey or KeyID must be set; with KeyID the certificate is also
+// attached to that stored key.
+type CertificateSignRequest struct {
+	CAKeyID         string            `json:"ca_key_id"`
+	CAPassphrase    string            `json:"ca_passphrase,omitempty"`
+	PublicKey       string            `json:"public_key,omitempty"`
+	KeyID           string            `json:"key_id,omitempty"`
+	CertType        string            `json:"cert_type"` // "user" or "host"
+	Identity        string            `json:"identity"`
+	Principals      []string          `json:"principals"`
+	ValidAfter      time.Time         `json:"valid_after,omitempty"`
+	ValidBefore     time.Time         `json:"valid_before,omitempty"`
+	CriticalOptions map[string]string `json:"critical_options,omitempty"`
+	Extensions      map[string]string `json:"extensions,omitempty"`
+}
@@ -1,18 +1,39 @@
 package models
 
+// Known host markers, matching the OpenSSH known_hosts "@" prefixes.
+const (
+	KnownHostMarkerCertAuthority = "cert-authority"
+	KnownHostMarkerRevoked       = "revoked"
+)
+
 type KnownHost struct {
 	ID          string    `json:"id"`
 	Hostname    string    `json:"hostname"`
 	Port        int       `json:"port"`
 	Fingerprint string    `json:"fingerprint"`
 	PublicKey   string    `json:"publicKey"`
+	KeyType     string    `json:"keyType,omitempty"` // e.g. "ssh-ed25519"; one key is kept per type
+	// Marker is empty for plain host keys. For marker entries Hostname holds
+	// OpenSSH host patterns (e.g. "*.example.com,[bastion]:2222") and Port is 0.
+	Marker string `json:"marker,omitempty"`
 }
 
 type HostKeyVerification struct {
-	Status      string `json:"status"` // "new", "known", "changed"
+	Status      string `json:"status"` // "new", "known", "new_key_type", "changed", "revoked", "invalid"
 	Hostname    string `json:"hostname"`
 	Port        int    `json:"port"`
 	Fingerprint string `json:"fingerprint"`
 	KeyType     string `json:"keyType"`
 	OldFingerprint string `json:"oldFingerprint,omitempty"`
+	PublicKey      string `json:"publicKey,omitempty"`
+	// KnownKeyTypes lists the key types already trusted for a "new_key_type"
+	// key on a host that is known under other key types.
+	KnownKeyTypes []string `json:"knownKeyTypes,omitempty"`
+}
+
+type KnownHostsExportResponse struct {
+	Data     string   `json:"data"`
+	Filename string   `json:"filename"`
+	Count    int      `json:"count"`
+	Skipped  []string `json:"skipped,omitempty"`
 }
@@ -0,0 +1,46 @@
+package models
+
+// NetworkOptions tunes timeouts, keepalives and the reconnect policy of a
+// connection. Durations are in seconds. A zero field inherits the global
+// setting, and an unset global falls back to the built-in default.
+type NetworkOptions struct {
+	ConnectTimeout     int `json:"connect_timeout,omitempty"`
+	KeepAliveInterval  int `json:"keepalive_interval,omitempty"`
+	KeepAliveMaxMissed int `json:"keepalive_max_missed,omitempty"`
+
+	ReconnectInitialDelay int `json:"reconnect_initial_delay,omitempty"`
+	ReconnectMaxDelay     int `json:"reconnect_max_delay,omitempty"`
+	// ReconnectMaxAttempts of -1 retries without limit.
+	ReconnectMaxAttempts int `json:"reconnect_max_attempts,omitempty"`
+	// ReconnectJitter randomises each delay by up to this fraction (0-1).
+	ReconnectJitter float64 `json:"reconnect_jitter,omitempty"`
+}
+
+// Merge returns o with every field that is set in override replaced.
+func (o NetworkOptions) Merge(override *NetworkOptions) NetworkOptions {
+	if override == nil {
+		return o
+	}
+	if override.ConnectTimeout > 0 {
+		o.ConnectTimeout = override.ConnectTimeout
+	}
+	if override.KeepAliveInterval > 0 {
+		o.KeepAliveInterval = override.KeepAliveInterval
+	}
+	if override.KeepAliveMaxMissed > 0 {
+		o.KeepAliveMaxMissed = override.KeepAliveMaxMissed
+	}
+	if override.ReconnectInitialDelay > 0 {
+		o.ReconnectInitialDelay = override.ReconnectInitialDelay
+	}
+	if override.ReconnectMaxDelay > 0 {
+		o.ReconnectMaxDelay = override.ReconnectMaxDelay
+	}
+	if override.ReconnectMaxAttempts != 0 {
+		o.ReconnectMaxAttempts = override.ReconnectMaxAttempts
+	}
+	if override.ReconnectJitter > 0 {
+		o.ReconnectJitter = override.ReconnectJitter
+	}
+	return o
+}
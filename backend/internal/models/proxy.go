@@ -0,0 +1,37 @@
+package models
+
+type ProxyType string
+
+const (
+	ProxyNone    ProxyType = "none"
+	ProxyHTTP    ProxyType = "http"
+	ProxySOCKS4  ProxyType = "socks4"
+	ProxySOCKS4A ProxyType = "socks4a"
+	ProxySOCKS5  ProxyType = "socks5"
+)
+
+// ProxyConfig describes an outbound proxy for SSH connections. The password
+// lives in the keychain under ProxyKeychainAccount.
+type ProxyConfig struct {
+	Type     ProxyType `json:"type"`
+	Host     string    `json:"host,omitempty"`
+	Port     int       `json:"port,omitempty"`
+	Username string    `json:"username,omitempty"`
+
+	// Runtime-only fields (not persisted to JSON)
+	Password string `json:"-"`
+}
+
+// GlobalProxyKeychainAccount holds the password of the global proxy.
+const GlobalProxyKeychainAccount = "proxy:global"
+
+// ProxyKeychainAccount returns the keychain account holding the password of
+// a connection's own proxy.
+func ProxyKeychainAccount(connectionID string) string {
+	return connectionID + ":proxy"
+}
+
+// Enabled reports whether p routes traffic through a proxy.
+func (p *ProxyConfig) Enabled() bool {
+	return p != nil && p.Type != "" && p.Type != ProxyNone
+}
@@ -10,6 +10,7 @@ const (
 	SessionConnected     SessionStatus = "connected"
 	SessionDisconnected  SessionStatus = "disconnected"
 	SessionError         SessionStatus = "error"
+	SessionReconnecting  SessionStatus = "reconnecting"
 	
 	SessionTypeSSH   SessionType = "ssh"
 	SessionTypeLocal SessionType = "local"
@@ -23,4 +24,16 @@ type Session struct {
 	ConnectedAt  time.Time     `json:"connected_at,omitempty"`
 	Error        string        `json:"error,omitempty"`
 	OSType       string        `json:"os_type,omitempty"`
+	AuthMethod   AuthMethod    `json:"auth_method,omitempty"` // method that completed authentication
+	Algorithms   *NegotiatedAlgorithms `json:"algorithms,omitempty"`
+}
+
+// SessionStatusEvent reports a change in a live session's connection, such as
+// reconnect progress, to the UI.
+type SessionStatusEvent struct {
+	Status      SessionStatus `json:"status"`
+	Reason      string        `json:"reason,omitempty"`
+	Attempt     int           `json:"attempt,omitempty"`
+	MaxAttempts int           `json:"max_attempts,omitempty"`
+	Error       string        `json:"error,omitempty"`
 }
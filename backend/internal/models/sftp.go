@@ -15,7 +15,15 @@ type TransferProgress struct {
 	Total      int64   `json:"total"`
 	Transferred int64  `json:"transferred"`
 	Percentage float64 `json:"percentage"`
-	Status     string  `json:"status"` // uploading, downloading, completed, failed
+	Status     string  `json:"status"` // uploading, downloading, verifying, completed, failed
+	ResumedFrom int64  `json:"resumed_from,omitempty"`
+	// Rate is the current throughput in bytes per second.
+	Rate int64 `json:"rate"`
+	// Verified is set on completion when the checksums were compared.
+	// Unverified holds why a completed transfer could not be verified.
+	Verified   bool   `json:"verified,omitempty"`
+	Unverified string `json:"unverified,omitempty"`
+	Error      string `json:"error,omitempty"`
 }
 
 type ListRequest struct {
@@ -25,11 +33,23 @@ type ListRequest struct {
 type UploadRequest struct {
 	LocalPath  string `json:"local_path"`
 	RemotePath string `json:"remote_path"`
+	Resume     bool   `json:"resume,omitempty"`
+	// RateLimit caps this transfer in bytes per second. Zero means unlimited.
+	RateLimit int64 `json:"rate_limit,omitempty"`
+	// Verify compares the SHA-256 of both sides after the transfer. On a
+	// mismatch the transfer is repeated from scratch up to VerifyRetries
+	// times before it fails.
+	Verify        bool `json:"verify,omitempty"`
+	VerifyRetries int  `json:"verify_retries,omitempty"`
 }
 
 type DownloadRequest struct {
 	RemotePath string `json:"remote_path"`
 	LocalPath  string `json:"local_path"`
+	Resume     bool   `json:"resume,omitempty"`
+	RateLimit  int64  `json:"rate_limit,omitempty"`
+	Verify        bool `json:"verify,omitempty"`
+	VerifyRetries int  `json:"verify_retries,omitempty"`
 }
 
 type DeleteRequest struct {
@@ -47,6 +67,9 @@ type RenameRequest struct {
 
 type CancelRequest struct {
 	TransferID string `json:"transfer_id"`
+	// KeepPartial pauses the transfer: the partial file and its journal entry
+	// are kept so it can be resumed later.
+	KeepPartial bool `json:"keep_partial,omitempty"`
 }
 
 type ReadFileRequest struct {
@@ -3,31 +3,87 @@ package models
 import "time"
 
 type Snippet struct {
-	ID        string    `json:"id"`
-	Name      string    `json:"name"`
-	Command   string    `json:"command"`
-	CreatedAt time.Time `json:"created_at"`
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Command string `json:"command"`
+	// Params declares the {{name}} placeholders of Command. Placeholders
+	// without a declaration are plain string parameters.
+	Params []SnippetParam `json:"params,omitempty"`
+	// Folder is a slash-separated path such as "ops/nginx"; empty is the
+	// top level.
+	Folder      string   `json:"folder,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Description string   `json:"description,omitempty"`
+	// Variants maps an OS type from osdetect, or "linux" for any
+	// distribution, to the command used instead of Command on that OS.
+	Variants   map[string]string `json:"variants,omitempty"`
+	UsageCount int               `json:"usage_count"`
+	LastUsedAt *time.Time        `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+// SnippetParamType is the type a snippet parameter value must have.
+type SnippetParamType string
+
+const (
+	SnippetParamString SnippetParamType = "string"
+	SnippetParamInt    SnippetParamType = "int"
+	SnippetParamBool   SnippetParamType = "bool"
+)
+
+// SnippetParam describes a placeholder of a snippet command. Written inline
+// as {{name}}, {{name:type}} or {{name:type=default}}.
+type SnippetParam struct {
+	Name        string           `json:"name"`
+	Type        SnippetParamType `json:"type,omitempty"`
+	Default     string           `json:"default,omitempty"`
+	Choices     []string         `json:"choices,omitempty"`
+	Description string           `json:"description,omitempty"`
 }
 
-type SnippetListRequest struct{}
+// SnippetListRequest filters the snippet list. Every field is optional.
+// Query matches names, descriptions, commands and tags; Folder includes
+// subfolders; OS keeps snippets with a command for that OS.
+type SnippetListRequest struct {
+	Query  string `json:"query,omitempty"`
+	Folder string `json:"folder,omitempty"`
+	Tag    string `json:"tag,omitempty"`
+	OS     string `json:"os,omitempty"`
+}
 
+// SnippetListResponse lists the matching snippets along with every folder
+// and tag in the library, for building filters.
 type SnippetListResponse struct {
 	Snippets []Snippet `json:"snippets"`
+	Folders  []string  `json:"folders"`
+	Tags     []string  `json:"tags"`
 }
 
 type SnippetCreateRequest struct {
-	Name    string `json:"name"`
-	Command string `json:"command"`
+	Name        string            `json:"name"`
+	Command     string            `json:"command"`
+	Params      []SnippetParam    `json:"params,omitempty"`
+	Folder      string            `json:"folder,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Variants    map[string]string `json:"variants,omitempty"`
 }
 
 type SnippetCreateResponse struct {
 	Snippet Snippet `json:"snippet"`
 }
 
+// SnippetUpdateRequest edits a snippet. Fields left out (nil) keep their
+// stored values; send an empty value to clear one.
 type SnippetUpdateRequest struct {
-	ID      string `json:"id"`
-	Name    string `json:"name"`
-	Command string `json:"command"`
+	ID          string             `json:"id"`
+	Name        string             `json:"name"`
+	Command     string             `json:"command"`
+	Params      *[]SnippetParam    `json:"params,omitempty"`
+	Folder      *string            `json:"folder,omitempty"`
+	Tags        *[]string          `json:"tags,omitempty"`
+	Description *string            `json:"description,omitempty"`
+	Variants    *map[string]string `json:"variants,omitempty"`
 }
 
 type SnippetUpdateResponse struct {
@@ -41,3 +97,85 @@ type SnippetDeleteRequest struct {
 type SnippetDeleteResponse struct {
 	Status string `json:"status"`
 }
+
+// SnippetParamsRequest asks for the parameters to prompt for before running
+// a snippet, or an unsaved Command when SnippetID is empty.
+type SnippetParamsRequest struct {
+	SnippetID string `json:"snippet_id,omitempty"`
+	Command   string `json:"command,omitempty"`
+	// SessionID or OS selects the snippet's variant for that OS.
+	SessionID string `json:"session_id,omitempty"`
+	OS        string `json:"os,omitempty"`
+}
+
+type SnippetParamsResponse struct {
+	Params []SnippetParam `json:"params"`
+}
+
+// SnippetRenderRequest fills in a snippet's placeholders. Built-in variables
+// come from the connection of SessionID, or ConnectionID when not connected.
+// The variant is picked by OS, or else the OS detected for SessionID.
+type SnippetRenderRequest struct {
+	SnippetID    string            `json:"snippet_id,omitempty"`
+	Command      string            `json:"command,omitempty"`
+	SessionID    string            `json:"session_id,omitempty"`
+	ConnectionID string            `json:"connection_id,omitempty"`
+	OS           string            `json:"os,omitempty"`
+	Values       map[string]string `json:"values,omitempty"`
+}
+
+type SnippetRenderResponse struct {
+	Command string `json:"command"`
+}
+
+type SnippetRecordUseRequest struct {
+	ID string `json:"id"`
+}
+
+// SnippetPack is a shareable set of snippets.
+type SnippetPack struct {
+	Name        string             `json:"name"`
+	Description string             `json:"description,omitempty"`
+	Snippets    []SnippetPackEntry `json:"snippets"`
+}
+
+// SnippetPackEntry is a snippet without its ID and usage statistics.
+type SnippetPackEntry struct {
+	Name        string            `json:"name"`
+	Command     string            `json:"command"`
+	Params      []SnippetParam    `json:"params,omitempty"`
+	Folder      string            `json:"folder,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Variants    map[string]string `json:"variants,omitempty"`
+}
+
+// SnippetExportPackRequest exports the snippets in IDs, or in Folder and its
+// subfolders, or the whole library when both are empty.
+type SnippetExportPackRequest struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	IDs         []string `json:"ids,omitempty"`
+	Folder      string   `json:"folder,omitempty"`
+}
+
+type SnippetExportPackResponse struct {
+	Data     string `json:"data"`
+	Filename string `json:"filename"`
+}
+
+// SnippetImportPackRequest imports a pack from Data, or every .sh file under
+// Directory. Imported snippets are placed under Folder.
+type SnippetImportPackRequest struct {
+	Data      []byte `json:"data,omitempty"`
+	Directory string `json:"directory,omitempty"`
+	Folder    string `json:"folder,omitempty"`
+}
+
+// SnippetImportPackResponse counts new snippets and existing ones (same
+// folder and name) that were replaced.
+type SnippetImportPackResponse struct {
+	Imported int      `json:"imported"`
+	Updated  int      `json:"updated"`
+	Errors   []string `json:"errors,omitempty"`
+}
@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+type TransferDirection string
+
+const (
+	TransferUpload   TransferDirection = "upload"
+	TransferDownload TransferDirection = "download"
+)
+
+type PartialTransferStatus string
+
+const (
+	PartialRunning     PartialTransferStatus = "running"
+	PartialPaused      PartialTransferStatus = "paused"
+	PartialInterrupted PartialTransferStatus = "interrupted"
+)
+
+// PartialTransfer is a journal entry for a single-file transfer that has not
+// finished. Entries survive reconnects and restarts so the transfer can be
+// resumed from the partial destination file.
+type PartialTransfer struct {
+	ID            string                `json:"id"`
+	ConnectionID  string                `json:"connection_id,omitempty"`
+	Direction     TransferDirection     `json:"direction"`
+	LocalPath     string                `json:"local_path"`
+	RemotePath    string                `json:"remote_path"`
+	Size          int64                 `json:"size"`
+	Transferred   int64                 `json:"transferred"`
+	SourceModTime int64                 `json:"source_mod_time"`
+	Status        PartialTransferStatus `json:"status"`
+	Error         string                `json:"error,omitempty"`
+	CreatedAt     time.Time             `json:"created_at"`
+	UpdatedAt     time.Time             `json:"updated_at"`
+}
+
+type PartialListRequest struct {
+	ConnectionID string `json:"connection_id,omitempty"`
+}
+
+type ResumeRequest struct {
+	TransferID    string `json:"transfer_id"`
+	RateLimit     int64  `json:"rate_limit,omitempty"`
+	Verify        bool   `json:"verify,omitempty"`
+	VerifyRetries int    `json:"verify_retries,omitempty"`
+}
+
+type PartialDiscardRequest struct {
+	TransferID string `json:"transfer_id"`
+	// DeleteFile also removes the partial destination file. Removing a
+	// partial remote file needs a session on the same connection.
+	DeleteFile bool `json:"delete_file,omitempty"`
+}
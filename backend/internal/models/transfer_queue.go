@@ -0,0 +1,99 @@
+package models
+
+import "time"
+
+type TransferJobState string
+
+const (
+	TransferQueued  TransferJobState = "queued"
+	TransferRunning TransferJobState = "running"
+	TransferPaused  TransferJobState = "paused"
+	TransferFailed  TransferJobState = "failed"
+	TransferDone    TransferJobState = "done"
+)
+
+// TransferJob is a single-file transfer in the persistent transfer queue.
+// Jobs run in order of descending priority, then ascending position.
+// RateLimit caps the job in bytes per second; Rate is the current throughput
+// of a running job and is not stored. With Verify set, a checksum mismatch
+// after the copy fails the attempt, so the job is retried from scratch; a
+// server that cannot compute the checksum leaves the job done, with the
+// reason in Unverified.
+type TransferJob struct {
+	ID             string            `json:"id"`
+	ConnectionID   string            `json:"connection_id"`
+	ConnectionName string            `json:"connection_name,omitempty"`
+	Direction      TransferDirection `json:"direction"`
+	LocalPath      string            `json:"local_path"`
+	RemotePath     string            `json:"remote_path"`
+	Priority       int               `json:"priority"`
+	Position       int64             `json:"position"`
+	State          TransferJobState  `json:"state"`
+	Attempts       int               `json:"attempts"`
+	MaxAttempts    int               `json:"max_attempts"`
+	Size           int64             `json:"size"`
+	Transferred    int64             `json:"transferred"`
+	SourceModTime  int64             `json:"source_mod_time,omitempty"`
+	RateLimit      int64             `json:"rate_limit,omitempty"`
+	Rate           int64             `json:"rate,omitempty"`
+	Verify         bool              `json:"verify,omitempty"`
+	Unverified     string            `json:"unverified,omitempty"`
+	Error          string            `json:"error,omitempty"`
+	NextAttemptAt  time.Time         `json:"next_attempt_at,omitempty"`
+	CreatedAt      time.Time         `json:"created_at"`
+	StartedAt      time.Time         `json:"started_at,omitempty"`
+	FinishedAt     time.Time         `json:"finished_at,omitempty"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+}
+
+// TransferEnqueueRequest adds a job to the queue. When ConnectionID is empty
+// the connection of the message's session is used.
+type TransferEnqueueRequest struct {
+	ConnectionID string            `json:"connection_id,omitempty"`
+	Direction    TransferDirection `json:"direction"`
+	LocalPath    string            `json:"local_path"`
+	RemotePath   string            `json:"remote_path"`
+	Priority     int               `json:"priority,omitempty"`
+	MaxAttempts  int               `json:"max_attempts,omitempty"`
+	RateLimit    int64             `json:"rate_limit,omitempty"`
+	Verify       bool              `json:"verify,omitempty"`
+	Paused       bool              `json:"paused,omitempty"`
+}
+
+type TransferQueueListRequest struct {
+	ConnectionID string           `json:"connection_id,omitempty"`
+	State        TransferJobState `json:"state,omitempty"`
+}
+
+type TransferJobRequest struct {
+	JobID string `json:"job_id"`
+}
+
+// TransferReorderRequest moves the listed jobs, in order, ahead of all other
+// jobs. Priority still takes precedence over position.
+type TransferReorderRequest struct {
+	JobIDs []string `json:"job_ids"`
+}
+
+type TransferPriorityRequest struct {
+	JobID    string `json:"job_id"`
+	Priority int    `json:"priority"`
+}
+
+type RateLimitScope string
+
+const (
+	RateLimitGlobal     RateLimitScope = "global"
+	RateLimitConnection RateLimitScope = "connection"
+	RateLimitTransfer   RateLimitScope = "transfer"
+)
+
+// RateLimitRequest sets a bandwidth limit in bytes per second, zero meaning
+// unlimited. ID names the connection or the transfer for those scopes; a
+// transfer is either a queue job or any running upload, download, bulk or
+// remote transfer.
+type RateLimitRequest struct {
+	Scope RateLimitScope `json:"scope"`
+	ID    string         `json:"id,omitempty"`
+	Rate  int64          `json:"rate"`
+}
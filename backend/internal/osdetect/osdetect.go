@@ -22,6 +22,25 @@ const (
 	Unknown OSType = "unknown"
 )
 
+// Family returns Linux for a Linux distribution and osType itself otherwise.
+func Family(osType OSType) OSType {
+	switch osType {
+	case Ubuntu, Debian, RedHat, CentOS, Fedora, Arch:
+		return Linux
+	}
+	return osType
+}
+
+// Known reports whether osType is one DetectOS can return, other than
+// Unknown.
+func Known(osType OSType) bool {
+	switch osType {
+	case Ubuntu, Debian, RedHat, CentOS, Fedora, Arch, Linux, MacOS, FreeBSD, Windows:
+		return true
+	}
+	return false
+}
+
 // DetectOS runs commands over SSH to detect the remote operating system.
 func DetectOS(client *ssh.Client) (OSType, error) {
 	commands := []string{
@@ -14,7 +14,9 @@ import (
 
 type Manager struct {
 	tunnels map[string]*TunnelWrapper
-	mu      sync.RWMutex
+	// attached is the SSH connection the tunnels currently run over.
+	attached *ssh.Client
+	mu       sync.RWMutex
 }
 
 func NewManager() *Manager {
@@ -34,15 +36,17 @@ func (m *Manager) CreateLocalTunnel(connectionID, name string, config models.Tun
 		return nil, err
 	}
 
+	m.attached = sshClient
 	m.tunnels[id] = &TunnelWrapper{
-		ID:           id,
-		ConnectionID: connectionID,
-		Name:         name,
-		Type:         "local",
-		LocalPort:    config.LocalPort,
-		RemoteHost:   config.RemoteHost,
-		RemotePort:   config.RemotePort,
-		Tunnel:       tunnel,
+		ID:             id,
+		ConnectionID:   connectionID,
+		Name:           name,
+		Type:           "local",
+		LocalPort:      config.LocalPort,
+		RemoteHost:     config.RemoteHost,
+		RemotePort:     config.RemotePort,
+		BindingAddress: config.BindingAddress,
+		Tunnel:         tunnel,
 	}
 
 	return &models.TunnelInfo{
@@ -68,15 +72,17 @@ func (m *Manager) CreateRemoteTunnel(connectionID, name string, config models.Re
 		return nil, err
 	}
 
+	m.attached = sshClient
 	m.tunnels[id] = &TunnelWrapper{
-		ID:           id,
-		ConnectionID: connectionID,
-		Name:         name,
-		Type:         "remote",
-		LocalPort:    config.LocalPort,
-		RemoteHost:   config.LocalHost,
-		RemotePort:   config.RemotePort,
-		Tunnel:       tunnel,
+		ID:             id,
+		ConnectionID:   connectionID,
+		Name:           name,
+		Type:           "remote",
+		LocalPort:      config.LocalPort,
+		RemoteHost:     config.LocalHost,
+		RemotePort:     config.RemotePort,
+		BindingAddress: config.BindingAddress,
+		Tunnel:         tunnel,
 	}
 
 	return &models.TunnelInfo{
@@ -102,15 +108,17 @@ func (m *Manager) CreateDynamicTunnel(connectionID, name string, config models.D
 		return nil, err
 	}
 
+	m.attached = sshClient
 	m.tunnels[id] = &TunnelWrapper{
-		ID:           id,
-		ConnectionID: connectionID,
-		Name:         name,
-		Type:         "dynamic",
-		LocalPort:    config.LocalPort,
-		RemoteHost:   "",
-		RemotePort:   0,
-		Tunnel:       tunnel,
+		ID:             id,
+		ConnectionID:   connectionID,
+		Name:           name,
+		Type:           "dynamic",
+		LocalPort:      config.LocalPort,
+		RemoteHost:     "",
+		RemotePort:     0,
+		BindingAddress: config.BindingAddress,
+		Tunnel:         tunnel,
 	}
 
 	return &models.TunnelInfo{
@@ -198,6 +206,49 @@ func (m *Manager) ListTunnelsByConnection(connectionID string) []models.TunnelIn
 	return tunnels
 }
 
+// Reattach restarts every tunnel over sshClient, keeping tunnel IDs, after
+// the SSH connection has been re-established. Tunnels that fail to start are
+// dropped and the first error is returned. Tunnels already running over
+// sshClient are left alone, as every session sharing a transport reattaches.
+func (m *Manager) Reattach(sshClient *ssh.Client) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.attached == sshClient {
+		return nil
+	}
+	m.attached = sshClient
+
+	var firstErr error
+	for id, wrapper := range m.tunnels {
+		wrapper.Tunnel.Stop()
+
+		var tunnel Tunnel
+		switch wrapper.Type {
+		case "local":
+			tunnel = local.NewTunnel(id, wrapper.LocalPort, wrapper.RemoteHost, wrapper.RemotePort, wrapper.BindingAddress, sshClient)
+		case "remote":
+			tunnel = remote.NewTunnel(id, wrapper.RemotePort, wrapper.RemoteHost, wrapper.LocalPort, wrapper.BindingAddress, sshClient)
+		case "dynamic":
+			tunnel = dynamic.NewTunnel(id, wrapper.LocalPort, wrapper.BindingAddress, sshClient)
+		default:
+			delete(m.tunnels, id)
+			continue
+		}
+
+		if err := tunnel.Start(); err != nil {
+			delete(m.tunnels, id)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to restart tunnel %s: %w", wrapper.Name, err)
+			}
+			continue
+		}
+		wrapper.Tunnel = tunnel
+	}
+
+	return firstErr
+}
+
 func (m *Manager) StopAll() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
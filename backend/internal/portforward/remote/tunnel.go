@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"strconv"
 
 	"golang.org/x/crypto/ssh"
 )
@@ -76,7 +77,7 @@ func (t *Tunnel) acceptConnections() {
 func (t *Tunnel) handleConnection(remoteConn net.Conn) {
 	defer remoteConn.Close()
 
-	localConn, err := net.Dial("tcp", fmt.Sprintf("%s:%d", t.LocalHost, t.LocalPort))
+	localConn, err := net.Dial("tcp", net.JoinHostPort(t.LocalHost, strconv.Itoa(t.LocalPort)))
 	if err != nil {
 		return
 	}
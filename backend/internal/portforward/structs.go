@@ -7,12 +7,13 @@ type Tunnel interface {
 }
 
 type TunnelWrapper struct {
-	ID           string
-	ConnectionID string
-	Name         string
-	Type         string // "local" or "remote"
-	LocalPort    int
-	RemoteHost   string
-	RemotePort   int
-	Tunnel       Tunnel
+	ID             string
+	ConnectionID   string
+	Name           string
+	Type           string // "local" or "remote"
+	LocalPort      int
+	RemoteHost     string
+	RemotePort     int
+	BindingAddress string
+	Tunnel         Tunnel
 }
@@ -0,0 +1,182 @@
+package proxy
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ExpandCommand substitutes the OpenSSH ProxyCommand tokens %h (host),
+// %p (port), %r (remote user) and %% in command.
+func ExpandCommand(command, host string, port int, user string) string {
+	var builder strings.Builder
+	for i := 0; i < len(command); i++ {
+		if command[i] != '%' || i+1 == len(command) {
+			builder.WriteByte(command[i])
+			continue
+		}
+
+		i++
+		switch command[i] {
+		case 'h':
+			builder.WriteString(host)
+		case 'p':
+			builder.WriteString(strconv.Itoa(port))
+		case 'r':
+			builder.WriteString(user)
+		case '%':
+			builder.WriteByte('%')
+		default:
+			builder.WriteByte('%')
+			builder.WriteByte(command[i])
+		}
+	}
+	return builder.String()
+}
+
+// DialCommand runs command through the local shell and returns a connection
+// over its stdin and stdout. Closing the connection stops the process.
+func DialCommand(command string) (net.Conn, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		shell := os.Getenv("SHELL")
+		if shell == "" {
+			shell = "/bin/sh"
+		}
+		cmd = exec.Command(shell, "-c", "exec "+command)
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("proxy command: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("proxy command: %w", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, fmt.Errorf("proxy command: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start proxy command: %w", err)
+	}
+
+	// Surface diagnostics from tools like cloudflared in the backend log.
+	go func() {
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			log.Printf("proxy command: %s", scanner.Text())
+		}
+	}()
+
+	return &commandConn{cmd: cmd, stdin: stdin, stdout: stdout}, nil
+}
+
+// commandConn adapts a ProxyCommand process to net.Conn. Pipes cannot be
+// interrupted, so an expired deadline stops the process and the connection
+// cannot be used afterwards.
+type commandConn struct {
+	cmd       *exec.Cmd
+	stdin     io.WriteCloser
+	stdout    io.ReadCloser
+	closeOnce sync.Once
+
+	mu         sync.Mutex
+	readTimer  *time.Timer
+	writeTimer *time.Timer
+	timedOut   atomic.Bool
+}
+
+func (c *commandConn) Read(b []byte) (int, error) {
+	n, err := c.stdout.Read(b)
+	if err != nil && c.timedOut.Load() {
+		return n, os.ErrDeadlineExceeded
+	}
+	return n, err
+}
+
+func (c *commandConn) Write(b []byte) (int, error) {
+	n, err := c.stdin.Write(b)
+	if err != nil && c.timedOut.Load() {
+		return n, os.ErrDeadlineExceeded
+	}
+	return n, err
+}
+
+func (c *commandConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.mu.Lock()
+		stopTimer(c.readTimer)
+		stopTimer(c.writeTimer)
+		c.mu.Unlock()
+
+		c.stdin.Close()
+		if c.cmd.Process != nil {
+			c.cmd.Process.Kill()
+		}
+		c.cmd.Wait()
+	})
+	return nil
+}
+
+func (c *commandConn) LocalAddr() net.Addr  { return commandAddr{} }
+func (c *commandConn) RemoteAddr() net.Addr { return commandAddr{} }
+
+func (c *commandConn) SetDeadline(t time.Time) error {
+	c.SetReadDeadline(t)
+	return c.SetWriteDeadline(t)
+}
+
+func (c *commandConn) SetReadDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.readTimer = c.armTimer(c.readTimer, t)
+	return nil
+}
+
+func (c *commandConn) SetWriteDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writeTimer = c.armTimer(c.writeTimer, t)
+	return nil
+}
+
+// armTimer replaces timer with one that stops the process at t. A zero t
+// clears the deadline.
+func (c *commandConn) armTimer(timer *time.Timer, t time.Time) *time.Timer {
+	stopTimer(timer)
+	if t.IsZero() {
+		return nil
+	}
+	return time.AfterFunc(time.Until(t), c.expire)
+}
+
+func (c *commandConn) expire() {
+	c.timedOut.Store(true)
+	c.Close()
+}
+
+func stopTimer(timer *time.Timer) {
+	if timer != nil {
+		timer.Stop()
+	}
+}
+
+type commandAddr struct{}
+
+func (commandAddr) Network() string { return "proxycommand" }
+func (commandAddr) String() string  { return "proxycommand" }
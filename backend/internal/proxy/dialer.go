@@ -0,0 +1,58 @@
+package proxy
+
+import (
+	"fmt"
+	"freessh-backend/internal/models"
+	"net"
+	"strconv"
+	"time"
+)
+
+// Dial connects to addr ("host:port") through p, or directly when p is nil
+// or disabled. timeout bounds both the TCP connect and the proxy handshake.
+func Dial(p *models.ProxyConfig, addr string, timeout time.Duration) (net.Conn, error) {
+	if !p.Enabled() {
+		return net.DialTimeout("tcp", addr, timeout)
+	}
+
+	proxyAddr := net.JoinHostPort(p.Host, strconv.Itoa(p.Port))
+	conn, err := net.DialTimeout("tcp", proxyAddr, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach proxy %s: %w", proxyAddr, err)
+	}
+
+	if timeout > 0 {
+		conn.SetDeadline(time.Now().Add(timeout))
+	}
+
+	var tunnel net.Conn
+	switch p.Type {
+	case models.ProxyHTTP:
+		tunnel, err = httpConnect(conn, p, addr)
+	case models.ProxySOCKS4, models.ProxySOCKS4A:
+		tunnel, err = socks4Connect(conn, p, addr)
+	case models.ProxySOCKS5:
+		tunnel, err = socks5Connect(conn, p, addr)
+	default:
+		err = fmt.Errorf("unsupported proxy type: %s", p.Type)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("proxy %s: %w", proxyAddr, err)
+	}
+
+	tunnel.SetDeadline(time.Time{})
+	return tunnel, nil
+}
+
+func splitHostPort(addr string) (string, int, error) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", 0, err
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port <= 0 || port > 65535 {
+		return "", 0, fmt.Errorf("invalid port in %s", addr)
+	}
+	return host, port, nil
+}
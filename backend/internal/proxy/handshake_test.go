@@ -0,0 +1,318 @@
+package proxy
+
+import (
+	"bytes"
+	"freessh-backend/internal/models"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+const banner = "SSH-2.0-OpenSSH_9.6\r\n"
+
+// step is one exchange of a scripted proxy: it reads exactly expect from the
+// client, then writes reply.
+type step struct {
+	expect string
+	reply  string
+}
+
+type handshakeTest struct {
+	name    string
+	proxy   models.ProxyConfig
+	addr    string
+	steps   []step
+	wantErr string
+}
+
+func bytesOf(parts ...any) string {
+	var buf bytes.Buffer
+	for _, part := range parts {
+		switch v := part.(type) {
+		case string:
+			buf.WriteString(v)
+		case int:
+			buf.WriteByte(byte(v))
+		case []byte:
+			buf.Write(v)
+		}
+	}
+	return buf.String()
+}
+
+// runHandshake performs connect against a fake proxy following tt.steps over
+// a net.Pipe. The banner is sent right after the last reply, so a tunnel that
+// works reads it back.
+func runHandshake(t *testing.T, tt handshakeTest, connect func(net.Conn, *models.ProxyConfig, string) (net.Conn, error)) {
+	t.Helper()
+
+	client, server := net.Pipe()
+	defer client.Close()
+	client.SetDeadline(time.Now().Add(5 * time.Second))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer server.Close()
+		for i, s := range tt.steps {
+			got := make([]byte, len(s.expect))
+			if _, err := io.ReadFull(server, got); err != nil {
+				return
+			}
+			if string(got) != s.expect {
+				t.Errorf("step %d: proxy read %q, want %q", i, got, s.expect)
+				return
+			}
+			reply := s.reply
+			if i == len(tt.steps)-1 && tt.wantErr == "" {
+				reply += banner
+			}
+			if _, err := server.Write([]byte(reply)); err != nil {
+				return
+			}
+		}
+		// Hold the pipe open until the client is done with it
+		io.Copy(io.Discard, server)
+	}()
+
+	tunnel, err := connect(client, &tt.proxy, tt.addr)
+	if tt.wantErr != "" {
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("error = %v, want one containing %q", err, tt.wantErr)
+		}
+		client.Close()
+		<-done
+		return
+	}
+	if err != nil {
+		t.Fatalf("handshake error: %v", err)
+	}
+
+	got := make([]byte, len(banner))
+	if _, err := io.ReadFull(tunnel, got); err != nil {
+		t.Fatalf("failed to read through the tunnel: %v", err)
+	}
+	if string(got) != banner {
+		t.Errorf("tunnel read %q, want %q", got, banner)
+	}
+	client.Close()
+	<-done
+}
+
+func TestSOCKS4Connect(t *testing.T) {
+	granted := bytesOf(0, 0x5a, 0, 0, 0, 0, 0, 0)
+
+	tests := []handshakeTest{
+		{
+			name:  "IPv4 address",
+			proxy: models.ProxyConfig{Type: models.ProxySOCKS4},
+			addr:  "10.0.0.1:22",
+			steps: []step{{bytesOf(4, 1, 0, 22, 10, 0, 0, 1, 0), granted}},
+		},
+		{
+			name:  "user ID",
+			proxy: models.ProxyConfig{Type: models.ProxySOCKS4, Username: "alice"},
+			addr:  "10.0.0.1:2222",
+			steps: []step{{bytesOf(4, 1, 0x08, 0xae, 10, 0, 0, 1, "alice", 0), granted}},
+		},
+		{
+			name:  "4a sends the hostname",
+			proxy: models.ProxyConfig{Type: models.ProxySOCKS4A, Username: "alice"},
+			addr:  "example.com:22",
+			steps: []step{{bytesOf(4, 1, 0, 22, 0, 0, 0, 1, "alice", 0, "example.com", 0), granted}},
+		},
+		{
+			name:  "4a with an address",
+			proxy: models.ProxyConfig{Type: models.ProxySOCKS4A},
+			addr:  "10.0.0.1:22",
+			steps: []step{{bytesOf(4, 1, 0, 22, 10, 0, 0, 1, 0), granted}},
+		},
+		{
+			name:    "rejected",
+			proxy:   models.ProxyConfig{Type: models.ProxySOCKS4},
+			addr:    "10.0.0.1:22",
+			steps:   []step{{bytesOf(4, 1, 0, 22, 10, 0, 0, 1, 0), bytesOf(0, 0x5b, 0, 0, 0, 0, 0, 0)}},
+			wantErr: "rejected (code 91)",
+		},
+		{
+			name:    "invalid address",
+			proxy:   models.ProxyConfig{Type: models.ProxySOCKS4},
+			addr:    "10.0.0.1",
+			wantErr: "missing port",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runHandshake(t, tt, socks4Connect)
+		})
+	}
+}
+
+func TestSOCKS5Connect(t *testing.T) {
+	noAuth := step{bytesOf(5, 1, 0), bytesOf(5, 0)}
+	withAuth := step{bytesOf(5, 2, 0, 2), bytesOf(5, 2)}
+	hostRequest := bytesOf(5, 1, 0, 3, 11, "example.com", 0, 22)
+	succeeded := bytesOf(5, 0, 0, 1, 0, 0, 0, 0, 0, 0)
+
+	tests := []handshakeTest{
+		{
+			name:  "hostname",
+			proxy: models.ProxyConfig{Type: models.ProxySOCKS5},
+			addr:  "example.com:22",
+			steps: []step{noAuth, {hostRequest, succeeded}},
+		},
+		{
+			name:  "IPv4 address",
+			proxy: models.ProxyConfig{Type: models.ProxySOCKS5},
+			addr:  "192.168.1.2:22",
+			steps: []step{noAuth, {bytesOf(5, 1, 0, 1, 192, 168, 1, 2, 0, 22), succeeded}},
+		},
+		{
+			name:  "IPv6 address",
+			proxy: models.ProxyConfig{Type: models.ProxySOCKS5},
+			addr:  "[2001:db8::1]:22",
+			steps: []step{noAuth, {
+				bytesOf(5, 1, 0, 4, []byte(net.ParseIP("2001:db8::1")), 0, 22),
+				bytesOf(5, 0, 0, 4, make([]byte, 16), 0, 0),
+			}},
+		},
+		{
+			name:  "bound address is a hostname",
+			proxy: models.ProxyConfig{Type: models.ProxySOCKS5},
+			addr:  "example.com:22",
+			steps: []step{noAuth, {hostRequest, bytesOf(5, 0, 0, 3, 5, "proxy", 0, 0)}},
+		},
+		{
+			name:  "username and password",
+			proxy: models.ProxyConfig{Type: models.ProxySOCKS5, Username: "alice", Password: "secret"},
+			addr:  "example.com:22",
+			steps: []step{
+				withAuth,
+				{bytesOf(1, 5, "alice", 6, "secret"), bytesOf(1, 0)},
+				{hostRequest, succeeded},
+			},
+		},
+		{
+			name:  "credentials offered but not required",
+			proxy: models.ProxyConfig{Type: models.ProxySOCKS5, Username: "alice", Password: "secret"},
+			addr:  "example.com:22",
+			steps: []step{{bytesOf(5, 2, 0, 2), bytesOf(5, 0)}, {hostRequest, succeeded}},
+		},
+		{
+			name:  "wrong credentials",
+			proxy: models.ProxyConfig{Type: models.ProxySOCKS5, Username: "alice", Password: "wrong"},
+			addr:  "example.com:22",
+			steps: []step{
+				withAuth,
+				{bytesOf(1, 5, "alice", 5, "wrong"), bytesOf(1, 1)},
+			},
+			wantErr: "authentication failed",
+		},
+		{
+			name:    "authentication required",
+			proxy:   models.ProxyConfig{Type: models.ProxySOCKS5},
+			addr:    "example.com:22",
+			steps:   []step{{bytesOf(5, 1, 0), bytesOf(5, 0xff)}},
+			wantErr: "unsupported authentication method",
+		},
+		{
+			name:    "not a SOCKS5 proxy",
+			proxy:   models.ProxyConfig{Type: models.ProxySOCKS5},
+			addr:    "example.com:22",
+			steps:   []step{{bytesOf(5, 1, 0), bytesOf(4, 0)}},
+			wantErr: "not a SOCKS5 proxy",
+		},
+		{
+			name:    "connection refused",
+			proxy:   models.ProxyConfig{Type: models.ProxySOCKS5},
+			addr:    "example.com:22",
+			steps:   []step{noAuth, {hostRequest, bytesOf(5, 5, 0, 1, 0, 0, 0, 0, 0, 0)}},
+			wantErr: "connection refused",
+		},
+		{
+			name:    "unknown failure code",
+			proxy:   models.ProxyConfig{Type: models.ProxySOCKS5},
+			addr:    "example.com:22",
+			steps:   []step{noAuth, {hostRequest, bytesOf(5, 42, 0, 1, 0, 0, 0, 0, 0, 0)}},
+			wantErr: "code 42",
+		},
+		{
+			name:    "invalid bound address type",
+			proxy:   models.ProxyConfig{Type: models.ProxySOCKS5},
+			addr:    "example.com:22",
+			steps:   []step{noAuth, {hostRequest, bytesOf(5, 0, 0, 9)}},
+			wantErr: "invalid SOCKS5 address type 9",
+		},
+		{
+			name:    "hostname too long",
+			proxy:   models.ProxyConfig{Type: models.ProxySOCKS5},
+			addr:    strings.Repeat("a", 256) + ":22",
+			steps:   []step{noAuth},
+			wantErr: "hostname too long",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runHandshake(t, tt, socks5Connect)
+		})
+	}
+}
+
+func TestHTTPConnect(t *testing.T) {
+	request := "CONNECT example.com:22 HTTP/1.1\r\nHost: example.com:22\r\n"
+
+	tests := []handshakeTest{
+		{
+			name:  "established",
+			proxy: models.ProxyConfig{Type: models.ProxyHTTP},
+			addr:  "example.com:22",
+			steps: []step{{request + "\r\n", "HTTP/1.1 200 Connection established\r\n\r\n"}},
+		},
+		{
+			name:  "basic auth",
+			proxy: models.ProxyConfig{Type: models.ProxyHTTP, Username: "user", Password: "pass"},
+			addr:  "example.com:22",
+			steps: []step{{
+				request + "Proxy-Authorization: Basic dXNlcjpwYXNz\r\n\r\n",
+				"HTTP/1.1 200 OK\r\nProxy-Agent: test\r\n\r\n",
+			}},
+		},
+		{
+			name:  "HTTP/1.0 reply",
+			proxy: models.ProxyConfig{Type: models.ProxyHTTP},
+			addr:  "example.com:22",
+			steps: []step{{request + "\r\n", "HTTP/1.0 200 Connection established\r\n\r\n"}},
+		},
+		{
+			name:    "proxy auth required",
+			proxy:   models.ProxyConfig{Type: models.ProxyHTTP},
+			addr:    "example.com:22",
+			steps:   []step{{request + "\r\n", "HTTP/1.1 407 Proxy Authentication Required\r\nContent-Length: 0\r\n\r\n"}},
+			wantErr: "407",
+		},
+		{
+			name:    "forbidden",
+			proxy:   models.ProxyConfig{Type: models.ProxyHTTP},
+			addr:    "example.com:22",
+			steps:   []step{{request + "\r\n", "HTTP/1.1 403 Forbidden\r\nContent-Length: 0\r\n\r\n"}},
+			wantErr: "403 Forbidden",
+		},
+		{
+			name:    "not HTTP",
+			proxy:   models.ProxyConfig{Type: models.ProxyHTTP},
+			addr:    "example.com:22",
+			steps:   []step{{request + "\r\n", "\x05\x00garbage\r\n\r\n"}},
+			wantErr: "failed to read CONNECT response",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runHandshake(t, tt, httpConnect)
+		})
+	}
+}
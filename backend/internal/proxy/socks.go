@@ -0,0 +1,190 @@
+package proxy
+
+import (
+	"encoding/binary"
+	"fmt"
+	"freessh-backend/internal/models"
+	"io"
+	"net"
+)
+
+// socks4Connect implements SOCKS4, and SOCKS4a when the proxy should resolve
+// the hostname.
+func socks4Connect(conn net.Conn, p *models.ProxyConfig, addr string) (net.Conn, error) {
+	host, port, err := splitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	ip := net.ParseIP(host).To4()
+	if ip == nil && p.Type == models.ProxySOCKS4 {
+		// Plain SOCKS4 only carries IPv4 addresses, so resolve locally
+		addrs, err := net.LookupIP(host)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %w", host, err)
+		}
+		for _, candidate := range addrs {
+			if ip = candidate.To4(); ip != nil {
+				break
+			}
+		}
+		if ip == nil {
+			return nil, fmt.Errorf("no IPv4 address for %s", host)
+		}
+	}
+
+	request := []byte{4, 1, byte(port >> 8), byte(port)}
+	if ip != nil {
+		request = append(request, ip...)
+	} else {
+		// SOCKS4a: invalid IP 0.0.0.x followed by the hostname
+		request = append(request, 0, 0, 0, 1)
+	}
+	request = append(request, []byte(p.Username)...)
+	request = append(request, 0)
+	if ip == nil {
+		request = append(request, []byte(host)...)
+		request = append(request, 0)
+	}
+
+	if _, err := conn.Write(request); err != nil {
+		return nil, fmt.Errorf("failed to send SOCKS4 request: %w", err)
+	}
+
+	reply := make([]byte, 8)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return nil, fmt.Errorf("failed to read SOCKS4 reply: %w", err)
+	}
+	if reply[1] != 0x5a {
+		return nil, fmt.Errorf("SOCKS4 request rejected (code %d)", reply[1])
+	}
+
+	return conn, nil
+}
+
+var socks5Errors = map[byte]string{
+	1: "general failure",
+	2: "connection not allowed by ruleset",
+	3: "network unreachable",
+	4: "host unreachable",
+	5: "connection refused",
+	6: "TTL expired",
+	7: "command not supported",
+	8: "address type not supported",
+}
+
+// socks5Connect implements the SOCKS5 CONNECT command with optional
+// username/password authentication (RFC 1928, RFC 1929). Hostnames are
+// resolved by the proxy.
+func socks5Connect(conn net.Conn, p *models.ProxyConfig, addr string) (net.Conn, error) {
+	host, port, err := splitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	methods := []byte{0x00}
+	if p.Username != "" {
+		methods = []byte{0x00, 0x02}
+	}
+	greeting := append([]byte{5, byte(len(methods))}, methods...)
+	if _, err := conn.Write(greeting); err != nil {
+		return nil, fmt.Errorf("failed to send SOCKS5 greeting: %w", err)
+	}
+
+	choice := make([]byte, 2)
+	if _, err := io.ReadFull(conn, choice); err != nil {
+		return nil, fmt.Errorf("failed to read SOCKS5 greeting: %w", err)
+	}
+	if choice[0] != 5 {
+		return nil, fmt.Errorf("not a SOCKS5 proxy")
+	}
+
+	switch choice[1] {
+	case 0x00:
+	case 0x02:
+		if err := socks5Authenticate(conn, p); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("SOCKS5 proxy requires an unsupported authentication method")
+	}
+
+	request := []byte{5, 1, 0}
+	if ip := net.ParseIP(host); ip != nil {
+		if ip4 := ip.To4(); ip4 != nil {
+			request = append(request, 1)
+			request = append(request, ip4...)
+		} else {
+			request = append(request, 4)
+			request = append(request, ip.To16()...)
+		}
+	} else {
+		if len(host) > 255 {
+			return nil, fmt.Errorf("hostname too long: %s", host)
+		}
+		request = append(request, 3, byte(len(host)))
+		request = append(request, []byte(host)...)
+	}
+	request = binary.BigEndian.AppendUint16(request, uint16(port))
+
+	if _, err := conn.Write(request); err != nil {
+		return nil, fmt.Errorf("failed to send SOCKS5 request: %w", err)
+	}
+
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return nil, fmt.Errorf("failed to read SOCKS5 reply: %w", err)
+	}
+	if header[1] != 0 {
+		reason, ok := socks5Errors[header[1]]
+		if !ok {
+			reason = fmt.Sprintf("code %d", header[1])
+		}
+		return nil, fmt.Errorf("SOCKS5 connect failed: %s", reason)
+	}
+
+	// Skip the bound address and port
+	var skip int
+	switch header[3] {
+	case 1:
+		skip = net.IPv4len + 2
+	case 4:
+		skip = net.IPv6len + 2
+	case 3:
+		length := make([]byte, 1)
+		if _, err := io.ReadFull(conn, length); err != nil {
+			return nil, fmt.Errorf("failed to read SOCKS5 reply: %w", err)
+		}
+		skip = int(length[0]) + 2
+	default:
+		return nil, fmt.Errorf("invalid SOCKS5 address type %d", header[3])
+	}
+	if _, err := io.CopyN(io.Discard, conn, int64(skip)); err != nil {
+		return nil, fmt.Errorf("failed to read SOCKS5 reply: %w", err)
+	}
+
+	return conn, nil
+}
+
+func socks5Authenticate(conn net.Conn, p *models.ProxyConfig) error {
+	if len(p.Username) > 255 || len(p.Password) > 255 {
+		return fmt.Errorf("SOCKS5 credentials too long")
+	}
+
+	request := []byte{1, byte(len(p.Username))}
+	request = append(request, []byte(p.Username)...)
+	request = append(request, byte(len(p.Password)))
+	request = append(request, []byte(p.Password)...)
+	if _, err := conn.Write(request); err != nil {
+		return fmt.Errorf("failed to send SOCKS5 credentials: %w", err)
+	}
+
+	reply := make([]byte, 2)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return fmt.Errorf("failed to read SOCKS5 auth reply: %w", err)
+	}
+	if reply[1] != 0 {
+		return fmt.Errorf("SOCKS5 authentication failed")
+	}
+	return nil
+}
@@ -1,6 +1,8 @@
 package reconnect
 
 import (
+	"freessh-backend/internal/models"
+	"math/rand"
 	"time"
 )
 
@@ -8,6 +10,9 @@ type Config struct {
 	InitialDelay time.Duration
 	MaxDelay     time.Duration
 	MaxAttempts  int
+	// Jitter randomises each delay by up to this fraction of it, so clients
+	// that dropped together do not retry in lockstep.
+	Jitter float64
 }
 
 func DefaultConfig() Config {
@@ -18,6 +23,30 @@ func DefaultConfig() Config {
 	}
 }
 
+// FromOptions builds a Config from opts, keeping the defaults for anything
+// opts leaves unset.
+func FromOptions(opts models.NetworkOptions) Config {
+	config := DefaultConfig()
+	if opts.ReconnectInitialDelay > 0 {
+		config.InitialDelay = time.Duration(opts.ReconnectInitialDelay) * time.Second
+	}
+	if opts.ReconnectMaxDelay > 0 {
+		config.MaxDelay = time.Duration(opts.ReconnectMaxDelay) * time.Second
+	}
+	if config.MaxDelay < config.InitialDelay {
+		config.MaxDelay = config.InitialDelay
+	}
+	if opts.ReconnectMaxAttempts > 0 {
+		config.MaxAttempts = opts.ReconnectMaxAttempts
+	} else if opts.ReconnectMaxAttempts < 0 {
+		config.MaxAttempts = 0
+	}
+	if opts.ReconnectJitter > 0 {
+		config.Jitter = min(opts.ReconnectJitter, 1)
+	}
+	return config
+}
+
 type Backoff struct {
 	config       Config
 	attempt      int
@@ -46,6 +75,11 @@ func (b *Backoff) Next() (time.Duration, bool) {
 		b.currentDelay = b.config.MaxDelay
 	}
 
+	if b.config.Jitter > 0 {
+		spread := float64(delay) * b.config.Jitter
+		delay += time.Duration(spread * (2*rand.Float64() - 1))
+	}
+
 	return delay, true
 }
 
@@ -33,9 +33,9 @@ func (m *Manager) CloseSession(sessionID string) error {
 		session.Terminal.Close()
 	}
 
-	// Disconnect SSH (this stops keep-alive)
+	// Release the shared transport; the last session disconnects it
 	if session.SSHClient != nil {
-		session.SSHClient.Disconnect()
+		m.pool.release(session.SSHClient)
 	}
 
 	// Update status and remove
@@ -0,0 +1,294 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"freessh-backend/internal/keychain"
+	"freessh-backend/internal/models"
+	"freessh-backend/internal/ssh"
+	"freessh-backend/internal/storage"
+	"log"
+
+	sshpkg "golang.org/x/crypto/ssh"
+)
+
+// maxJumpHosts bounds ProxyJump chains so misconfigured loops fail fast.
+const maxJumpHosts = 8
+
+// connectHooks carries the UI callbacks used while a connection is set up.
+// Either may be nil when no UI is attached.
+type connectHooks struct {
+	verification func(*models.HostKeyVerification) error
+	authPrompt   func(*models.AuthPrompt) ([]string, error)
+}
+
+// loadCredentials fills the runtime-only secrets of config from the keychain
+// and the key file storage. When interactive is set, a missing password is
+// left empty so the client prompts for it. A method whose secrets cannot be
+// loaded is dropped so the remaining ones are still tried; an error is
+// returned only when none is left.
+func loadCredentials(config *models.ConnectionConfig, interactive bool) error {
+	kc := keychain.New()
+	var usable []models.AuthMethod
+	var failures []error
+	for _, method := range config.EffectiveAuthMethods() {
+		var err error
+		switch method {
+		case models.AuthPassword:
+			err = loadPassword(kc, config, interactive)
+		case models.AuthPublicKey:
+			err = loadPrivateKey(kc, config)
+		}
+		if err != nil {
+			log.Printf("Skipping %s auth for %s: %v", method, config.Host, err)
+			failures = append(failures, fmt.Errorf("%s: %w", method, err))
+			continue
+		}
+		usable = append(usable, method)
+	}
+
+	if len(usable) == 0 {
+		return errors.Join(failures...)
+	}
+	if len(failures) > 0 {
+		config.AuthMethods = usable
+	}
+	return nil
+}
+
+func loadPassword(kc *keychain.Keychain, config *models.ConnectionConfig, interactive bool) error {
+	password, err := kc.Get(config.ID)
+	if err != nil && !interactive {
+		return fmt.Errorf("password not found in keychain")
+	}
+	config.Password = password
+	return nil
+}
+
+func loadPrivateKey(kc *keychain.Keychain, config *models.ConnectionConfig) error {
+	// Load private key from file if KeyID is set (for generated keys)
+	if config.KeyID != "" {
+		fileStorage, err := storage.NewKeyFileStorage()
+		if err != nil {
+			return fmt.Errorf("failed to initialize key storage: %w", err)
+		}
+		privateKey, err := fileStorage.GetPrivateKey(config.KeyID)
+		if err != nil {
+			return fmt.Errorf("failed to load private key: %w", err)
+		}
+		config.PrivateKey = privateKey
+
+		certificate, err := fileStorage.GetCertificate(config.KeyID)
+		if err != nil {
+			return err
+		}
+		config.Certificate = certificate
+	}
+
+	// Get passphrase from keychain if key is encrypted
+	if config.PrivateKey != "" {
+		passphrase, _ := kc.Get(config.ID + ":passphrase")
+		config.Passphrase = passphrase
+	}
+	return nil
+}
+
+// ProxyFor returns the proxy used to reach config, with its password loaded
+// from the keychain, or nil for a direct connection. A connection's own proxy
+// setting takes precedence over the global one.
+func (m *Manager) ProxyFor(config models.ConnectionConfig) *models.ProxyConfig {
+	proxy, account := m.proxySetting(config)
+	if !proxy.Enabled() {
+		return nil
+	}
+	if proxy.Username != "" {
+		proxy.Password, _ = keychain.New().Get(account)
+	}
+	return proxy
+}
+
+// proxySetting returns a copy of the proxy setting that applies to config,
+// without its password, and the keychain account holding that password.
+func (m *Manager) proxySetting(config models.ConnectionConfig) (*models.ProxyConfig, string) {
+	if config.Proxy != nil {
+		copied := *config.Proxy
+		return &copied, models.ProxyKeychainAccount(config.ID)
+	}
+	if m.networkSettings != nil {
+		return m.networkSettings.GetProxy(), models.GlobalProxyKeychainAccount
+	}
+	return nil, ""
+}
+
+// NetworkOptionsFor returns the global network options overridden by those
+// set on config.
+func (m *Manager) NetworkOptionsFor(config models.ConnectionConfig) models.NetworkOptions {
+	var options models.NetworkOptions
+	if m.networkSettings != nil {
+		options = m.networkSettings.GetNetworkOptions()
+	}
+	return options.Merge(config.Network)
+}
+
+// CryptoPolicyFor returns the crypto policy of config, falling back to the
+// global one when the connection does not set its own.
+func (m *Manager) CryptoPolicyFor(config models.ConnectionConfig) *models.CryptoPolicy {
+	if !config.Crypto.IsZero() {
+		return config.Crypto
+	}
+	if m.networkSettings != nil {
+		return m.networkSettings.GetCryptoPolicy()
+	}
+	return nil
+}
+
+// EnvironmentFor returns the variables of the group config belongs to with
+// the connection's own variables applied on top.
+func (m *Manager) EnvironmentFor(config models.ConnectionConfig) []models.EnvVar {
+	var inherited []models.EnvVar
+	if config.Group != "" && m.groups != nil {
+		if group, err := m.groups.GetByName(config.Group); err == nil && group != nil {
+			inherited = group.Environment
+		}
+	}
+	return models.MergeEnvironment(inherited, config.Environment)
+}
+
+// OpenTransport returns a connected client for config for non-interactive
+// use. A transport already shared by open sessions is reused; otherwise a new
+// one is dialed that does not reconnect on its own. New host keys are trusted
+// and auth prompts are not answered. If ctx ends before the connection is up,
+// its error is returned and the connection is dropped once it completes.
+// Call release when done.
+func (m *Manager) OpenTransport(ctx context.Context, config models.ConnectionConfig) (client *ssh.Client, release func(), err error) {
+	if pooled := m.pool.acquire(m.transportKey(config)); pooled != nil {
+		return pooled, func() { m.pool.release(pooled) }, nil
+	}
+
+	client, err = m.newSSHClient(&config, connectHooks{})
+	if err != nil {
+		return nil, nil, err
+	}
+	client.DisableReconnect()
+
+	connected := make(chan error, 1)
+	go func() { connected <- client.Connect() }()
+
+	select {
+	case err := <-connected:
+		if err != nil {
+			return nil, nil, err
+		}
+		return client, func() { client.Disconnect() }, nil
+	case <-ctx.Done():
+		// Connect is bounded by the connect timeout, so this ends
+		go func() {
+			if <-connected == nil {
+				client.Disconnect()
+			}
+		}()
+		return nil, nil, ctx.Err()
+	}
+}
+
+// newSSHClient loads credentials into config and builds a client for it,
+// including the jump host chain. Every hop gets its own host key check.
+func (m *Manager) newSSHClient(config *models.ConnectionConfig, hooks connectHooks) (*ssh.Client, error) {
+	knownHostStorage, err := storage.NewKnownHostStorage()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize known hosts storage: %w", err)
+	}
+
+	verifier := ssh.NewHostKeyVerifier(knownHostStorage)
+	return m.buildClient(config, verifier, hooks, nil)
+}
+
+func (m *Manager) buildClient(config *models.ConnectionConfig, verifier *ssh.HostKeyVerifier, hooks connectHooks, path []string) (*ssh.Client, error) {
+	if err := loadCredentials(config, hooks.authPrompt != nil); err != nil {
+		return nil, err
+	}
+
+	client := ssh.NewClient(*config)
+	client.SetProxy(m.ProxyFor(*config))
+	client.SetNetworkOptions(m.NetworkOptionsFor(*config))
+	client.SetCryptoPolicy(m.CryptoPolicyFor(*config))
+	if hooks.authPrompt != nil {
+		client.SetAuthPromptCallback(hooks.authPrompt)
+	}
+
+	// Set up host key verification callback
+	callback := verifier.CreateCallback(config.Host, config.Port, func(verification *models.HostKeyVerification) error {
+		// If verification callback provided, use it
+		if hooks.verification != nil {
+			return hooks.verification(verification)
+		}
+
+		// Otherwise auto-trust new hosts; new key types on known hosts
+		// and changed keys always need explicit approval
+		if verification.Status == "new" {
+			return nil
+		}
+		return fmt.Errorf("host key verification failed")
+	})
+	client.SetHostKeyCallback(callback)
+	client.SetHostKeyAlgorithms(verifier.PreferredHostKeyAlgorithms(config.Host, config.Port))
+	client.SetHostKeysCallback(func(keys []sshpkg.PublicKey) error {
+		return verifier.RecordHostKeys(config.Host, config.Port, keys)
+	})
+
+	if len(config.JumpHosts) > 0 {
+		hop, err := m.buildJumpChain(config, verifier, hooks, path)
+		if err != nil {
+			return nil, err
+		}
+		client.SetJumpHost(hop)
+	}
+
+	return client, nil
+}
+
+// buildJumpChain resolves config.JumpHosts into connected-on-demand clients.
+// The first hop honours its own saved jump hosts; each later hop is reached
+// through the one before it. The last hop is returned.
+func (m *Manager) buildJumpChain(config *models.ConnectionConfig, verifier *ssh.HostKeyVerifier, hooks connectHooks, path []string) (*ssh.Client, error) {
+	if m.storage == nil {
+		return nil, fmt.Errorf("storage not available for jump hosts")
+	}
+
+	path = append(append([]string{}, path...), config.ID)
+
+	var previous *ssh.Client
+	for _, jumpID := range config.JumpHosts {
+		for _, visited := range path {
+			if visited == jumpID {
+				return nil, fmt.Errorf("jump host loop detected at %s", jumpID)
+			}
+		}
+		if len(path) > maxJumpHosts {
+			return nil, fmt.Errorf("too many jump hosts (max %d)", maxJumpHosts)
+		}
+
+		jumpConfig, err := m.storage.Get(jumpID)
+		if err != nil {
+			return nil, fmt.Errorf("jump host %s: %w", jumpID, err)
+		}
+		if previous != nil {
+			jumpConfig.JumpHosts = nil
+		}
+
+		hop, err := m.buildClient(jumpConfig, verifier, hooks, path)
+		if err != nil {
+			return nil, fmt.Errorf("jump host %s: %w", jumpConfig.Name, err)
+		}
+		hop.DisableReconnect()
+		if previous != nil {
+			hop.SetJumpHost(previous)
+		}
+
+		path = append(path, jumpID)
+		previous = hop
+	}
+
+	return previous, nil
+}
@@ -2,12 +2,9 @@ package session
 
 import (
 	"fmt"
-	"freessh-backend/internal/keychain"
 	"freessh-backend/internal/localterminal"
 	"freessh-backend/internal/models"
 	"freessh-backend/internal/osdetect"
-	"freessh-backend/internal/ssh"
-	"freessh-backend/internal/storage"
 	"freessh-backend/internal/terminal"
 	"runtime"
 	"time"
@@ -20,6 +17,12 @@ func (m *Manager) CreateSession(config models.ConnectionConfig) (*models.Session
 }
 
 func (m *Manager) CreateSessionWithVerification(config models.ConnectionConfig, verificationCallback func(*models.HostKeyVerification) error) (*models.Session, error) {
+	return m.CreateSessionWithPrompts(config, verificationCallback, nil)
+}
+
+// CreateSessionWithPrompts is CreateSessionWithVerification with an extra
+// callback that answers keyboard-interactive challenges and password prompts.
+func (m *Manager) CreateSessionWithPrompts(config models.ConnectionConfig, verificationCallback func(*models.HostKeyVerification) error, promptCallback func(*models.AuthPrompt) ([]string, error)) (*models.Session, error) {
 	config.Profile = models.NormalizeSessionProfile(config.Profile)
 	sessionID := uuid.New().String()
 
@@ -38,89 +41,52 @@ func (m *Manager) CreateSessionWithVerification(config models.ConnectionConfig,
 		}
 	}
 
-	// Fetch credentials from keychain
-	kc := keychain.New()
-	if config.AuthMethod == models.AuthPassword {
-		password, err := kc.Get(config.ID)
+	// Reuse a live transport opened for the same connection and settings
+	transportKey := m.transportKey(config)
+	sshClient := m.pool.acquire(transportKey)
+	if sshClient == nil {
+		client, err := m.newSSHClient(&config, connectHooks{
+			verification: verificationCallback,
+			authPrompt:   promptCallback,
+		})
 		if err != nil {
 			session.Status = models.SessionError
-			session.Error = "Password not found in keychain"
-			return &session, fmt.Errorf("password not found in keychain")
-		}
-		config.Password = password
-	} else if config.AuthMethod == models.AuthPublicKey {
-		// Load private key from file if KeyID is set (for generated keys)
-		if config.KeyID != "" {
-			fileStorage, err := storage.NewKeyFileStorage()
-			if err != nil {
-				session.Status = models.SessionError
-				session.Error = "Failed to initialize key storage"
-				return &session, fmt.Errorf("failed to initialize key storage: %w", err)
-			}
-			privateKey, err := fileStorage.GetPrivateKey(config.KeyID)
-			if err != nil {
-				session.Status = models.SessionError
-				session.Error = "Failed to load private key"
-				return &session, fmt.Errorf("failed to load private key: %w", err)
-			}
-			config.PrivateKey = privateKey
-		}
-
-		// Get passphrase from keychain if key is encrypted
-		if config.PrivateKey != "" {
-			passphrase, _ := kc.Get(config.ID + ":passphrase")
-			config.Passphrase = passphrase
-		}
-	}
-
-	// Initialize host key verification
-	knownHostStorage, err := storage.NewKnownHostStorage()
-	if err != nil {
-		session.Status = models.SessionError
-		session.Error = "Failed to initialize known hosts storage"
-		return &session, fmt.Errorf("failed to initialize known hosts storage: %w", err)
-	}
-
-	verifier := ssh.NewHostKeyVerifier(knownHostStorage)
-
-	sshClient := ssh.NewClient(config)
-
-	// Set up host key verification callback
-	callback := verifier.CreateCallback(config.Host, config.Port, func(verification *models.HostKeyVerification) error {
-		// If verification callback provided, use it
-		if verificationCallback != nil {
-			return verificationCallback(verification)
+			session.Error = err.Error()
+			return &session, err
 		}
 
-		// Otherwise auto-trust new hosts
-		if verification.Status == "new" {
-			return nil
+		if err := client.Connect(); err != nil {
+			session.Status = models.SessionError
+			session.Error = err.Error()
+			return &session, err
 		}
-		return fmt.Errorf("host key verification failed")
-	})
-	sshClient.SetHostKeyCallback(callback)
 
-	if err := sshClient.Connect(); err != nil {
-		session.Status = models.SessionError
-		session.Error = err.Error()
-		return &session, err
+		sshClient = client
+		sshClient.SetConnectionLostCallback(func(err error) {
+			m.reconnectSessionsOf(sshClient, err)
+		})
+		m.pool.add(transportKey, sshClient)
 	}
 
 	profileTerm := ""
 	if config.Profile != nil {
 		profileTerm = config.Profile.Term
 	}
+	// Detect OS type, which decides how the terminal exports variables
+	osType, _ := osdetect.DetectOS(sshClient.GetSSHClient())
+	session.OSType = string(osType)
+
 	term := terminal.NewTerminal(sshClient)
+	term.SetEnvironment(m.EnvironmentFor(config))
+	term.SetOSType(session.OSType)
 	if err := term.Initialize(profileTerm, 24, 80); err != nil {
-		sshClient.Disconnect()
+		m.pool.release(sshClient)
 		session.Status = models.SessionError
 		session.Error = err.Error()
 		return &session, err
 	}
-
-	// Detect OS type
-	osType, _ := osdetect.DetectOS(sshClient.GetSSHClient())
-	session.OSType = string(osType)
+	session.AuthMethod = sshClient.AuthMethodUsed()
+	session.Algorithms = sshClient.NegotiatedAlgorithms()
 
 	session.Status = models.SessionConnected
 	session.ConnectedAt = time.Now()
@@ -128,36 +94,26 @@ func (m *Manager) CreateSessionWithVerification(config models.ConnectionConfig,
 
 	activeSession := NewActiveSession(sessionID, sshClient, term, session)
 	activeSession.Config = config
-
-	sshClient.SetReconnectCallbacks(
-		func(attempt int) {},
-		func() {},
-		func(err error) {
-			if err == nil {
-				err = fmt.Errorf("reconnect failed")
-			}
-			wrapped := fmt.Errorf("reconnect failed: %w", err)
-			select {
-			case activeSession.ErrorChan <- wrapped:
-			default:
-				// Drop duplicate reconnect failure if reader is already processing.
-			}
-		},
-	)
+	activeSession.PortForwardMgr = m.pool.forwards(sshClient)
 
 	m.AddSession(activeSession)
 
 	go m.readOutput(activeSession)
 	m.initShellHistoryHook(sessionID)
 	m.applySessionProfile(activeSession)
+	m.reportRefusedEnvironment(activeSession)
+	m.reportX11Failure(activeSession)
 
 	// Auto-start logging if enabled
 	if m.logSettings != nil && m.logSettings.GetAutoLogging() {
 		m.StartLogging(sessionID)
 	}
 
-	// Auto-start port forwards
-	m.AutoStartPortForwards(sessionID, config.ID)
+	// Auto-start port forwards, unless an earlier session on the shared
+	// transport already has them running
+	if m.pool.claimAutoStart(sshClient, config.ID) {
+		m.AutoStartPortForwards(sessionID, config.ID)
+	}
 
 	return &session, nil
 }
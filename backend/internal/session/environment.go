@@ -0,0 +1,51 @@
+package session
+
+import (
+	"freessh-backend/internal/models"
+	"freessh-backend/internal/osdetect"
+	"strings"
+)
+
+// reportRefusedEnvironment tells the UI which variables the server refused to
+// set. The terminal exported them in the shell instead, so they only reach
+// the login shell and not commands run over separate channels. Windows shells
+// get no export line, so there they are not set at all.
+func (m *Manager) reportRefusedEnvironment(as *ActiveSession) {
+	if as == nil || as.Terminal == nil {
+		return
+	}
+
+	refused := as.Terminal.RefusedEnvironment()
+	if len(refused) == 0 {
+		return
+	}
+
+	message := "server refused environment variables (exported in shell instead): "
+	if osdetect.OSType(as.Session.OSType) == osdetect.Windows {
+		message = "server refused environment variables: "
+	}
+	as.sendStatus(models.SessionStatusEvent{
+		Status: models.SessionConnected,
+		Reason: "environment_refused",
+		Error:  message + strings.Join(refused, ", "),
+	})
+}
+
+// reportX11Failure tells the UI that X11 forwarding was requested for the
+// session but could not be set up. The shell works without it.
+func (m *Manager) reportX11Failure(as *ActiveSession) {
+	if as == nil || as.Terminal == nil {
+		return
+	}
+
+	err := as.Terminal.X11ForwardingError()
+	if err == nil {
+		return
+	}
+
+	as.sendStatus(models.SessionStatusEvent{
+		Status: models.SessionConnected,
+		Reason: "x11_forwarding_failed",
+		Error:  "X11 forwarding unavailable: " + err.Error(),
+	})
+}
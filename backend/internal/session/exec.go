@@ -0,0 +1,120 @@
+package session
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"freessh-backend/internal/config"
+	"freessh-backend/internal/models"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	activeExecs = make(map[string]context.CancelFunc)
+	execsMu     sync.Mutex
+)
+
+// execWriter forwards each write of a command's output stream as a chunk.
+type execWriter struct {
+	execID string
+	stream models.ExecStream
+	output func(models.ExecOutput)
+}
+
+func (w *execWriter) Write(p []byte) (int, error) {
+	w.output(models.ExecOutput{
+		ExecID: w.execID,
+		Stream: w.stream,
+		Data:   bytes.Clone(p),
+	})
+	return len(p), nil
+}
+
+// RunCommand runs req.Command on the connection of req.SessionID in a separate
+// channel, passing output chunks to output as they arrive. Only invalid
+// requests are returned as errors; once the command is attempted, how it
+// ended, including timeouts, cancellation and channel errors, is reported in
+// the result.
+func (m *Manager) RunCommand(req models.ExecRequest, output func(models.ExecOutput)) (*models.ExecResult, error) {
+	if strings.TrimSpace(req.Command) == "" {
+		return nil, fmt.Errorf("command is required")
+	}
+
+	as, err := m.GetSession(req.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	if as.SSHClient == nil {
+		return nil, fmt.Errorf("session %s is not an SSH session", req.SessionID)
+	}
+	if as.isReconnecting() {
+		return nil, fmt.Errorf("session %s is reconnecting", req.SessionID)
+	}
+
+	timeout := config.DefaultExecTimeout
+	if req.TimeoutSeconds > 0 {
+		timeout = time.Duration(req.TimeoutSeconds) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	execsMu.Lock()
+	if _, exists := activeExecs[req.ExecID]; exists {
+		execsMu.Unlock()
+		return nil, fmt.Errorf("exec %s is already running", req.ExecID)
+	}
+	activeExecs[req.ExecID] = cancel
+	execsMu.Unlock()
+
+	defer func() {
+		execsMu.Lock()
+		delete(activeExecs, req.ExecID)
+		execsMu.Unlock()
+	}()
+
+	var stdin io.Reader
+	if req.Stdin != "" {
+		stdin = strings.NewReader(req.Stdin)
+	}
+
+	started := time.Now()
+	status, err := as.SSHClient.Exec(ctx, req.Command, stdin,
+		&execWriter{execID: req.ExecID, stream: models.ExecStdout, output: output},
+		&execWriter{execID: req.ExecID, stream: models.ExecStderr, output: output},
+	)
+
+	result := &models.ExecResult{
+		ExecID:     req.ExecID,
+		ExitCode:   status.Code,
+		Signal:     status.Signal,
+		DurationMs: time.Since(started).Milliseconds(),
+	}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		result.TimedOut = true
+		result.Error = fmt.Sprintf("command timed out after %s", timeout)
+	case errors.Is(err, context.Canceled):
+		result.Cancelled = true
+		result.Error = "command cancelled"
+	case err != nil:
+		result.Error = err.Error()
+	}
+	return result, nil
+}
+
+// CancelCommand stops a command started by RunCommand.
+func (m *Manager) CancelCommand(execID string) bool {
+	execsMu.Lock()
+	defer execsMu.Unlock()
+
+	if cancel, ok := activeExecs[execID]; ok {
+		cancel()
+		delete(activeExecs, execID)
+		return true
+	}
+	return false
+}
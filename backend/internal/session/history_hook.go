@@ -1,10 +1,17 @@
 package session
 
 import (
+	"bytes"
+	"context"
 	"freessh-backend/internal/freesshhistory"
+	"io"
 	"strings"
+	"time"
 )
 
+// shellDetectTimeout keeps a hung login shell from delaying session setup.
+const shellDetectTimeout = 10 * time.Second
+
 func (m *Manager) initShellHistoryHook(sessionID string) {
 	activeSession, err := m.GetSession(sessionID)
 	if err != nil {
@@ -36,16 +43,14 @@ func (m *Manager) detectRemoteShell(as *ActiveSession) string {
 		return ""
 	}
 
-	sshSession, err := as.SSHClient.GetSSHClient().NewSession()
-	if err != nil {
-		return ""
-	}
-	defer sshSession.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), shellDetectTimeout)
+	defer cancel()
 
-	output, err := sshSession.Output(`printf '%s' "$SHELL"`)
-	if err != nil {
+	var output bytes.Buffer
+	status, err := as.SSHClient.Exec(ctx, `printf '%s' "$SHELL"`, nil, &output, io.Discard)
+	if err != nil || status.Code != 0 {
 		return ""
 	}
 
-	return strings.TrimSpace(string(output))
+	return strings.TrimSpace(output.String())
 }
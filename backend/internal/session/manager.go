@@ -2,8 +2,10 @@ package session
 
 import (
 	"fmt"
+	"freessh-backend/internal/config"
 	"freessh-backend/internal/settings"
 	"freessh-backend/internal/storage"
+	"freessh-backend/internal/throttle"
 	"sync"
 )
 
@@ -11,10 +13,29 @@ type Manager struct {
 	sessions        map[string]*ActiveSession
 	storage         *storage.ConnectionStorage
 	logSettings     *settings.LogSettingsStorage
+	networkSettings *settings.NetworkSettingsStorage
+	pool            *transportPool
+	journal         *storage.TransferJournalStorage
+	groups          *storage.GroupStorage
+	rates           *throttle.Registry
+	slots           *throttle.Slots
 	mu              sync.RWMutex
 }
 
-func NewManager(logSettings *settings.LogSettingsStorage) *Manager {
+func NewManager(logSettings *settings.LogSettingsStorage, networkSettings *settings.NetworkSettingsStorage) *Manager {
+	journal, journalErr := storage.NewTransferJournalStorage()
+	if journalErr != nil {
+		journal = nil
+	} else {
+		// Transfers still marked running were cut off by the last shutdown
+		journal.MarkInterrupted()
+	}
+
+	groups, err := storage.NewGroupStorage()
+	if err != nil {
+		groups = nil
+	}
+
 	storage, err := storage.NewConnectionStorage()
 	if err != nil {
 		// Log error but don't fail - storage is optional
@@ -24,10 +45,28 @@ func NewManager(logSettings *settings.LogSettingsStorage) *Manager {
 	return &Manager{
 		sessions:    make(map[string]*ActiveSession),
 		storage:     storage,
-		logSettings: logSettings,
+		logSettings:     logSettings,
+		networkSettings: networkSettings,
+		pool:            newTransportPool(),
+		journal:         journal,
+		groups:          groups,
+		rates:           throttle.NewRegistry(),
+		slots:           throttle.NewSlots(config.DefaultTransferConcurrency, config.DefaultTransferPerHost),
 	}
 }
 
+// Throttle returns the bandwidth limiters shared by every transfer of this
+// manager, including those run by the transfer queue.
+func (m *Manager) Throttle() *throttle.Registry {
+	return m.rates
+}
+
+// Slots returns the concurrency limits shared by every transfer of this
+// manager, including those run by the transfer queue.
+func (m *Manager) Slots() *throttle.Slots {
+	return m.slots
+}
+
 func (m *Manager) GetConnectionStorage() *storage.ConnectionStorage {
 	return m.storage
 }
@@ -58,6 +58,17 @@ func (m *Manager) pipeOutput(ctx context.Context, as *ActiveSession, reader io.R
 					default:
 					}
 
+					// Streams of a replaced shell end after cancellation
+					if ctx.Err() != nil {
+						return
+					}
+
+					// A dead transport is recovered rather than reported
+					if m.connectionLost(as) {
+						go m.reconnectSession(as, err)
+						return
+					}
+
 					streamErr := err
 					if err == io.EOF {
 						streamErr = fmt.Errorf("terminal stream closed")
@@ -0,0 +1,194 @@
+package session
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"freessh-backend/internal/models"
+	"freessh-backend/internal/portforward"
+	"freessh-backend/internal/ssh"
+	"strings"
+	"sync"
+)
+
+// transportPool shares one SSH connection between every session opened for
+// the same saved connection, like OpenSSH's ControlMaster. Every user of the transport, a session or an OpenTransport
+// caller, holds a reference; the transport is closed when the last one is
+// released. Port forwards belong to the transport rather than to the session
+// that started them, so they outlive it while the transport is shared.
+type transportPool struct {
+	byKey    map[string]*pooledTransport
+	byClient map[*ssh.Client]*pooledTransport
+	mu       sync.Mutex
+}
+
+type pooledTransport struct {
+	key      string
+	client   *ssh.Client
+	forwards *portforward.Manager
+	refs     int
+	// autoStarted holds the connections whose auto-start forwards run on
+	// the transport.
+	autoStarted map[string]bool
+	// reconnectMu serialises reconnects requested by the sessions sharing
+	// the transport, so only the first one redials.
+	reconnectMu sync.Mutex
+}
+
+func newTransportPool() *transportPool {
+	return &transportPool{
+		byKey:    make(map[string]*pooledTransport),
+		byClient: make(map[*ssh.Client]*pooledTransport),
+	}
+}
+
+// acquire returns a live client pooled under key with its reference count
+// raised, or nil if none is pooled.
+func (p *transportPool) acquire(key string) *ssh.Client {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	entry, ok := p.byKey[key]
+	if !ok || !entry.client.IsConnected() {
+		return nil
+	}
+	entry.refs++
+	return entry.client
+}
+
+// add registers a freshly connected client under key with one reference.
+// New sessions share it from then on, replacing any transport pooled under
+// the same key that has since dropped.
+func (p *transportPool) add(key string, client *ssh.Client) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	entry := &pooledTransport{
+		key:         key,
+		client:      client,
+		forwards:    portforward.NewManager(),
+		refs:        1,
+		autoStarted: make(map[string]bool),
+	}
+	p.byClient[client] = entry
+	p.byKey[key] = entry
+}
+
+// release drops one reference to client and, when no session uses it any
+// more, stops its port forwards and disconnects it.
+func (p *transportPool) release(client *ssh.Client) {
+	p.mu.Lock()
+	entry, ok := p.byClient[client]
+	if ok {
+		entry.refs--
+		if entry.refs > 0 {
+			p.mu.Unlock()
+			return
+		}
+		delete(p.byClient, client)
+		if p.byKey[entry.key] == entry {
+			delete(p.byKey, entry.key)
+		}
+	}
+	p.mu.Unlock()
+
+	if ok {
+		entry.forwards.StopAll()
+	}
+	client.Disconnect()
+}
+
+// forwards returns the port forwards of client, shared by every session on
+// it.
+func (p *transportPool) forwards(client *ssh.Client) *portforward.Manager {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if entry, ok := p.byClient[client]; ok {
+		return entry.forwards
+	}
+	return portforward.NewManager()
+}
+
+// claimAutoStart reports whether the auto-start forwards of connectionID
+// still have to be started on client, and marks them started.
+func (p *transportPool) claimAutoStart(client *ssh.Client, connectionID string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	entry, ok := p.byClient[client]
+	if !ok || entry.autoStarted[connectionID] {
+		return false
+	}
+	entry.autoStarted[connectionID] = true
+	return true
+}
+
+// inUse reports whether any session still holds a reference to client.
+func (p *transportPool) inUse(client *ssh.Client) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.byClient[client]
+	return ok
+}
+
+// reconnect re-establishes client's transport unless another session sharing
+// it already has. alive reports whether the transport currently works.
+func (p *transportPool) reconnect(client *ssh.Client, alive func() bool) error {
+	p.mu.Lock()
+	entry := p.byClient[client]
+	p.mu.Unlock()
+
+	if entry != nil {
+		entry.reconnectMu.Lock()
+		defer entry.reconnectMu.Unlock()
+	}
+	if alive() {
+		return nil
+	}
+	return client.Reconnect()
+}
+
+// transportKey identifies the transport config needs: the connection it
+// belongs to, the target, the route to it through jump hosts or a proxy, the
+// identity it authenticates as and the settings the transport is set up with.
+// Sessions share a transport only when their keys match, so editing any of
+// these gets a connection a transport of its own, and two saved connections
+// to the same target never share one.
+func (m *Manager) transportKey(config models.ConnectionConfig) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "%s %s@%s:%d", config.ID, config.Username, config.Host, config.Port)
+
+	if len(config.JumpHosts) > 0 {
+		for _, jumpID := range config.JumpHosts {
+			builder.WriteString(" via ")
+			if m.storage != nil {
+				if hop, err := m.storage.Get(jumpID); err == nil {
+					fmt.Fprintf(&builder, "%s@%s:%d/", hop.Username, hop.Host, hop.Port)
+				}
+			}
+			builder.WriteString(jumpID)
+		}
+	} else if config.ProxyCommand != "" {
+		fmt.Fprintf(&builder, " command %q", config.ProxyCommand)
+	} else if proxy, _ := m.proxySetting(config); proxy.Enabled() {
+		fmt.Fprintf(&builder, " proxy %s://%s@%s:%d", proxy.Type, proxy.Username, proxy.Host, proxy.Port)
+	}
+
+	fmt.Fprintf(&builder, " auth %v", config.EffectiveAuthMethods())
+	if config.KeyID != "" {
+		fmt.Fprintf(&builder, " key %s", config.KeyID)
+	}
+	if config.PrivateKey != "" || config.Certificate != "" {
+		// Keys given inline are told apart by a digest, not kept in the key
+		sum := sha256.Sum256([]byte(config.PrivateKey + "\n" + config.Certificate))
+		fmt.Fprintf(&builder, " inline %x", sum[:8])
+	}
+
+	// Forwarding is requested on, and algorithms and timeouts are fixed for,
+	// the transport as a whole
+	if policy := m.CryptoPolicyFor(config); !policy.IsZero() {
+		fmt.Fprintf(&builder, " crypto %+v", *policy)
+	}
+	fmt.Fprintf(&builder, " agent %t x11 %t", config.ForwardAgent, config.ForwardX11)
+	fmt.Fprintf(&builder, " network %+v", m.NetworkOptionsFor(config))
+	return builder.String()
+}
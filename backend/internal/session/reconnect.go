@@ -0,0 +1,153 @@
+package session
+
+import (
+	"fmt"
+	"freessh-backend/internal/models"
+	"freessh-backend/internal/reconnect"
+	"freessh-backend/internal/ssh"
+	"time"
+)
+
+// streamCheckTimeout bounds the keepalive used to tell a closed shell from a
+// dropped connection.
+const streamCheckTimeout = 5 * time.Second
+
+// connectionLost reports whether the SSH connection behind as is gone, as
+// opposed to the shell having exited on its own.
+func (m *Manager) connectionLost(as *ActiveSession) bool {
+	if as.SSHClient == nil {
+		return false
+	}
+	if as.isReconnecting() {
+		return true
+	}
+	return as.SSHClient.Ping(streamCheckTimeout) != nil
+}
+
+// reconnectSessionsOf recovers every session sharing client after its
+// keepalive failed.
+func (m *Manager) reconnectSessionsOf(client *ssh.Client, cause error) {
+	for _, as := range m.GetAllSessions() {
+		if as.SSHClient == client {
+			go m.reconnectSession(as, cause)
+		}
+	}
+}
+
+// reconnectSession re-establishes the SSH connection of as with backoff and
+// rebuilds everything that was bound to the old one: the shell, the output
+// pipes, SFTP, port forwards and shell integration. Progress is reported on
+// StatusChan; giving up is reported on ErrorChan like any stream failure.
+func (m *Manager) reconnectSession(as *ActiveSession, cause error) {
+	if !as.beginReconnect() {
+		return
+	}
+	defer as.endReconnect()
+
+	// Detach the output pipes before the old shell goes away
+	if as.cancelOutput != nil {
+		as.cancelOutput()
+	}
+	if as.Terminal != nil {
+		as.Terminal.Close()
+	}
+
+	reconnectConfig := reconnect.FromOptions(m.NetworkOptionsFor(as.Config))
+	backoff := reconnect.NewBackoff(reconnectConfig)
+	lastErr := cause
+	if lastErr == nil {
+		lastErr = fmt.Errorf("connection lost")
+	}
+
+	for {
+		delay, ok := backoff.Next()
+		if !ok {
+			break
+		}
+
+		as.Session.Status = models.SessionReconnecting
+		as.sendStatus(models.SessionStatusEvent{
+			Status:      models.SessionReconnecting,
+			Reason:      "connection_lost",
+			Attempt:     backoff.Attempt(),
+			MaxAttempts: reconnectConfig.MaxAttempts,
+			Error:       lastErr.Error(),
+		})
+
+		select {
+		case <-time.After(delay):
+		case <-as.stopChan:
+			return
+		}
+
+		alive := func() bool { return as.SSHClient.Ping(streamCheckTimeout) == nil }
+		if err := m.pool.reconnect(as.SSHClient, alive); err != nil {
+			lastErr = err
+			continue
+		}
+
+		warning, err := m.restoreSession(as)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		// The session may have been closed while the handshake was running
+		select {
+		case <-as.stopChan:
+			if !m.pool.inUse(as.SSHClient) {
+				as.SSHClient.Disconnect()
+			}
+			return
+		default:
+		}
+
+		as.Session.Status = models.SessionConnected
+		as.Session.AuthMethod = as.SSHClient.AuthMethodUsed()
+		as.Session.Algorithms = as.SSHClient.NegotiatedAlgorithms()
+		event := models.SessionStatusEvent{
+			Status:  models.SessionConnected,
+			Reason:  "reconnected",
+			Attempt: backoff.Attempt(),
+		}
+		if warning != nil {
+			event.Error = warning.Error()
+		}
+		as.sendStatus(event)
+		return
+	}
+
+	select {
+	case as.ErrorChan <- fmt.Errorf("reconnect failed: %w", lastErr):
+	default:
+	}
+}
+
+// restoreSession rebinds as to its SSH client's new connection. A failed
+// shell is fatal; SFTP and tunnel failures are returned as a warning.
+func (m *Manager) restoreSession(as *ActiveSession) (warning error, err error) {
+	if err := as.Terminal.Reopen(); err != nil {
+		return nil, fmt.Errorf("failed to reopen terminal: %w", err)
+	}
+	m.readOutput(as)
+
+	if err := as.Terminal.X11ForwardingError(); err != nil {
+		warning = fmt.Errorf("X11 forwarding unavailable: %w", err)
+	}
+
+	if as.SFTPClient != nil {
+		if err := as.SFTPClient.Reconnect(); err != nil {
+			warning = err
+		}
+	}
+
+	if as.PortForwardMgr != nil {
+		if err := as.PortForwardMgr.Reattach(as.SSHClient.GetSSHClient()); err != nil && warning == nil {
+			warning = err
+		}
+	}
+
+	m.initShellHistoryHook(as.ID)
+
+	return warning, nil
+}
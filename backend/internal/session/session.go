@@ -23,12 +23,15 @@ type ActiveSession struct {
 	Config         models.ConnectionConfig
 	OutputChan     chan []byte
 	ErrorChan      chan error
+	StatusChan     chan models.SessionStatusEvent
 	stopChan       chan struct{}
 	cancelOutput   context.CancelFunc
 	logFile        *os.File
 	isLogging      bool
 	logMutex       sync.Mutex
 	stopOnce       sync.Once
+	reconnecting   bool
+	reconnectMu    sync.Mutex
 }
 
 func NewActiveSession(id string, sshClient *ssh.Client, term *terminal.Terminal, session models.Session) *ActiveSession {
@@ -41,6 +44,7 @@ func NewActiveSession(id string, sshClient *ssh.Client, term *terminal.Terminal,
 		Session:        session,
 		OutputChan:     make(chan []byte, 500), // Increased from 100 to 500
 		ErrorChan:      make(chan error, 10),
+		StatusChan:     make(chan models.SessionStatusEvent, 10),
 		stopChan:       make(chan struct{}),
 	}
 }
@@ -52,6 +56,7 @@ func NewLocalSession(id string, localTerm *localterminal.Terminal, session model
 		Session:       session,
 		OutputChan:    make(chan []byte, 500), // Increased from 100 to 500
 		ErrorChan:     make(chan error, 10),
+		StatusChan:    make(chan models.SessionStatusEvent, 10),
 		stopChan:      make(chan struct{}),
 	}
 }
@@ -62,10 +67,7 @@ func (as *ActiveSession) Stop() {
 		as.cancelOutput()
 	}
 
-	// Stop port forwarding first
-	if as.PortForwardMgr != nil {
-		as.PortForwardMgr.StopAll()
-	}
+	// Port forwards belong to the transport and stop with its last session
 
 	as.stopOnce.Do(func() {
 		close(as.stopChan)
@@ -75,3 +77,36 @@ func (as *ActiveSession) Stop() {
 func (as *ActiveSession) StopChannel() <-chan struct{} {
 	return as.stopChan
 }
+
+// beginReconnect marks the session as reconnecting. It returns false if a
+// reconnect is already in progress.
+func (as *ActiveSession) beginReconnect() bool {
+	as.reconnectMu.Lock()
+	defer as.reconnectMu.Unlock()
+
+	if as.reconnecting {
+		return false
+	}
+	as.reconnecting = true
+	return true
+}
+
+func (as *ActiveSession) endReconnect() {
+	as.reconnectMu.Lock()
+	as.reconnecting = false
+	as.reconnectMu.Unlock()
+}
+
+func (as *ActiveSession) isReconnecting() bool {
+	as.reconnectMu.Lock()
+	defer as.reconnectMu.Unlock()
+	return as.reconnecting
+}
+
+// sendStatus queues event for the UI, dropping it if nobody is listening.
+func (as *ActiveSession) sendStatus(event models.SessionStatusEvent) {
+	select {
+	case as.StatusChan <- event:
+	default:
+	}
+}
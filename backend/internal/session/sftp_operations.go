@@ -1,15 +1,20 @@
 package session
 
 import (
+	"fmt"
 	"freessh-backend/internal/models"
 	"freessh-backend/internal/sftp"
+	"freessh-backend/internal/throttle"
 	"strings"
 	"sync"
+
+	"github.com/google/uuid"
 )
 
 var (
 	activeTransfers       = make(map[string]chan struct{})
 	activeRemoteTransfers = make(map[string]chan struct{})
+	pausedTransfers       = make(map[string]bool)
 	transfersMu           sync.Mutex
 )
 
@@ -113,13 +118,80 @@ func (m *Manager) RenameFile(sessionID, oldPath, newPath string) error {
 	return session.SFTPClient.Rename(oldPath, newPath)
 }
 
-func (m *Manager) BulkDownload(sessionID string, remotePaths []string, localBaseDir string, progress func(models.BulkProgress)) ([]models.BulkResult, error) {
+// beginLimit registers a transfer over sessionID with the bandwidth limiters
+// and returns its ID, generated when transferID is empty, and the limiters it
+// is subject to. The caller must end it with m.rates.End.
+func (m *Manager) beginLimit(sessionID, transferID string, rateLimit int64) (string, throttle.Chain, error) {
+	if transferID == "" {
+		transferID = uuid.New().String()
+	}
+
+	var connectionID string
+	if session, err := m.GetSession(sessionID); err == nil {
+		connectionID = session.Config.ID
+	}
+	limit, err := m.rates.Begin(transferID, rateLimit, connectionID)
+	if err != nil {
+		return "", nil, fmt.Errorf("transfer %s: %w", transferID, err)
+	}
+	return transferID, limit, nil
+}
+
+// gate returns the transfer slots taken by bulk and remote transfers the
+// user starts. Like direct transfers they only count against the overall
+// limit, not the per-connection one of the queue.
+func (m *Manager) gate() *throttle.Gate {
+	return m.slots.For()
+}
+
+// bulkCancel registers a cancel channel for the bulk transfer transferID, so
+// CancelTransfer stops the items that have not started yet. Call done when
+// the transfer is over.
+func bulkCancel(transferID string) (cancel chan struct{}, done func()) {
+	cancel = make(chan struct{})
+
+	transfersMu.Lock()
+	activeTransfers[transferID] = cancel
+	transfersMu.Unlock()
+
+	return cancel, func() {
+		transfersMu.Lock()
+		if activeTransfers[transferID] == cancel {
+			delete(activeTransfers, transferID)
+		}
+		delete(pausedTransfers, transferID)
+		transfersMu.Unlock()
+	}
+}
+
+// connectionIDs returns the saved connections of the open sessions among
+// sessionIDs.
+func (m *Manager) connectionIDs(sessionIDs ...string) []string {
+	var connectionIDs []string
+	for _, sessionID := range sessionIDs {
+		if session, err := m.GetSession(sessionID); err == nil {
+			connectionIDs = append(connectionIDs, session.Config.ID)
+		}
+	}
+	return connectionIDs
+}
+
+func (m *Manager) BulkDownload(sessionID string, remotePaths []string, localBaseDir string, transferID string, rateLimit int64, progress func(models.BulkProgress)) ([]models.BulkResult, error) {
 	client, err := m.ensureSFTP(sessionID)
 	if err != nil {
 		return nil, err
 	}
 
-	sftpResults, err := client.BulkDownload(remotePaths, localBaseDir, func(p sftp.BulkProgress) {
+	transferID, limit, err := m.beginLimit(sessionID, transferID, rateLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer m.rates.End(transferID)
+
+	cancel, done := bulkCancel(transferID)
+	defer done()
+
+	sftpResults, err := client.BulkDownload(remotePaths, localBaseDir, limit, m.gate(), cancel, func(p sftp.BulkProgress) {
 		if progress != nil {
 			progress(models.BulkProgress{
 				TotalItems:     p.TotalItems,
@@ -146,13 +218,22 @@ func (m *Manager) BulkDownload(sessionID string, remotePaths []string, localBase
 	return results, nil
 }
 
-func (m *Manager) BulkUpload(sessionID string, localPaths []string, remoteBaseDir string, progress func(models.BulkProgress)) ([]models.BulkResult, error) {
+func (m *Manager) BulkUpload(sessionID string, localPaths []string, remoteBaseDir string, transferID string, rateLimit int64, progress func(models.BulkProgress)) ([]models.BulkResult, error) {
 	client, err := m.ensureSFTP(sessionID)
 	if err != nil {
 		return nil, err
 	}
 
-	sftpResults, err := client.BulkUpload(localPaths, remoteBaseDir, func(p sftp.BulkProgress) {
+	transferID, limit, err := m.beginLimit(sessionID, transferID, rateLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer m.rates.End(transferID)
+
+	cancel, done := bulkCancel(transferID)
+	defer done()
+
+	sftpResults, err := client.BulkUpload(localPaths, remoteBaseDir, limit, m.gate(), cancel, func(p sftp.BulkProgress) {
 		if progress != nil {
 			progress(models.BulkProgress{
 				TotalItems:     p.TotalItems,
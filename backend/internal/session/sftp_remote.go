@@ -3,6 +3,7 @@ package session
 import (
 	"fmt"
 	"freessh-backend/internal/sftp/remote"
+	"freessh-backend/internal/throttle"
 )
 
 func (m *Manager) RemoteTransfer(
@@ -12,6 +13,7 @@ func (m *Manager) RemoteTransfer(
 	destPath string,
 	progress func(transferred, total int64),
 	transferID string,
+	rateLimit int64,
 ) error {
 	sourceClient, err := m.GetSFTPClient(sourceSessionID)
 	if err != nil {
@@ -23,6 +25,13 @@ func (m *Manager) RemoteTransfer(
 		return fmt.Errorf("failed to get destination SFTP client: %w", err)
 	}
 
+	// Registering the limiter first refuses an ID that is already running
+	limit, err := m.remoteLimit(sourceSessionID, destSessionID, transferID, rateLimit)
+	if err != nil {
+		return err
+	}
+	defer m.rates.End(transferID)
+
 	cancel := make(chan struct{})
 
 	transfersMu.Lock()
@@ -35,7 +44,13 @@ func (m *Manager) RemoteTransfer(
 		transfersMu.Unlock()
 	}()
 
-	return remote.Transfer(sourceClient.GetClient(), destClient.GetClient(), sourcePath, destPath, progress, cancel)
+	gate := m.gate()
+	if err := gate.Acquire(cancel); err != nil {
+		return fmt.Errorf("transfer cancelled")
+	}
+	defer gate.Release()
+
+	return remote.Transfer(sourceClient.GetClient(), destClient.GetClient(), sourcePath, destPath, limit, progress, cancel)
 }
 
 func (m *Manager) BulkRemoteTransfer(
@@ -45,6 +60,7 @@ func (m *Manager) BulkRemoteTransfer(
 	destDir string,
 	progress remote.ProgressCallback,
 	transferID string,
+	rateLimit int64,
 ) []remote.RemoteTransferResult {
 	sourceClient, err := m.GetSFTPClient(sourceSessionID)
 	if err != nil {
@@ -62,6 +78,16 @@ func (m *Manager) BulkRemoteTransfer(
 		}}
 	}
 
+	// Registering the limiter first refuses an ID that is already running
+	limit, err := m.remoteLimit(sourceSessionID, destSessionID, transferID, rateLimit)
+	if err != nil {
+		return []remote.RemoteTransferResult{{
+			Success: false,
+			Error:   err.Error(),
+		}}
+	}
+	defer m.rates.End(transferID)
+
 	cancel := make(chan struct{})
 
 	transfersMu.Lock()
@@ -74,7 +100,17 @@ func (m *Manager) BulkRemoteTransfer(
 		transfersMu.Unlock()
 	}()
 
-	return remote.BulkTransfer(sourceClient.GetClient(), destClient.GetClient(), sourcePaths, destDir, progress, cancel)
+	return remote.BulkTransfer(sourceClient.GetClient(), destClient.GetClient(), sourcePaths, destDir, limit, m.gate(), progress, cancel)
+}
+
+// remoteLimit registers a remote transfer with the bandwidth limiters. It
+// counts against the limits of both connections it uses.
+func (m *Manager) remoteLimit(sourceSessionID, destSessionID, transferID string, rateLimit int64) (throttle.Chain, error) {
+	limit, err := m.rates.Begin(transferID, rateLimit, m.connectionIDs(sourceSessionID, destSessionID)...)
+	if err != nil {
+		return nil, fmt.Errorf("transfer %s: %w", transferID, err)
+	}
+	return limit, nil
 }
 
 func (m *Manager) CancelRemoteTransfer(transferID string) bool {
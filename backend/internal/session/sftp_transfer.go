@@ -1,99 +1,414 @@
 package session
 
 import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
 	"freessh-backend/internal/models"
+	"freessh-backend/internal/sftp"
+	"freessh-backend/internal/throttle"
+	"os"
+	"time"
 
 	"github.com/google/uuid"
 )
 
-func (m *Manager) UploadFile(sessionID, localPath, remotePath string, progressChan chan<- models.TransferProgress) error {
-	session, err := m.GetSession(sessionID)
+// journalFlushInterval bounds how often a running transfer writes its
+// progress to the transfer journal.
+const journalFlushInterval = 2 * time.Second
+
+// transferOptions are the per-request settings of a single-file transfer.
+type transferOptions struct {
+	resume        bool
+	rateLimit     int64
+	verify        bool
+	verifyRetries int
+}
+
+// UploadFile copies req.LocalPath to req.RemotePath. With Resume set, a
+// partial remote file left by an earlier attempt is continued instead of
+// overwritten. A positive RateLimit caps the upload in bytes per second, on
+// top of the global and per-connection limits. With Verify set, the SHA-256
+// of both sides is compared afterwards.
+func (m *Manager) UploadFile(sessionID string, req models.UploadRequest, progressChan chan<- models.TransferProgress) error {
+	return m.runTransfer(sessionID, models.PartialTransfer{
+		Direction:  models.TransferUpload,
+		LocalPath:  req.LocalPath,
+		RemotePath: req.RemotePath,
+	}, transferOptions{
+		resume:        req.Resume,
+		rateLimit:     req.RateLimit,
+		verify:        req.Verify,
+		verifyRetries: req.VerifyRetries,
+	}, progressChan)
+}
+
+// DownloadFile copies req.RemotePath to req.LocalPath, resuming, limited and
+// verified like UploadFile.
+func (m *Manager) DownloadFile(sessionID string, req models.DownloadRequest, progressChan chan<- models.TransferProgress) error {
+	return m.runTransfer(sessionID, models.PartialTransfer{
+		Direction:  models.TransferDownload,
+		LocalPath:  req.LocalPath,
+		RemotePath: req.RemotePath,
+	}, transferOptions{
+		resume:        req.Resume,
+		rateLimit:     req.RateLimit,
+		verify:        req.Verify,
+		verifyRetries: req.VerifyRetries,
+	}, progressChan)
+}
+
+// ResumeTransfer continues the journaled transfer req.TransferID over
+// sessionID, which must be connected to the same host the transfer started
+// on.
+func (m *Manager) ResumeTransfer(sessionID string, req models.ResumeRequest, progressChan chan<- models.TransferProgress) (*models.PartialTransfer, error) {
+	entry, err := m.partialTransfer(sessionID, req.TransferID)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	if err := m.runTransfer(sessionID, *entry, transferOptions{
+		resume:        true,
+		rateLimit:     req.RateLimit,
+		verify:        req.Verify,
+		verifyRetries: req.VerifyRetries,
+	}, progressChan); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// ListPartialTransfers returns the journaled transfers for connectionID, or
+// all of them when it is empty.
+func (m *Manager) ListPartialTransfers(connectionID string) []models.PartialTransfer {
+	if m.journal == nil {
+		return []models.PartialTransfer{}
+	}
+	return m.journal.List(connectionID)
+}
+
+// DiscardPartialTransfer forgets a journaled transfer and, with deleteFile
+// set, removes its partial destination file.
+func (m *Manager) DiscardPartialTransfer(sessionID, transferID string, deleteFile bool) error {
+	if m.journal == nil {
+		return fmt.Errorf("transfer journal not available")
 	}
 
-	if !session.SFTPClient.IsConnected() {
-		if err := session.SFTPClient.Connect(); err != nil {
+	if transferActive(transferID) {
+		return fmt.Errorf("transfer is still running: %s", transferID)
+	}
+
+	if deleteFile {
+		entry, err := m.journal.Get(transferID)
+		if err != nil {
 			return err
 		}
+
+		if entry.Direction == models.TransferDownload {
+			if err := os.Remove(entry.LocalPath); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove partial file: %w", err)
+			}
+		} else {
+			if _, err := m.partialTransfer(sessionID, transferID); err != nil {
+				return err
+			}
+			client, err := m.ensureSFTP(sessionID)
+			if err != nil {
+				return err
+			}
+			if _, err := client.Stat(entry.RemotePath); err == nil {
+				if err := client.Remove(entry.RemotePath); err != nil {
+					return err
+				}
+			}
+		}
 	}
 
-	transferID := uuid.New().String()
-	cancel := make(chan struct{})
+	return m.journal.Delete(transferID)
+}
 
+func (m *Manager) CancelTransfer(transferID string, keepPartial bool) bool {
 	transfersMu.Lock()
-	activeTransfers[transferID] = cancel
-	transfersMu.Unlock()
+	defer transfersMu.Unlock()
 
-	defer func() {
-		transfersMu.Lock()
+	if cancel, ok := activeTransfers[transferID]; ok {
+		if keepPartial {
+			pausedTransfers[transferID] = true
+		}
+		close(cancel)
 		delete(activeTransfers, transferID)
-		transfersMu.Unlock()
-	}()
+		return true
+	}
+	return false
+}
 
-	return session.SFTPClient.Upload(localPath, remotePath, func(transferred, total int64) {
-		if progressChan != nil {
-			percentage := float64(transferred) / float64(total) * 100
-			progressChan <- models.TransferProgress{
-				TransferID:  transferID,
-				Filename:    localPath,
-				Total:       total,
-				Transferred: transferred,
-				Percentage:  percentage,
-				Status:      "uploading",
-			}
-		}
-	}, cancel)
+func transferActive(transferID string) bool {
+	transfersMu.Lock()
+	defer transfersMu.Unlock()
+	_, ok := activeTransfers[transferID]
+	return ok
 }
 
-func (m *Manager) DownloadFile(sessionID, remotePath, localPath string, progressChan chan<- models.TransferProgress) error {
+// partialTransfer loads a journal entry and checks that sessionID is connected
+// to the host it belongs to.
+func (m *Manager) partialTransfer(sessionID, transferID string) (*models.PartialTransfer, error) {
+	if m.journal == nil {
+		return nil, fmt.Errorf("transfer journal not available")
+	}
+
+	entry, err := m.journal.Get(transferID)
+	if err != nil {
+		return nil, err
+	}
+	if transferActive(transferID) {
+		return nil, fmt.Errorf("transfer is still running: %s", transferID)
+	}
+
+	session, err := m.GetSession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if entry.ConnectionID != "" && entry.ConnectionID != session.Config.ID {
+		return nil, fmt.Errorf("partial transfer %s belongs to another connection", transferID)
+	}
+
+	return entry, nil
+}
+
+// runTransfer performs one journaled single-file transfer. The journal entry
+// is removed when the transfer completes or is cancelled, and kept with its
+// progress when it is paused or fails, so it can be resumed later. A failed
+// checksum comparison counts as a failure once the retries are used up.
+func (m *Manager) runTransfer(sessionID string, entry models.PartialTransfer, options transferOptions, progressChan chan<- models.TransferProgress) error {
 	session, err := m.GetSession(sessionID)
 	if err != nil {
 		return err
 	}
 
-	if !session.SFTPClient.IsConnected() {
-		if err := session.SFTPClient.Connect(); err != nil {
+	client, err := m.ensureSFTP(sessionID)
+	if err != nil {
+		return err
+	}
+
+	if entry.ID == "" && m.journal != nil {
+		if existing := m.journal.Find(session.Config.ID, entry.Direction, entry.LocalPath, entry.RemotePath); existing != nil && !transferActive(existing.ID) {
+			entry = *existing
+		}
+	}
+	if entry.ID == "" {
+		entry.ID = uuid.New().String()
+	}
+	entry.ConnectionID = session.Config.ID
+
+	size, modTime, err := transferSource(client, entry)
+	if err != nil {
+		return err
+	}
+
+	var offset int64
+	// A journaled source that changed since the partial was written cannot
+	// be continued, whatever the partial file's tail looks like.
+	sourceChanged := entry.Size != 0 && (entry.Size != size || entry.SourceModTime != modTime)
+	if options.resume && !sourceChanged {
+		if entry.Direction == models.TransferUpload {
+			offset, err = client.UploadOffset(entry.LocalPath, entry.RemotePath)
+		} else {
+			offset, err = client.DownloadOffset(entry.RemotePath, entry.LocalPath)
+		}
+		if err != nil {
 			return err
 		}
 	}
 
-	transferID := uuid.New().String()
+	// Registering the limiter first refuses a second run of the same entry
+	limit, err := m.rates.Begin(entry.ID, options.rateLimit, entry.ConnectionID)
+	if err != nil {
+		return err
+	}
+	defer m.rates.End(entry.ID)
+
+	now := time.Now()
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = now
+	}
+	entry.Size = size
+	entry.SourceModTime = modTime
+	entry.Transferred = offset
+	entry.Status = models.PartialRunning
+	entry.Error = ""
+	entry.UpdatedAt = now
+	m.saveJournalEntry(entry)
+
 	cancel := make(chan struct{})
 
 	transfersMu.Lock()
-	activeTransfers[transferID] = cancel
+	activeTransfers[entry.ID] = cancel
 	transfersMu.Unlock()
 
 	defer func() {
 		transfersMu.Lock()
-		delete(activeTransfers, transferID)
+		delete(activeTransfers, entry.ID)
 		transfersMu.Unlock()
 	}()
 
-	return session.SFTPClient.Download(remotePath, localPath, func(transferred, total int64) {
+	status, filename := "uploading", entry.LocalPath
+	if entry.Direction == models.TransferDownload {
+		status, filename = "downloading", entry.RemotePath
+	}
+
+	lastFlush := now
+	meter := throttle.NewMeter()
+	progress := func(transferred, total int64) {
+		entry.Transferred = transferred
+		if m.journal != nil && time.Since(lastFlush) >= journalFlushInterval {
+			m.journal.UpdateProgress(entry.ID, transferred)
+			lastFlush = time.Now()
+		}
+
 		if progressChan != nil {
 			percentage := float64(transferred) / float64(total) * 100
 			progressChan <- models.TransferProgress{
-				TransferID:  transferID,
-				Filename:    remotePath,
+				TransferID:  entry.ID,
+				Filename:    filename,
 				Total:       total,
 				Transferred: transferred,
 				Percentage:  percentage,
-				Status:      "downloading",
+				Status:      status,
+				ResumedFrom: offset,
+				Rate:        meter.Update(transferred),
 			}
 		}
-	}, cancel)
-}
+	}
+
+	// Direct transfers count against the overall limit the queue runs under
+	if err = m.slots.Acquire(cancel); err != nil {
+		err = sftp.ErrTransferCancelled
+	} else {
+		defer m.slots.Release()
+
+		for attempt := 0; ; attempt++ {
+			opts := sftp.TransferOptions{Offset: offset, Limit: limit}
+			if options.verify {
+				opts.Hash = sha256.New()
+			}
+
+			if entry.Direction == models.TransferUpload {
+				err = client.Upload(entry.LocalPath, entry.RemotePath, opts, progress, cancel)
+			} else {
+				err = client.Download(entry.RemotePath, entry.LocalPath, opts, progress, cancel)
+			}
+
+			if err == nil && options.verify {
+				if progressChan != nil {
+					progressChan <- models.TransferProgress{
+						TransferID:  entry.ID,
+						Filename:    filename,
+						Total:       size,
+						Transferred: size,
+						Percentage:  100,
+						Status:      "verifying",
+					}
+				}
+				err = client.VerifyChecksum(entry.RemotePath, opts.Hash.Sum(nil), cancel)
+			}
+
+			if !errors.Is(err, sftp.ErrChecksumMismatch) || attempt >= options.verifyRetries {
+				break
+			}
+			// The copy is corrupt somewhere, so the retry starts over
+			offset = 0
+			meter = throttle.NewMeter()
+		}
+	}
+
+	// Verification is best effort, so a copy the server cannot verify is
+	// complete all the same
+	var unverified string
+	if errors.Is(err, sftp.ErrChecksumUnavailable) {
+		unverified, err = err.Error(), nil
+	}
 
-func (m *Manager) CancelTransfer(transferID string) bool {
 	transfersMu.Lock()
-	defer transfersMu.Unlock()
+	paused := pausedTransfers[entry.ID]
+	delete(pausedTransfers, entry.ID)
+	transfersMu.Unlock()
 
-	if cancel, ok := activeTransfers[transferID]; ok {
-		close(cancel)
-		delete(activeTransfers, transferID)
-		return true
+	m.finishTransfer(client, entry, err, paused)
+
+	if progressChan != nil && !errors.Is(err, sftp.ErrTransferCancelled) {
+		final := models.TransferProgress{
+			TransferID:  entry.ID,
+			Filename:    filename,
+			Total:       size,
+			Transferred: entry.Transferred,
+			Status:      "completed",
+			Verified:    err == nil && options.verify && unverified == "",
+			Unverified:  unverified,
+		}
+		if err != nil {
+			final.Status = "failed"
+			final.Error = err.Error()
+		}
+		if size > 0 {
+			final.Percentage = float64(final.Transferred) / float64(size) * 100
+		}
+		progressChan <- final
+	}
+	return err
+}
+
+func (m *Manager) finishTransfer(client *sftp.Client, entry models.PartialTransfer, err error, paused bool) {
+	if err == nil {
+		m.deleteJournalEntry(entry.ID)
+		return
+	}
+
+	if errors.Is(err, sftp.ErrTransferCancelled) && !paused {
+		if entry.Direction == models.TransferUpload {
+			client.Remove(entry.RemotePath)
+		} else {
+			os.Remove(entry.LocalPath)
+		}
+		m.deleteJournalEntry(entry.ID)
+		return
+	}
+
+	entry.Status = models.PartialInterrupted
+	entry.Error = err.Error()
+	if paused {
+		entry.Status = models.PartialPaused
+		entry.Error = ""
+	}
+	entry.UpdatedAt = time.Now()
+	m.saveJournalEntry(entry)
+}
+
+// transferSource returns the size and modification time of the file a
+// transfer reads from.
+func transferSource(client *sftp.Client, entry models.PartialTransfer) (int64, int64, error) {
+	if entry.Direction == models.TransferUpload {
+		info, err := os.Stat(entry.LocalPath)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to stat local file: %w", err)
+		}
+		return info.Size(), info.ModTime().Unix(), nil
+	}
+
+	info, err := client.Stat(entry.RemotePath)
+	if err != nil {
+		return 0, 0, err
+	}
+	return info.Size, info.ModTime, nil
+}
+
+func (m *Manager) saveJournalEntry(entry models.PartialTransfer) {
+	if m.journal != nil {
+		m.journal.Save(entry)
+	}
+}
+
+func (m *Manager) deleteJournalEntry(id string) {
+	if m.journal != nil {
+		m.journal.Delete(id)
 	}
-	return false
 }
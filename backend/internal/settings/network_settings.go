@@ -0,0 +1,96 @@
+package settings
+
+import (
+	"freessh-backend/internal/models"
+	"freessh-backend/internal/storage"
+	"sync"
+)
+
+// NetworkSettings holds defaults applied to every SSH connection unless the
+// connection overrides them.
+type NetworkSettings struct {
+	Proxy   *models.ProxyConfig   `json:"proxy,omitempty"`
+	Network models.NetworkOptions `json:"network"`
+	Crypto  *models.CryptoPolicy  `json:"crypto,omitempty"`
+}
+
+type NetworkSettingsStorage struct {
+	manager  *storage.Manager
+	settings NetworkSettings
+	mu       sync.RWMutex
+}
+
+func NewNetworkSettingsStorage() (*NetworkSettingsStorage, error) {
+	manager, err := storage.NewManager("network_settings.json")
+	if err != nil {
+		return nil, err
+	}
+
+	storage := &NetworkSettingsStorage{
+		manager: manager,
+	}
+
+	if err := storage.load(); err != nil {
+		return nil, err
+	}
+
+	return storage, nil
+}
+
+func (s *NetworkSettingsStorage) load() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.manager.Load(&s.settings); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *NetworkSettingsStorage) Get() NetworkSettings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.settings
+}
+
+func (s *NetworkSettingsStorage) Update(settings NetworkSettings) error {
+	s.mu.Lock()
+	s.settings = settings
+	s.mu.Unlock()
+
+	return s.manager.Save(settings)
+}
+
+// GetProxy returns a copy of the global proxy, or nil if none is set.
+func (s *NetworkSettingsStorage) GetProxy() *models.ProxyConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.settings.Proxy == nil {
+		return nil
+	}
+	proxy := *s.settings.Proxy
+	return &proxy
+}
+
+// GetNetworkOptions returns the global timeout, keepalive and reconnect
+// settings.
+func (s *NetworkSettingsStorage) GetNetworkOptions() models.NetworkOptions {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.settings.Network
+}
+
+// GetCryptoPolicy returns a copy of the global crypto policy, or nil if none
+// is set.
+func (s *NetworkSettingsStorage) GetCryptoPolicy() *models.CryptoPolicy {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.settings.Crypto == nil {
+		return nil
+	}
+	policy := *s.settings.Crypto
+	return &policy
+}
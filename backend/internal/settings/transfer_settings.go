@@ -0,0 +1,83 @@
+package settings
+
+import (
+	"freessh-backend/internal/config"
+	"freessh-backend/internal/storage"
+	"sync"
+)
+
+// TransferSettings holds the transfer limits. MaxConcurrent applies to
+// queued and direct transfers alike, while MaxPerHost and MaxAttempts apply
+// to the queue. Zero values mean the defaults from the config package. RateLimit caps all transfers
+// together and ConnectionRateLimits the transfers of single connections by
+// ID, both in bytes per second with zero meaning unlimited.
+type TransferSettings struct {
+	MaxConcurrent        int              `json:"max_concurrent"`
+	MaxPerHost           int              `json:"max_per_host"`
+	MaxAttempts          int              `json:"max_attempts"`
+	RateLimit            int64            `json:"rate_limit"`
+	ConnectionRateLimits map[string]int64 `json:"connection_rate_limits,omitempty"`
+}
+
+type TransferSettingsStorage struct {
+	manager  *storage.Manager
+	settings TransferSettings
+	mu       sync.RWMutex
+}
+
+func NewTransferSettingsStorage() (*TransferSettingsStorage, error) {
+	manager, err := storage.NewManager("transfer_settings.json")
+	if err != nil {
+		return nil, err
+	}
+
+	storage := &TransferSettingsStorage{
+		manager: manager,
+	}
+
+	if err := storage.load(); err != nil {
+		return nil, err
+	}
+
+	return storage, nil
+}
+
+func (s *TransferSettingsStorage) load() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.manager.Load(&s.settings); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *TransferSettingsStorage) Get() TransferSettings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.settings
+}
+
+func (s *TransferSettingsStorage) Update(settings TransferSettings) error {
+	s.mu.Lock()
+	s.settings = settings
+	s.mu.Unlock()
+
+	return s.manager.Save(settings)
+}
+
+// Limits returns the settings with defaults filled in for unset values.
+func (s *TransferSettingsStorage) Limits() TransferSettings {
+	limits := s.Get()
+	if limits.MaxConcurrent <= 0 {
+		limits.MaxConcurrent = config.DefaultTransferConcurrency
+	}
+	if limits.MaxPerHost <= 0 {
+		limits.MaxPerHost = config.DefaultTransferPerHost
+	}
+	if limits.MaxAttempts <= 0 {
+		limits.MaxAttempts = config.DefaultTransferAttempts
+	}
+	return limits
+}
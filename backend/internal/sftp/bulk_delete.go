@@ -31,7 +31,7 @@ func (c *Client) BulkDelete(remotePaths []string, progress BulkProgressCallback)
 		}
 	}
 
-	sem := make(chan struct{}, maxConcurrentTransfers)
+	sem := make(chan struct{}, maxConcurrentDeletes)
 	var wg sync.WaitGroup
 
 	for _, remotePath := range remotePaths {
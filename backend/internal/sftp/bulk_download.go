@@ -2,6 +2,7 @@ package sftp
 
 import (
 	"fmt"
+	"freessh-backend/internal/throttle"
 	"io"
 	"os"
 	"path/filepath"
@@ -9,7 +10,7 @@ import (
 )
 
 // BulkDownload downloads multiple files/directories from remote to local
-func (c *Client) BulkDownload(remotePaths []string, localBaseDir string, progress BulkProgressCallback) ([]BulkResult, error) {
+func (c *Client) BulkDownload(remotePaths []string, localBaseDir string, limit throttle.Chain, gate *throttle.Gate, cancel <-chan struct{}, progress BulkProgressCallback) ([]BulkResult, error) {
 	if !c.IsConnected() {
 		return nil, fmt.Errorf("SFTP not connected")
 	}
@@ -34,19 +35,28 @@ func (c *Client) BulkDownload(remotePaths []string, localBaseDir string, progres
 	}
 
 	// Semaphore for concurrency control
-	sem := make(chan struct{}, maxConcurrentTransfers)
 	var wg sync.WaitGroup
 
 	for _, remotePath := range remotePaths {
 		wg.Add(1)
 		go func(rPath string) {
 			defer wg.Done()
-			sem <- struct{}{}
-			defer func() { <-sem }()
+			// Each item takes a transfer slot, shared with the queue
+			if err := gate.Acquire(cancel); err != nil {
+				resultsMu.Lock()
+				results = append(results, BulkResult{Path: rPath, Success: false, Error: ErrTransferCancelled.Error()})
+				resultsMu.Unlock()
+				progressMu.Lock()
+				failed++
+				progressMu.Unlock()
+				updateProgress(rPath)
+				return
+			}
+			defer gate.Release()
 
 			updateProgress(rPath)
 
-			err := c.downloadRecursive(rPath, localBaseDir)
+			err := c.downloadRecursive(rPath, localBaseDir, limit)
 			
 			resultsMu.Lock()
 			if err != nil {
@@ -70,7 +80,7 @@ func (c *Client) BulkDownload(remotePaths []string, localBaseDir string, progres
 	return results, nil
 }
 
-func (c *Client) downloadRecursive(remotePath, localBaseDir string) error {
+func (c *Client) downloadRecursive(remotePath, localBaseDir string, limit throttle.Chain) error {
 	stat, err := c.sftpClient.Stat(remotePath)
 	if err != nil {
 		return fmt.Errorf("failed to stat %s: %w", remotePath, err)
@@ -79,7 +89,7 @@ func (c *Client) downloadRecursive(remotePath, localBaseDir string) error {
 	localPath := filepath.Join(localBaseDir, filepath.Base(remotePath))
 
 	if !stat.IsDir() {
-		return c.downloadFile(remotePath, localPath)
+		return c.downloadFile(remotePath, localPath, limit)
 	}
 
 	// Create local directory
@@ -104,11 +114,11 @@ func (c *Client) downloadRecursive(remotePath, localBaseDir string) error {
 		localEntryPath := filepath.Join(localPath, entry.Name())
 
 		if entry.IsDir() {
-			if err := c.downloadRecursive(remoteEntryPath, filepath.Dir(localEntryPath)); err != nil {
+			if err := c.downloadRecursive(remoteEntryPath, filepath.Dir(localEntryPath), limit); err != nil {
 				return err
 			}
 		} else {
-			if err := c.downloadFile(remoteEntryPath, localEntryPath); err != nil {
+			if err := c.downloadFile(remoteEntryPath, localEntryPath, limit); err != nil {
 				return err
 			}
 		}
@@ -117,7 +127,7 @@ func (c *Client) downloadRecursive(remotePath, localBaseDir string) error {
 	return nil
 }
 
-func (c *Client) downloadFile(remotePath, localPath string) error {
+func (c *Client) downloadFile(remotePath, localPath string, limit throttle.Chain) error {
 	remoteFile, err := c.sftpClient.Open(remotePath)
 	if err != nil {
 		return fmt.Errorf("failed to open remote file: %w", err)
@@ -135,7 +145,7 @@ func (c *Client) downloadFile(remotePath, localPath string) error {
 	defer localFile.Close()
 
 	buf := make([]byte, bufferSize)
-	_, err = io.CopyBuffer(localFile, remoteFile, buf)
+	_, err = io.CopyBuffer(throttle.NewWriter(localFile, limit, nil), remoteFile, buf)
 	if err != nil {
 		os.Remove(localPath)
 		return fmt.Errorf("failed to copy file: %w", err)
@@ -1,8 +1,8 @@
 package sftp
 
 const (
-	maxConcurrentTransfers = 5
-	bufferSize             = 128 * 1024
+	maxConcurrentDeletes = 5
+	bufferSize           = 128 * 1024
 )
 
 type BulkResult struct {
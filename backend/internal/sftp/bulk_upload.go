@@ -2,6 +2,7 @@ package sftp
 
 import (
 	"fmt"
+	"freessh-backend/internal/throttle"
 	"io"
 	"os"
 	"path/filepath"
@@ -9,7 +10,7 @@ import (
 )
 
 // BulkUpload uploads multiple files/directories from local to remote
-func (c *Client) BulkUpload(localPaths []string, remoteBaseDir string, progress BulkProgressCallback) ([]BulkResult, error) {
+func (c *Client) BulkUpload(localPaths []string, remoteBaseDir string, limit throttle.Chain, gate *throttle.Gate, cancel <-chan struct{}, progress BulkProgressCallback) ([]BulkResult, error) {
 	if !c.IsConnected() {
 		return nil, fmt.Errorf("SFTP not connected")
 	}
@@ -33,19 +34,28 @@ func (c *Client) BulkUpload(localPaths []string, remoteBaseDir string, progress
 		}
 	}
 
-	sem := make(chan struct{}, maxConcurrentTransfers)
 	var wg sync.WaitGroup
 
 	for _, localPath := range localPaths {
 		wg.Add(1)
 		go func(lPath string) {
 			defer wg.Done()
-			sem <- struct{}{}
-			defer func() { <-sem }()
+			// Each item takes a transfer slot, shared with the queue
+			if err := gate.Acquire(cancel); err != nil {
+				resultsMu.Lock()
+				results = append(results, BulkResult{Path: lPath, Success: false, Error: ErrTransferCancelled.Error()})
+				resultsMu.Unlock()
+				progressMu.Lock()
+				failed++
+				progressMu.Unlock()
+				updateProgress(lPath)
+				return
+			}
+			defer gate.Release()
 
 			updateProgress(lPath)
 
-			err := c.uploadRecursive(lPath, remoteBaseDir)
+			err := c.uploadRecursive(lPath, remoteBaseDir, limit)
 			
 			resultsMu.Lock()
 			if err != nil {
@@ -69,7 +79,7 @@ func (c *Client) BulkUpload(localPaths []string, remoteBaseDir string, progress
 	return results, nil
 }
 
-func (c *Client) uploadRecursive(localPath, remoteBaseDir string) error {
+func (c *Client) uploadRecursive(localPath, remoteBaseDir string, limit throttle.Chain) error {
 	stat, err := os.Stat(localPath)
 	if err != nil {
 		return fmt.Errorf("failed to stat %s: %w", localPath, err)
@@ -78,7 +88,7 @@ func (c *Client) uploadRecursive(localPath, remoteBaseDir string) error {
 	remotePath := filepath.Join(remoteBaseDir, filepath.Base(localPath))
 
 	if !stat.IsDir() {
-		return c.uploadFile(localPath, remotePath)
+		return c.uploadFile(localPath, remotePath, limit)
 	}
 
 	// Create remote directory
@@ -103,11 +113,11 @@ func (c *Client) uploadRecursive(localPath, remoteBaseDir string) error {
 		remoteEntryPath := filepath.Join(remotePath, entry.Name())
 
 		if entry.IsDir() {
-			if err := c.uploadRecursive(localEntryPath, filepath.Dir(remoteEntryPath)); err != nil {
+			if err := c.uploadRecursive(localEntryPath, filepath.Dir(remoteEntryPath), limit); err != nil {
 				return err
 			}
 		} else {
-			if err := c.uploadFile(localEntryPath, remoteEntryPath); err != nil {
+			if err := c.uploadFile(localEntryPath, remoteEntryPath, limit); err != nil {
 				return err
 			}
 		}
@@ -116,7 +126,7 @@ func (c *Client) uploadRecursive(localPath, remoteBaseDir string) error {
 	return nil
 }
 
-func (c *Client) uploadFile(localPath, remotePath string) error {
+func (c *Client) uploadFile(localPath, remotePath string, limit throttle.Chain) error {
 	localFile, err := os.Open(localPath)
 	if err != nil {
 		return fmt.Errorf("failed to open local file: %w", err)
@@ -134,7 +144,7 @@ func (c *Client) uploadFile(localPath, remotePath string) error {
 	defer remoteFile.Close()
 
 	buf := make([]byte, bufferSize)
-	_, err = io.CopyBuffer(remoteFile, localFile, buf)
+	_, err = io.CopyBuffer(remoteFile, throttle.NewReader(localFile, limit, nil), buf)
 	if err != nil {
 		return fmt.Errorf("failed to copy file: %w", err)
 	}
@@ -0,0 +1,315 @@
+package sftp
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+var (
+	ErrChecksumMismatch    = errors.New("checksum mismatch")
+	ErrChecksumUnavailable = errors.New("server cannot compute checksums")
+)
+
+const (
+	// checkFileExtension is advertised by servers that implement the
+	// check-file requests of the SFTP extensions draft.
+	checkFileExtension = "check-file"
+	checkFileRequest   = "check-file-name"
+	checksumAlgorithm  = "sha256"
+
+	// checksumTimeout bounds how long the server may take to hash a file.
+	checksumTimeout = 30 * time.Minute
+
+	sshFxpInit          = 1
+	sshFxpVersion       = 2
+	sshFxpStatus        = 101
+	sshFxpExtended      = 200
+	sshFxpExtendedReply = 201
+
+	maxPacketLength = 256 * 1024
+)
+
+// VerifyChecksum compares the SHA-256 of remotePath, computed by the server,
+// with sum. It returns an error wrapping ErrChecksumMismatch when they
+// differ, ErrTransferCancelled when cancel closes first, and
+// ErrChecksumUnavailable when the server has no way to compute checksums.
+// Any other error means the attempt to get the checksum failed.
+func (c *Client) VerifyChecksum(remotePath string, sum []byte, cancel <-chan struct{}) error {
+	remoteSum, err := c.RemoteChecksum(remotePath, cancel)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(remoteSum, sum) {
+		return fmt.Errorf("%w: local %x, remote %x", ErrChecksumMismatch, sum, remoteSum)
+	}
+	return nil
+}
+
+// RemoteChecksum returns the SHA-256 of remotePath computed by the server.
+// The check-file extension is used when the server offers it; otherwise
+// sha256sum or shasum is run over an exec channel. ErrChecksumUnavailable is
+// returned only when the server has neither; a dropped channel or a timeout
+// is an ordinary error. Closing cancel stops the server's work.
+func (c *Client) RemoteChecksum(remotePath string, cancel <-chan struct{}) ([]byte, error) {
+	if !c.IsConnected() {
+		return nil, fmt.Errorf("SFTP not connected")
+	}
+	if c.sshClient == nil {
+		return nil, ErrChecksumUnavailable
+	}
+
+	path, err := c.normalizeRemotePath(remotePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if algorithms, ok := c.sftpClient.HasExtension(checkFileExtension); ok && offersAlgorithm(algorithms, checksumAlgorithm) {
+		// Servers may advertise the extension but refuse a given file, so
+		// fall back to exec rather than fail.
+		sum, err := c.checkFile(path, cancel)
+		if err == nil || errors.Is(err, ErrTransferCancelled) {
+			return sum, err
+		}
+	}
+	return c.execChecksum(path, cancel)
+}
+
+// offersAlgorithm reports whether the comma-separated list advertised with
+// check-file contains algorithm. Servers that advertise no list are tried.
+func offersAlgorithm(list, algorithm string) bool {
+	if strings.TrimSpace(list) == "" {
+		return true
+	}
+	for _, name := range strings.Split(list, ",") {
+		if strings.TrimSpace(name) == algorithm {
+			return true
+		}
+	}
+	return false
+}
+
+// checkFile sends a check-file-name request on an SFTP channel of its own,
+// as the SFTP library cannot send extended requests it does not know.
+func (c *Client) checkFile(path string, cancel <-chan struct{}) ([]byte, error) {
+	session, err := c.sshClient.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+	defer session.Close()
+
+	// Closing the channel unblocks the request below
+	done := make(chan struct{})
+	defer close(done)
+	cancelled := make(chan struct{})
+	go func() {
+		select {
+		case <-cancel:
+			close(cancelled)
+			session.Close()
+		case <-done:
+		}
+	}()
+
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := session.RequestSubsystem("sftp"); err != nil {
+		return nil, fmt.Errorf("failed to start SFTP subsystem: %w", err)
+	}
+
+	sum, err := requestCheckFile(stdout, stdin, path)
+	select {
+	case <-cancelled:
+		return nil, ErrTransferCancelled
+	default:
+		return sum, err
+	}
+}
+
+// requestCheckFile performs the SFTP handshake on r and w and asks for the
+// SHA-256 of the whole of path.
+func requestCheckFile(r io.Reader, w io.Writer, path string) ([]byte, error) {
+	if err := writePacket(w, sshFxpInit, binary.BigEndian.AppendUint32(nil, 3)); err != nil {
+		return nil, err
+	}
+	packetType, _, err := readPacket(r)
+	if err != nil {
+		return nil, err
+	}
+	if packetType != sshFxpVersion {
+		return nil, fmt.Errorf("unexpected SFTP packet type %d", packetType)
+	}
+
+	const requestID = 1
+	payload := binary.BigEndian.AppendUint32(nil, requestID)
+	payload = appendString(payload, checkFileRequest)
+	payload = appendString(payload, path)
+	payload = appendString(payload, checksumAlgorithm)
+	payload = binary.BigEndian.AppendUint64(payload, 0) // start offset
+	payload = binary.BigEndian.AppendUint64(payload, 0) // length, zero for all
+	payload = binary.BigEndian.AppendUint32(payload, 0) // block size, zero for one hash
+	if err := writePacket(w, sshFxpExtended, payload); err != nil {
+		return nil, err
+	}
+
+	packetType, data, err := readPacket(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 4 || binary.BigEndian.Uint32(data) != requestID {
+		return nil, fmt.Errorf("unexpected check-file reply")
+	}
+	data = data[4:]
+
+	switch packetType {
+	case sshFxpExtendedReply:
+		// string "check-file", string algorithm, then the raw hash
+		if _, data, err = readString(data); err != nil {
+			return nil, err
+		}
+		algorithm, sum, err := readString(data)
+		if err != nil {
+			return nil, err
+		}
+		if algorithm != checksumAlgorithm || len(sum) != 32 {
+			return nil, fmt.Errorf("unexpected check-file hash %q", algorithm)
+		}
+		return sum, nil
+	case sshFxpStatus:
+		if len(data) < 4 {
+			return nil, fmt.Errorf("check-file failed")
+		}
+		message, _, _ := readString(data[4:])
+		return nil, fmt.Errorf("check-file failed with status %d: %s", binary.BigEndian.Uint32(data), message)
+	default:
+		return nil, fmt.Errorf("unexpected SFTP packet type %d", packetType)
+	}
+}
+
+func writePacket(w io.Writer, packetType byte, payload []byte) error {
+	packet := binary.BigEndian.AppendUint32(nil, uint32(len(payload)+1))
+	packet = append(packet, packetType)
+	_, err := w.Write(append(packet, payload...))
+	return err
+}
+
+func readPacket(r io.Reader) (byte, []byte, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, nil, err
+	}
+	length := binary.BigEndian.Uint32(header[:])
+	if length == 0 || length > maxPacketLength {
+		return 0, nil, fmt.Errorf("invalid SFTP packet length %d", length)
+	}
+	packet := make([]byte, length)
+	if _, err := io.ReadFull(r, packet); err != nil {
+		return 0, nil, err
+	}
+	return packet[0], packet[1:], nil
+}
+
+func appendString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint32(b, uint32(len(s)))
+	return append(b, s...)
+}
+
+func readString(b []byte) (string, []byte, error) {
+	if len(b) < 4 {
+		return "", nil, fmt.Errorf("short SFTP packet")
+	}
+	length := binary.BigEndian.Uint32(b)
+	if uint32(len(b)-4) < length {
+		return "", nil, fmt.Errorf("short SFTP packet")
+	}
+	return string(b[4 : 4+length]), b[4+length:], nil
+}
+
+// execChecksum hashes path with sha256sum, or shasum where that is missing
+// as on macOS and the BSDs. The file is read from stdin so the output never
+// carries an escaped file name.
+func (c *Client) execChecksum(path string, cancel <-chan struct{}) ([]byte, error) {
+	ctx, stop := context.WithTimeout(context.Background(), checksumTimeout)
+	defer stop()
+	go func() {
+		select {
+		case <-cancel:
+			stop()
+		case <-ctx.Done():
+		}
+	}()
+
+	command := fmt.Sprintf("{ sha256sum 2>/dev/null || shasum -a 256; } < %s", shellQuote(path))
+
+	var stdout, stderr bytes.Buffer
+	status, err := c.sshClient.Exec(ctx, command, nil, &stdout, &stderr)
+	if errors.Is(err, context.Canceled) {
+		return nil, ErrTransferCancelled
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to run checksum command: %w", err)
+	}
+	if status.Code == 127 || strings.Contains(stderr.String(), "not found") {
+		return nil, ErrChecksumUnavailable
+	}
+	if status.Code != 0 {
+		return nil, fmt.Errorf("checksum command failed: %s", strings.TrimSpace(stderr.String()))
+	}
+
+	fields := strings.Fields(stdout.String())
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("checksum command printed nothing")
+	}
+	sum, err := hex.DecodeString(fields[0])
+	if err != nil || len(sum) != 32 {
+		return nil, fmt.Errorf("unexpected checksum output: %q", fields[0])
+	}
+	return sum, nil
+}
+
+// hashLocal feeds the bytes from..to of a local file into h. It returns
+// ErrTransferCancelled when cancel closes first.
+func hashLocal(h hash.Hash, path string, from, to int64, cancel <-chan struct{}) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open local file: %w", err)
+	}
+	defer file.Close()
+
+	section := io.NewSectionReader(file, from, to-from)
+	buf := make([]byte, bufferSize)
+	for {
+		select {
+		case <-cancel:
+			return ErrTransferCancelled
+		default:
+		}
+
+		n, err := section.Read(buf)
+		h.Write(buf[:n])
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to hash local file: %w", err)
+		}
+	}
+}
+
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
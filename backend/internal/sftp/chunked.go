@@ -0,0 +1,166 @@
+package sftp
+
+import (
+	"freessh-backend/internal/throttle"
+	"io"
+	"sync"
+)
+
+const (
+	// LargeFileThreshold is the remaining size from which Upload and
+	// Download split a file into ranges copied in parallel.
+	LargeFileThreshold = 64 * 1024 * 1024
+
+	// DefaultChunkWorkers is how many ranges of a large file are copied at
+	// once.
+	DefaultChunkWorkers = 8
+
+	// chunkRangeSize is the size of the range a worker takes at a time, and
+	// chunkBlockSize the size of each read and write within it.
+	chunkRangeSize = 8 * 1024 * 1024
+	chunkBlockSize = 1024 * 1024
+)
+
+// SetChunkWorkers sets how many ranges of a large file are copied at once.
+// A value of one or less turns the large-file mode off.
+func (c *Client) SetChunkWorkers(workers int) {
+	c.chunkWorkers = workers
+}
+
+func (c *Client) useChunks(remaining int64) bool {
+	return c.chunkWorkers > 1 && remaining >= LargeFileThreshold
+}
+
+// chunkedCopy copies src to dst from offset to size with workers goroutines,
+// each taking the next unclaimed range. Progress is reported as one stream
+// of the bytes done so far, including offset. All workers share limit.
+//
+// Ranges finish out of order, so on error or cancel the destination may
+// have gaps. The returned length is where the contiguous prefix that is
+// known to be complete ends; callers truncate the destination to it so a
+// later resume only ever continues after intact data.
+func chunkedCopy(dst io.WriterAt, src io.ReaderAt, offset, size int64, workers int, limit throttle.Chain, progress ProgressCallback, cancel <-chan struct{}) (int64, error) {
+	ranges := int((size - offset + chunkRangeSize - 1) / chunkRangeSize)
+	if workers > ranges {
+		workers = ranges
+	}
+	done := make([]int64, ranges)
+
+	var (
+		mu           sync.Mutex
+		next         int
+		firstErr     error
+		transferred  = offset
+		lastReported = offset
+		stop         = make(chan struct{})
+		stopOnce     sync.Once
+		wg           sync.WaitGroup
+	)
+	const reportInterval = 512 * 1024
+
+	fail := func(err error) {
+		stopOnce.Do(func() {
+			mu.Lock()
+			firstErr = err
+			mu.Unlock()
+			close(stop)
+		})
+	}
+
+	// claim hands out ranges in file order, so the contiguous prefix grows
+	// steadily even though ranges finish out of order.
+	claim := func() (int, bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		if next >= ranges {
+			return 0, false
+		}
+		index := next
+		next++
+		return index, true
+	}
+
+	copyRange := func(index int, buf []byte) error {
+		start := offset + int64(index)*chunkRangeSize
+		end := min(start+chunkRangeSize, size)
+
+		for pos := start; pos < end; {
+			select {
+			case <-cancel:
+				return ErrTransferCancelled
+			case <-stop:
+				return nil
+			default:
+			}
+
+			block := buf[:min(int64(len(buf)), end-pos)]
+			n, err := src.ReadAt(block, pos)
+			if n > 0 {
+				if limit.Wait(n, cancel) != nil {
+					return ErrTransferCancelled
+				}
+				if _, werr := dst.WriteAt(block[:n], pos); werr != nil {
+					return werr
+				}
+			}
+			if err != nil && (err != io.EOF || pos+int64(n) < end) {
+				if err == io.EOF {
+					err = io.ErrUnexpectedEOF
+				}
+				return err
+			}
+			pos += int64(n)
+
+			mu.Lock()
+			done[index] += int64(n)
+			transferred += int64(n)
+			if progress != nil && (transferred-lastReported >= reportInterval || transferred == size) {
+				progress(transferred, size)
+				lastReported = transferred
+			}
+			mu.Unlock()
+		}
+		return nil
+	}
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			buf := make([]byte, chunkBlockSize)
+			for {
+				index, ok := claim()
+				if !ok {
+					return
+				}
+				if err := copyRange(index, buf); err != nil {
+					fail(err)
+					return
+				}
+				select {
+				case <-stop:
+					return
+				default:
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if progress != nil && transferred > lastReported {
+		progress(transferred, size)
+	}
+
+	if firstErr == nil {
+		return size, nil
+	}
+
+	complete := offset
+	for index, n := range done {
+		complete += n
+		if n < min(chunkRangeSize, size-offset-int64(index)*chunkRangeSize) {
+			break
+		}
+	}
+	return complete, firstErr
+}
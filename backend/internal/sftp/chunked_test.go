@@ -0,0 +1,119 @@
+package sftp
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// memFile is an in-memory io.WriterAt safe for concurrent writers.
+type memFile struct {
+	data []byte
+	mu   sync.Mutex
+}
+
+func (f *memFile) WriteAt(p []byte, off int64) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if end := off + int64(len(p)); end > int64(len(f.data)) {
+		f.data = append(f.data, make([]byte, end-int64(len(f.data)))...)
+	}
+	copy(f.data[off:], p)
+	return len(p), nil
+}
+
+func TestChunkedCopy(t *testing.T) {
+	tests := []struct {
+		name    string
+		size    int64
+		offset  int64
+		workers int
+	}{
+		{"one range", chunkRangeSize / 2, 0, 4},
+		{"exact ranges", 2 * chunkRangeSize, 0, 4},
+		{"partial last range", 2*chunkRangeSize + 12345, 0, 4},
+		{"more workers than ranges", chunkRangeSize + 1, 0, 8},
+		{"one worker", 2*chunkRangeSize + 1, 0, 1},
+		{"from offset", 2*chunkRangeSize + 12345, chunkRangeSize/2 + 3, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := patterned(int(tt.size))
+			dst := &memFile{data: bytes.Clone(src[:tt.offset])}
+
+			var last, calls int64
+			progress := func(transferred, total int64) {
+				if transferred < last {
+					t.Errorf("progress went back from %d to %d", last, transferred)
+				}
+				if total != tt.size {
+					t.Errorf("progress total = %d, want %d", total, tt.size)
+				}
+				last = transferred
+				calls++
+			}
+
+			n, err := chunkedCopy(dst, bytes.NewReader(src), tt.offset, tt.size, tt.workers, nil, progress, nil)
+			if err != nil {
+				t.Fatalf("chunkedCopy error: %v", err)
+			}
+			if n != tt.size {
+				t.Errorf("chunkedCopy = %d, want %d", n, tt.size)
+			}
+			if !bytes.Equal(dst.data, src) {
+				t.Error("destination differs from source")
+			}
+			if calls == 0 || last != tt.size {
+				t.Errorf("last progress = %d after %d calls, want %d", last, calls, tt.size)
+			}
+		})
+	}
+}
+
+func TestChunkedCopyErrorKeepsIntactPrefix(t *testing.T) {
+	const size = 4 * chunkRangeSize
+	src := patterned(size)
+
+	tests := []struct {
+		name   string
+		failAt int64
+	}{
+		{"first range", chunkRangeSize / 2},
+		{"third range", 2*chunkRangeSize + chunkBlockSize},
+		{"last block", size - 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := &memFile{}
+			reader := failingReaderAt{ReaderAt: bytes.NewReader(src), failAt: tt.failAt}
+
+			n, err := chunkedCopy(dst, reader, 0, size, 4, nil, nil, nil)
+			if !errors.Is(err, errInjected) {
+				t.Fatalf("chunkedCopy error = %v, want the read error", err)
+			}
+			if n > tt.failAt {
+				t.Errorf("complete prefix %d reaches past the failed read at %d", n, tt.failAt)
+			}
+			if int64(len(dst.data)) < n || !bytes.Equal(dst.data[:n], src[:n]) {
+				t.Errorf("the first %d bytes of the destination are not intact", n)
+			}
+		})
+	}
+}
+
+func TestChunkedCopyCancel(t *testing.T) {
+	src := patterned(2 * chunkRangeSize)
+	cancel := make(chan struct{})
+	close(cancel)
+
+	n, err := chunkedCopy(&memFile{}, bytes.NewReader(src), 0, int64(len(src)), 4, nil, nil, cancel)
+	if !errors.Is(err, ErrTransferCancelled) {
+		t.Fatalf("chunkedCopy error = %v, want ErrTransferCancelled", err)
+	}
+	if n != 0 {
+		t.Errorf("chunkedCopy = %d after cancel before any copy, want 0", n)
+	}
+}
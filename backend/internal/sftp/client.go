@@ -3,27 +3,59 @@ package sftp
 import (
 	"fmt"
 	"freessh-backend/internal/ssh"
+	"io"
 
 	"github.com/pkg/sftp"
 )
 
+// maxConcurrentRequests caps the SFTP requests in flight for a single
+// ReadAt or WriteAt call. Large-file mode runs several such calls at once.
+const maxConcurrentRequests = 64
+
 type Client struct {
-	sshClient  *ssh.Client
-	sftpClient *sftp.Client
+	sshClient    *ssh.Client
+	sftpClient   *sftp.Client
+	chunkWorkers int
 }
 
 func NewClient(sshClient *ssh.Client) *Client {
 	return &Client{
-		sshClient: sshClient,
+		sshClient:    sshClient,
+		chunkWorkers: DefaultChunkWorkers,
+	}
+}
+
+// NewPipeClient speaks SFTP over r and w instead of an SSH channel, for
+// example to an in-process server. It cannot reconnect.
+func NewPipeClient(r io.Reader, w io.WriteCloser) (*Client, error) {
+	sftpClient, err := sftp.NewClientPipe(r, w, clientOptions()...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create SFTP client: %w", err)
+	}
+
+	return &Client{
+		sftpClient:   sftpClient,
+		chunkWorkers: DefaultChunkWorkers,
+	}, nil
+}
+
+// clientOptions enables concurrent writes as well as reads, so a single large
+// WriteAt is split into requests that are in flight together. Writes that
+// fail part way can leave gaps, which the transfer code truncates away.
+func clientOptions() []sftp.ClientOption {
+	return []sftp.ClientOption{
+		sftp.UseConcurrentReads(true),
+		sftp.UseConcurrentWrites(true),
+		sftp.MaxConcurrentRequestsPerFile(maxConcurrentRequests),
 	}
 }
 
 func (c *Client) Connect() error {
-	if !c.sshClient.IsConnected() {
+	if c.sshClient == nil || !c.sshClient.IsConnected() {
 		return fmt.Errorf("SSH not connected")
 	}
 
-	sftpClient, err := sftp.NewClient(c.sshClient.GetSSHClient())
+	sftpClient, err := sftp.NewClient(c.sshClient.GetSSHClient(), clientOptions()...)
 	if err != nil {
 		return fmt.Errorf("failed to create SFTP client: %w", err)
 	}
@@ -39,6 +71,17 @@ func (c *Client) Close() error {
 	return nil
 }
 
+// Reconnect reopens the SFTP subsystem on the SSH client's current
+// connection. A client that was never connected stays lazy.
+func (c *Client) Reconnect() error {
+	if c.sftpClient == nil {
+		return nil
+	}
+	c.sftpClient.Close()
+	c.sftpClient = nil
+	return c.Connect()
+}
+
 func (c *Client) IsConnected() bool {
 	return c.sftpClient != nil
 }
@@ -2,6 +2,7 @@ package remote
 
 import (
 	"fmt"
+	"freessh-backend/internal/throttle"
 	"path/filepath"
 	"sync"
 	"sync/atomic"
@@ -9,13 +10,13 @@ import (
 	"github.com/pkg/sftp"
 )
 
-const maxConcurrentTransfers = 5
-
 func BulkTransfer(
 	sourceClient *sftp.Client,
 	destClient *sftp.Client,
 	sourcePaths []string,
 	destDir string,
+	limit throttle.Chain,
+	gate *throttle.Gate,
 	progress ProgressCallback,
 	cancel <-chan struct{},
 ) []RemoteTransferResult {
@@ -28,7 +29,6 @@ func BulkTransfer(
 
 	results := make([]RemoteTransferResult, len(sourcePaths))
 	var wg sync.WaitGroup
-	sem := make(chan struct{}, maxConcurrentTransfers)
 
 	var completed, failed int32
 	var totalBytes, transferredBytes int64
@@ -45,8 +45,8 @@ func BulkTransfer(
 		go func(index int, path string) {
 			defer wg.Done()
 
-			select {
-			case <-cancel:
+			// Each item takes a transfer slot, shared with the queue
+			if err := gate.Acquire(cancel); err != nil {
 				results[index] = RemoteTransferResult{
 					SourcePath: path,
 					Success:    false,
@@ -54,9 +54,8 @@ func BulkTransfer(
 				}
 				atomic.AddInt32(&failed, 1)
 				return
-			case sem <- struct{}{}:
 			}
-			defer func() { <-sem }()
+			defer gate.Release()
 
 			fileName := filepath.Base(path)
 			destPath := filepath.Join(destDir, fileName)
@@ -72,7 +71,7 @@ func BulkTransfer(
 				})
 			}
 
-			err := transferRecursive(sourceClient, destClient, path, destPath, func(transferred, total int64) {
+			err := transferRecursive(sourceClient, destClient, path, destPath, limit, func(transferred, total int64) {
 				// Calculate delta from last reported progress for this file
 				var lastTransferred int64
 				if val, ok := fileOffsets.Load(path); ok {
@@ -159,6 +158,7 @@ func transferRecursive(
 	destClient *sftp.Client,
 	sourcePath string,
 	destPath string,
+	limit throttle.Chain,
 	progress func(transferred, total int64),
 	cancel <-chan struct{},
 ) error {
@@ -168,7 +168,7 @@ func transferRecursive(
 	}
 
 	if !stat.IsDir() {
-		return Transfer(sourceClient, destClient, sourcePath, destPath, progress, cancel)
+		return Transfer(sourceClient, destClient, sourcePath, destPath, limit, progress, cancel)
 	}
 
 	// Create destination directory
@@ -193,7 +193,7 @@ func transferRecursive(
 		srcPath := filepath.Join(sourcePath, entry.Name())
 		dstPath := filepath.Join(destPath, entry.Name())
 
-		if err := transferRecursive(sourceClient, destClient, srcPath, dstPath, progress, cancel); err != nil {
+		if err := transferRecursive(sourceClient, destClient, srcPath, dstPath, limit, progress, cancel); err != nil {
 			return err
 		}
 	}
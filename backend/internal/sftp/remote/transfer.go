@@ -2,6 +2,7 @@ package remote
 
 import (
 	"fmt"
+	"freessh-backend/internal/throttle"
 	"io"
 	"path/filepath"
 
@@ -13,6 +14,7 @@ func Transfer(
 	destClient *sftp.Client,
 	sourcePath string,
 	destPath string,
+	limit throttle.Chain,
 	progress func(transferred, total int64),
 	cancel <-chan struct{},
 ) error {
@@ -73,6 +75,10 @@ func Transfer(
 			break
 		}
 
+		if err := limit.Wait(n, cancel); err != nil {
+			destClient.Remove(destPath)
+			return fmt.Errorf("transfer cancelled")
+		}
 		if _, err := destFile.Write(buf[:n]); err != nil {
 			return fmt.Errorf("failed to write destination file: %w", err)
 		}
@@ -5,6 +5,8 @@ type RemoteTransferRequest struct {
 	DestSessionID   string `json:"dest_session_id"`
 	SourcePath      string `json:"source_path"`
 	DestPath        string `json:"dest_path"`
+	TransferID      string `json:"transfer_id,omitempty"`
+	RateLimit       int64  `json:"rate_limit,omitempty"`
 }
 
 type BulkRemoteTransferRequest struct {
@@ -12,6 +14,8 @@ type BulkRemoteTransferRequest struct {
 	DestSessionID   string   `json:"dest_session_id"`
 	SourcePaths     []string `json:"source_paths"`
 	DestDir         string   `json:"dest_dir"`
+	TransferID      string   `json:"transfer_id,omitempty"`
+	RateLimit       int64    `json:"rate_limit,omitempty"`
 }
 
 type RemoteTransferResult struct {
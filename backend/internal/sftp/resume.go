@@ -0,0 +1,120 @@
+package sftp
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// resumeTailSize is how much of an existing partial destination is compared
+// with the source before a transfer continues after it.
+const resumeTailSize = 64 * 1024
+
+// UploadOffset returns the byte offset an upload of localPath to remotePath
+// can continue from. It is zero when the remote file is missing, not shorter
+// than the local file, or its last block differs from the local file.
+func (c *Client) UploadOffset(localPath, remotePath string) (int64, error) {
+	if !c.IsConnected() {
+		return 0, fmt.Errorf("SFTP not connected")
+	}
+
+	localFile, err := os.Open(localPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open local file: %w", err)
+	}
+	defer localFile.Close()
+
+	localStat, err := localFile.Stat()
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat local file: %w", err)
+	}
+
+	remoteFile, err := c.sftpClient.Open(remotePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to open remote file: %w", err)
+	}
+	defer remoteFile.Close()
+
+	remoteStat, err := remoteFile.Stat()
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat remote file: %w", err)
+	}
+
+	return resumeOffset(localFile, localStat.Size(), remoteFile, remoteStat.Size())
+}
+
+// DownloadOffset is the download counterpart of UploadOffset.
+func (c *Client) DownloadOffset(remotePath, localPath string) (int64, error) {
+	if !c.IsConnected() {
+		return 0, fmt.Errorf("SFTP not connected")
+	}
+
+	localFile, err := os.Open(localPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to open local file: %w", err)
+	}
+	defer localFile.Close()
+
+	localStat, err := localFile.Stat()
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat local file: %w", err)
+	}
+
+	remoteFile, err := c.sftpClient.Open(remotePath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open remote file: %w", err)
+	}
+	defer remoteFile.Close()
+
+	remoteStat, err := remoteFile.Stat()
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat remote file: %w", err)
+	}
+
+	return resumeOffset(remoteFile, remoteStat.Size(), localFile, localStat.Size())
+}
+
+// resumeOffset decides whether dst is a prefix of src by comparing sizes and
+// the hash of the block just before the end of dst.
+func resumeOffset(src io.ReaderAt, srcSize int64, dst io.ReaderAt, dstSize int64) (int64, error) {
+	if dstSize <= 0 || dstSize >= srcSize {
+		return 0, nil
+	}
+
+	srcHash, err := tailHash(src, dstSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read source block: %w", err)
+	}
+	dstHash, err := tailHash(dst, dstSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read destination block: %w", err)
+	}
+
+	if !bytes.Equal(srcHash, dstHash) {
+		return 0, nil
+	}
+	return dstSize, nil
+}
+
+// tailHash hashes the resumeTailSize bytes of r that end at end.
+func tailHash(r io.ReaderAt, end int64) ([]byte, error) {
+	start := end - resumeTailSize
+	if start < 0 {
+		start = 0
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, io.NewSectionReader(r, start, end-start)); err != nil {
+		return nil, err
+	}
+	return hash.Sum(nil), nil
+}
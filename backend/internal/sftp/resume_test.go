@@ -0,0 +1,80 @@
+package sftp
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+// patterned returns n bytes that differ from block to block, so a misplaced
+// block shows up as a mismatch.
+func patterned(n int) []byte {
+	data := make([]byte, n)
+	for i := range data {
+		data[i] = byte(i*7 + i/4096)
+	}
+	return data
+}
+
+func TestResumeOffset(t *testing.T) {
+	src := patterned(3*resumeTailSize + 100)
+
+	corrupt := bytes.Clone(src[:2*resumeTailSize])
+	corrupt[len(corrupt)-1] ^= 0xff
+
+	corruptEarly := bytes.Clone(src[:2*resumeTailSize])
+	corruptEarly[0] ^= 0xff
+
+	tests := []struct {
+		name string
+		dst  []byte
+		want int64
+	}{
+		{"empty destination", nil, 0},
+		{"prefix", src[:2*resumeTailSize], 2 * resumeTailSize},
+		{"prefix shorter than the tail block", src[:100], 100},
+		{"one byte", src[:1], 1},
+		{"complete", src, 0},
+		{"longer than source", append(bytes.Clone(src), 0), 0},
+		{"last block differs", corrupt, 0},
+		// Only the tail block is compared
+		{"earlier block differs", corruptEarly, 2 * resumeTailSize},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resumeOffset(bytes.NewReader(src), int64(len(src)), bytes.NewReader(tt.dst), int64(len(tt.dst)))
+			if err != nil {
+				t.Fatalf("resumeOffset error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("resumeOffset = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResumeOffsetReadError(t *testing.T) {
+	src := patterned(2 * resumeTailSize)
+	failing := failingReaderAt{ReaderAt: bytes.NewReader(src), failAt: 0}
+
+	if _, err := resumeOffset(failing, int64(len(src)), bytes.NewReader(src[:100]), 100); err == nil {
+		t.Fatal("resumeOffset ignored a source read error")
+	}
+}
+
+// failingReaderAt fails every read that reaches past failAt.
+type failingReaderAt struct {
+	io.ReaderAt
+	failAt int64
+}
+
+var errInjected = errors.New("injected read error")
+
+func (r failingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off+int64(len(p)) > r.failAt {
+		return 0, errInjected
+	}
+	return r.ReaderAt.ReadAt(p, off)
+}
@@ -4,17 +4,36 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"freessh-backend/internal/throttle"
+	"hash"
 	"io"
 	"os"
 	pathpkg "path"
 	"path/filepath"
 	"strings"
+
+	"github.com/pkg/sftp"
 )
 
 var ErrTransferCancelled = errors.New("transfer cancelled")
 
 type ProgressCallback func(transferred, total int64)
 
+// TransferOptions tunes a single Upload or Download.
+type TransferOptions struct {
+	// Offset continues a partial transfer from that byte, as returned by
+	// UploadOffset or DownloadOffset. Zero starts over.
+	Offset int64
+	// Limit throttles the transfer. An empty chain does not limit it.
+	Limit throttle.Chain
+	// Hash, when set, receives the whole local file, including a resumed
+	// prefix, so after a successful transfer it can be compared with the
+	// server's checksum. Data is hashed as it streams, except in the
+	// large-file mode where ranges finish out of order and the local file is
+	// hashed once the copy is done.
+	Hash hash.Hash
+}
+
 func (c *Client) normalizeRemotePath(remotePath string) (string, error) {
 	trimmed := strings.TrimSpace(remotePath)
 	if trimmed == "" {
@@ -41,7 +60,11 @@ func (c *Client) normalizeRemotePath(remotePath string) (string, error) {
 	return pathpkg.Clean(pathpkg.Join(wd, trimmed)), nil
 }
 
-func (c *Client) Upload(localPath, remotePath string, progress ProgressCallback, cancel <-chan struct{}) error {
+// Upload copies localPath to remotePath. A positive opts.Offset continues a
+// partial upload from that byte; otherwise the remote file is truncated.
+// Large files are copied in parallel ranges. On cancel the partial remote
+// file is left in place.
+func (c *Client) Upload(localPath, remotePath string, opts TransferOptions, progress ProgressCallback, cancel <-chan struct{}) error {
 	if !c.IsConnected() {
 		return fmt.Errorf("SFTP not connected")
 	}
@@ -57,12 +80,41 @@ func (c *Client) Upload(localPath, remotePath string, progress ProgressCallback,
 		return fmt.Errorf("failed to stat local file: %w", err)
 	}
 
-	remoteFile, err := c.sftpClient.Create(remotePath)
+	offset := opts.Offset
+	remoteFile, err := c.openUploadTarget(remotePath, offset)
 	if err != nil {
-		return fmt.Errorf("failed to create remote file: %w", err)
+		return err
 	}
 	defer remoteFile.Close()
 
+	if opts.Hash != nil && offset > 0 {
+		if err := hashLocal(opts.Hash, localPath, 0, offset, cancel); err != nil {
+			return err
+		}
+	}
+
+	if c.useChunks(stat.Size() - offset) {
+		complete, err := chunkedCopy(remoteFile, localFile, offset, stat.Size(), c.chunkWorkers, opts.Limit, progress, cancel)
+		if err != nil {
+			// Drop whatever lies past the intact prefix so a resume stays valid
+			remoteFile.Truncate(complete)
+			if err == ErrTransferCancelled {
+				return err
+			}
+			return fmt.Errorf("failed to upload file: %w", err)
+		}
+		if opts.Hash != nil {
+			return hashLocal(opts.Hash, localPath, offset, stat.Size(), cancel)
+		}
+		return nil
+	}
+
+	if offset > 0 {
+		if _, err := localFile.Seek(offset, io.SeekStart); err != nil {
+			return fmt.Errorf("failed to seek local file: %w", err)
+		}
+	}
+
 	// Dynamic buffer size based on file size
 	bufSize := 256 * 1024            // 256KB default
 	if stat.Size() > 100*1024*1024 { // >100MB
@@ -70,14 +122,13 @@ func (c *Client) Upload(localPath, remotePath string, progress ProgressCallback,
 	}
 
 	buf := make([]byte, bufSize)
-	var transferred int64
-	var lastReported int64
+	transferred := offset
+	lastReported := offset
 	const reportInterval = 512 * 1024 // Report every 512KB
 
 	for {
 		select {
 		case <-cancel:
-			c.sftpClient.Remove(remotePath)
 			return ErrTransferCancelled
 		default:
 		}
@@ -90,6 +141,12 @@ func (c *Client) Upload(localPath, remotePath string, progress ProgressCallback,
 			break
 		}
 
+		if err := opts.Limit.Wait(n, cancel); err != nil {
+			return ErrTransferCancelled
+		}
+		if opts.Hash != nil {
+			opts.Hash.Write(buf[:n])
+		}
 		if _, err := remoteFile.Write(buf[:n]); err != nil {
 			return fmt.Errorf("failed to write remote file: %w", err)
 		}
@@ -110,7 +167,9 @@ func (c *Client) Upload(localPath, remotePath string, progress ProgressCallback,
 	return nil
 }
 
-func (c *Client) Download(remotePath, localPath string, progress ProgressCallback, cancel <-chan struct{}) error {
+// Download copies remotePath to localPath, continuing from opts.Offset the
+// same way Upload does. On cancel the partial local file is left in place.
+func (c *Client) Download(remotePath, localPath string, opts TransferOptions, progress ProgressCallback, cancel <-chan struct{}) error {
 	if !c.IsConnected() {
 		return fmt.Errorf("SFTP not connected")
 	}
@@ -130,12 +189,41 @@ func (c *Client) Download(remotePath, localPath string, progress ProgressCallbac
 		return fmt.Errorf("failed to create local directory: %w", err)
 	}
 
-	localFile, err := os.Create(localPath)
+	offset := opts.Offset
+	localFile, err := openDownloadTarget(localPath, offset)
 	if err != nil {
-		return fmt.Errorf("failed to create local file: %w", err)
+		return err
 	}
 	defer localFile.Close()
 
+	if opts.Hash != nil && offset > 0 {
+		if err := hashLocal(opts.Hash, localPath, 0, offset, cancel); err != nil {
+			return err
+		}
+	}
+
+	if c.useChunks(stat.Size() - offset) {
+		complete, err := chunkedCopy(localFile, remoteFile, offset, stat.Size(), c.chunkWorkers, opts.Limit, progress, cancel)
+		if err != nil {
+			// Drop whatever lies past the intact prefix so a resume stays valid
+			localFile.Truncate(complete)
+			if err == ErrTransferCancelled {
+				return err
+			}
+			return fmt.Errorf("failed to download file: %w", err)
+		}
+		if opts.Hash != nil {
+			return hashLocal(opts.Hash, localPath, offset, stat.Size(), cancel)
+		}
+		return nil
+	}
+
+	if offset > 0 {
+		if _, err := remoteFile.Seek(offset, io.SeekStart); err != nil {
+			return fmt.Errorf("failed to seek remote file: %w", err)
+		}
+	}
+
 	// Dynamic buffer size based on file size
 	bufSize := 256 * 1024            // 256KB default
 	if stat.Size() > 100*1024*1024 { // >100MB
@@ -143,15 +231,13 @@ func (c *Client) Download(remotePath, localPath string, progress ProgressCallbac
 	}
 
 	buf := make([]byte, bufSize)
-	var transferred int64
-	var lastReported int64
+	transferred := offset
+	lastReported := offset
 	const reportInterval = 512 * 1024 // Report every 512KB
 
 	for {
 		select {
 		case <-cancel:
-			localFile.Close()
-			os.Remove(localPath)
 			return ErrTransferCancelled
 		default:
 		}
@@ -164,6 +250,12 @@ func (c *Client) Download(remotePath, localPath string, progress ProgressCallbac
 			break
 		}
 
+		if err := opts.Limit.Wait(n, cancel); err != nil {
+			return ErrTransferCancelled
+		}
+		if opts.Hash != nil {
+			opts.Hash.Write(buf[:n])
+		}
 		if _, err := localFile.Write(buf[:n]); err != nil {
 			return fmt.Errorf("failed to write local file: %w", err)
 		}
@@ -184,6 +276,46 @@ func (c *Client) Download(remotePath, localPath string, progress ProgressCallbac
 	return nil
 }
 
+func (c *Client) openUploadTarget(remotePath string, offset int64) (*sftp.File, error) {
+	if offset <= 0 {
+		file, err := c.sftpClient.Create(remotePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create remote file: %w", err)
+		}
+		return file, nil
+	}
+
+	file, err := c.sftpClient.OpenFile(remotePath, os.O_WRONLY)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open remote file: %w", err)
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to seek remote file: %w", err)
+	}
+	return file, nil
+}
+
+func openDownloadTarget(localPath string, offset int64) (*os.File, error) {
+	if offset <= 0 {
+		file, err := os.Create(localPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create local file: %w", err)
+		}
+		return file, nil
+	}
+
+	file, err := os.OpenFile(localPath, os.O_WRONLY, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open local file: %w", err)
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to seek local file: %w", err)
+	}
+	return file, nil
+}
+
 func (c *Client) ReadFile(remotePath string, binary bool) (string, error) {
 	if !c.IsConnected() {
 		return "", fmt.Errorf("SFTP not connected")
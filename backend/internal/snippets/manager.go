@@ -1,8 +1,14 @@
 package snippets
 
 import (
+	"fmt"
 	"freessh-backend/internal/models"
+	"freessh-backend/internal/osdetect"
 	"freessh-backend/internal/storage"
+	"path"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -18,21 +24,62 @@ func NewManager(storage *storage.SnippetStorage) *Manager {
 	}
 }
 
-func (m *Manager) List() ([]models.Snippet, error) {
-	return m.storage.List(), nil
+// List returns the snippets matching filter, sorted by folder and name,
+// together with every folder and tag in the library.
+func (m *Manager) List(filter models.SnippetListRequest) (*models.SnippetListResponse, error) {
+	all := m.storage.List()
+
+	response := &models.SnippetListResponse{
+		Snippets: make([]models.Snippet, 0),
+		Folders:  make([]string, 0),
+		Tags:     make([]string, 0),
+	}
+	folders := make(map[string]bool)
+	tags := make(map[string]bool)
+	for _, snippet := range all {
+		// Parent folders are listed too so the UI can build a tree
+		for folder := snippet.Folder; folder != "" && folder != "."; folder = path.Dir(folder) {
+			folders[folder] = true
+		}
+		for _, tag := range snippet.Tags {
+			tags[tag] = true
+		}
+		if matches(snippet, filter) {
+			response.Snippets = append(response.Snippets, snippet)
+		}
+	}
+
+	for folder := range folders {
+		response.Folders = append(response.Folders, folder)
+	}
+	for tag := range tags {
+		response.Tags = append(response.Tags, tag)
+	}
+	sort.Strings(response.Folders)
+	sort.Strings(response.Tags)
+	sort.SliceStable(response.Snippets, func(i, j int) bool {
+		a, b := response.Snippets[i], response.Snippets[j]
+		if a.Folder != b.Folder {
+			return a.Folder < b.Folder
+		}
+		return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+	})
+
+	return response, nil
 }
 
 func (m *Manager) Get(id string) (*models.Snippet, error) {
 	return m.storage.Get(id)
 }
 
-func (m *Manager) Create(name, command string) (*models.Snippet, error) {
-	snippet := models.Snippet{
-		ID:        uuid.New().String(),
-		Name:      name,
-		Command:   command,
-		CreatedAt: time.Now(),
+// Create saves a new snippet from the editable fields of input.
+func (m *Manager) Create(input models.Snippet) (*models.Snippet, error) {
+	snippet, err := prepare(input)
+	if err != nil {
+		return nil, err
 	}
+	snippet.ID = uuid.New().String()
+	snippet.CreatedAt = time.Now()
 
 	if err := m.storage.Create(snippet); err != nil {
 		return nil, err
@@ -41,18 +88,22 @@ func (m *Manager) Create(name, command string) (*models.Snippet, error) {
 	return &snippet, nil
 }
 
-func (m *Manager) Update(id, name, command string) (*models.Snippet, error) {
-	existing, err := m.storage.Get(id)
+// Update replaces the editable fields of the snippet with input.ID, keeping
+// its creation time and usage statistics.
+func (m *Manager) Update(input models.Snippet) (*models.Snippet, error) {
+	snippet, err := prepare(input)
 	if err != nil {
 		return nil, err
 	}
 
-	snippet := models.Snippet{
-		ID:        id,
-		Name:      name,
-		Command:   command,
-		CreatedAt: existing.CreatedAt,
+	existing, err := m.storage.Get(input.ID)
+	if err != nil {
+		return nil, err
 	}
+	snippet.ID = existing.ID
+	snippet.CreatedAt = existing.CreatedAt
+	snippet.UsageCount = existing.UsageCount
+	snippet.LastUsedAt = existing.LastUsedAt
 
 	if err := m.storage.Update(snippet); err != nil {
 		return nil, err
@@ -61,6 +112,189 @@ func (m *Manager) Update(id, name, command string) (*models.Snippet, error) {
 	return &snippet, nil
 }
 
+// Edit applies req to the snippet with req.ID. Fields req leaves out keep
+// their stored values, so clients that only send the name and command do not
+// clear the rest.
+func (m *Manager) Edit(req models.SnippetUpdateRequest) (*models.Snippet, error) {
+	existing, err := m.storage.Get(req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	input := *existing
+	input.Name = req.Name
+	input.Command = req.Command
+	if req.Params != nil {
+		input.Params = *req.Params
+	}
+	if req.Folder != nil {
+		input.Folder = *req.Folder
+	}
+	if req.Tags != nil {
+		input.Tags = *req.Tags
+	}
+	if req.Description != nil {
+		input.Description = *req.Description
+	}
+	if req.Variants != nil {
+		input.Variants = *req.Variants
+	}
+	return m.Update(input)
+}
+
 func (m *Manager) Delete(id string) error {
 	return m.storage.Delete(id)
 }
+
+// RecordUse counts a run of the snippet for its usage statistics.
+func (m *Manager) RecordUse(id string) error {
+	return m.storage.RecordUse(id, time.Now())
+}
+
+// Params returns the parameters to prompt for before running the snippet
+// with snippetID on osType, or command when snippetID is empty.
+func (m *Manager) Params(snippetID, command, osType string) ([]models.SnippetParam, error) {
+	command, declared, err := m.resolve(snippetID, command, osType)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateParams(command, declared); err != nil {
+		return nil, err
+	}
+	return Params(command, declared), nil
+}
+
+// Render fills in the snippet with snippetID, using its variant for osType,
+// or command when snippetID is empty. builtins may be nil when the snippet
+// is not run on a connection.
+func (m *Manager) Render(snippetID, command, osType string, values, builtins map[string]string) (string, error) {
+	command, declared, err := m.resolve(snippetID, command, osType)
+	if err != nil {
+		return "", err
+	}
+	return Render(command, osType, declared, values, builtins)
+}
+
+func (m *Manager) resolve(snippetID, command, osType string) (string, []models.SnippetParam, error) {
+	if snippetID == "" {
+		return command, nil, nil
+	}
+	snippet, err := m.storage.Get(snippetID)
+	if err != nil {
+		return "", nil, err
+	}
+	return CommandFor(*snippet, osType), snippet.Params, nil
+}
+
+// CommandFor returns the command of snippet for osType: the variant for that
+// exact OS, else the one for its family (such as "linux" for Ubuntu), else
+// the default command.
+func CommandFor(snippet models.Snippet, osType string) string {
+	if osType == "" || len(snippet.Variants) == 0 {
+		return snippet.Command
+	}
+	if command, ok := snippet.Variants[osType]; ok {
+		return command
+	}
+	if command, ok := snippet.Variants[string(osdetect.Family(osdetect.OSType(osType)))]; ok {
+		return command
+	}
+	return snippet.Command
+}
+
+// prepare normalizes the editable fields of input and validates them.
+func prepare(input models.Snippet) (models.Snippet, error) {
+	snippet := models.Snippet{
+		Name:        strings.TrimSpace(input.Name),
+		Command:     input.Command,
+		Params:      input.Params,
+		Folder:      NormalizeFolder(input.Folder),
+		Tags:        normalizeTags(input.Tags),
+		Description: strings.TrimSpace(input.Description),
+	}
+	if snippet.Name == "" {
+		return snippet, fmt.Errorf("snippet name is required")
+	}
+
+	if err := ValidateParams(snippet.Command, snippet.Params); err != nil {
+		return snippet, err
+	}
+	for osType, command := range input.Variants {
+		osType = strings.ToLower(strings.TrimSpace(osType))
+		if !osdetect.Known(osdetect.OSType(osType)) {
+			return snippet, fmt.Errorf("unknown OS for snippet variant: %q", osType)
+		}
+		if strings.TrimSpace(command) == "" {
+			continue
+		}
+		if err := ValidateParams(command, snippet.Params); err != nil {
+			return snippet, fmt.Errorf("%s variant: %w", osType, err)
+		}
+		if snippet.Variants == nil {
+			snippet.Variants = make(map[string]string)
+		}
+		snippet.Variants[osType] = command
+	}
+	if strings.TrimSpace(snippet.Command) == "" && len(snippet.Variants) == 0 {
+		return snippet, fmt.Errorf("snippet command is required")
+	}
+
+	return snippet, nil
+}
+
+// NormalizeFolder cleans a folder path: no leading, trailing or repeated
+// slashes, and "" for the top level.
+func NormalizeFolder(folder string) string {
+	folder = strings.TrimSpace(strings.ReplaceAll(folder, "\\", "/"))
+	if folder == "" {
+		return ""
+	}
+	folder = strings.Trim(path.Clean("/"+folder), "/")
+	return folder
+}
+
+func normalizeTags(tags []string) []string {
+	var normalized []string
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized
+}
+
+func matches(snippet models.Snippet, filter models.SnippetListRequest) bool {
+	if folder := NormalizeFolder(filter.Folder); folder != "" {
+		if snippet.Folder != folder && !strings.HasPrefix(snippet.Folder, folder+"/") {
+			return false
+		}
+	}
+
+	if tag := strings.ToLower(strings.TrimSpace(filter.Tag)); tag != "" && !slices.Contains(snippet.Tags, tag) {
+		return false
+	}
+
+	// Snippets with only other OSes' variants have nothing to run here
+	if osType := strings.ToLower(strings.TrimSpace(filter.OS)); osType != "" {
+		if strings.TrimSpace(CommandFor(snippet, osType)) == "" {
+			return false
+		}
+	}
+
+	query := strings.ToLower(strings.TrimSpace(filter.Query))
+	if query == "" {
+		return true
+	}
+	fields := []string{snippet.Name, snippet.Description, snippet.Command, snippet.Folder}
+	fields = append(fields, snippet.Tags...)
+	for _, command := range snippet.Variants {
+		fields = append(fields, command)
+	}
+	for _, field := range fields {
+		if strings.Contains(strings.ToLower(field), query) {
+			return true
+		}
+	}
+	return false
+}
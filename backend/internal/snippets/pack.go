@@ -0,0 +1,205 @@
+package snippets
+
+import (
+	"encoding/json"
+	"fmt"
+	"freessh-backend/internal/models"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ExportPack returns the snippets selected by req as an indented JSON pack.
+// Folders are made relative to req.Folder so the pack can be imported under
+// any folder.
+func (m *Manager) ExportPack(req models.SnippetExportPackRequest) ([]byte, error) {
+	folder := NormalizeFolder(req.Folder)
+	pack := models.SnippetPack{
+		Name:        strings.TrimSpace(req.Name),
+		Description: strings.TrimSpace(req.Description),
+		Snippets:    make([]models.SnippetPackEntry, 0),
+	}
+
+	for _, snippet := range m.storage.List() {
+		switch {
+		case len(req.IDs) > 0:
+			if !slices.Contains(req.IDs, snippet.ID) {
+				continue
+			}
+		case folder != "":
+			if snippet.Folder != folder && !strings.HasPrefix(snippet.Folder, folder+"/") {
+				continue
+			}
+			snippet.Folder = strings.TrimPrefix(strings.TrimPrefix(snippet.Folder, folder), "/")
+		}
+
+		pack.Snippets = append(pack.Snippets, models.SnippetPackEntry{
+			Name:        snippet.Name,
+			Command:     snippet.Command,
+			Params:      snippet.Params,
+			Folder:      snippet.Folder,
+			Tags:        snippet.Tags,
+			Description: snippet.Description,
+			Variants:    snippet.Variants,
+		})
+	}
+
+	data, err := json.MarshalIndent(pack, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal snippet pack: %w", err)
+	}
+	return data, nil
+}
+
+// ImportPack adds the snippets of a JSON pack, or of the .sh files under a
+// directory, below req.Folder. A snippet with the same folder and name as an
+// existing one replaces it, keeping the existing usage statistics.
+func (m *Manager) ImportPack(req models.SnippetImportPackRequest) (*models.SnippetImportPackResponse, error) {
+	var incoming []models.Snippet
+	var result models.SnippetImportPackResponse
+
+	if req.Directory != "" {
+		snippets, errs, err := readScriptDirectory(req.Directory)
+		if err != nil {
+			return nil, err
+		}
+		incoming = snippets
+		result.Errors = errs
+	} else {
+		var pack models.SnippetPack
+		if err := json.Unmarshal(req.Data, &pack); err != nil {
+			return nil, fmt.Errorf("failed to parse snippet pack: %w", err)
+		}
+		for _, entry := range pack.Snippets {
+			incoming = append(incoming, models.Snippet{
+				Name:        entry.Name,
+				Command:     entry.Command,
+				Params:      entry.Params,
+				Folder:      entry.Folder,
+				Tags:        entry.Tags,
+				Description: entry.Description,
+				Variants:    entry.Variants,
+			})
+		}
+	}
+
+	existing := make(map[string]string)
+	for _, snippet := range m.storage.List() {
+		existing[snippetKey(snippet.Folder, snippet.Name)] = snippet.ID
+	}
+
+	target := NormalizeFolder(req.Folder)
+	for _, snippet := range incoming {
+		snippet.Folder = NormalizeFolder(path.Join(target, snippet.Folder))
+
+		key := snippetKey(snippet.Folder, snippet.Name)
+		if id, ok := existing[key]; ok {
+			snippet.ID = id
+			if _, err := m.Update(snippet); err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", snippet.Name, err))
+				continue
+			}
+			result.Updated++
+			continue
+		}
+
+		created, err := m.Create(snippet)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", snippet.Name, err))
+			continue
+		}
+		existing[key] = created.ID
+		result.Imported++
+	}
+
+	return &result, nil
+}
+
+func snippetKey(folder, name string) string {
+	return strings.ToLower(NormalizeFolder(folder) + "\x00" + strings.TrimSpace(name))
+}
+
+// readScriptDirectory turns every .sh file under dir into a snippet named
+// after the file, in a folder matching its subdirectory. Files that cannot
+// be read or are empty are reported in errs.
+func readScriptDirectory(dir string) (snippets []models.Snippet, errs []string, err error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open snippet directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, nil, fmt.Errorf("not a directory: %s", dir)
+	}
+
+	err = filepath.WalkDir(dir, func(filePath string, entry fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			errs = append(errs, walkErr.Error())
+			return nil
+		}
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ".sh") {
+			return nil
+		}
+
+		rel, _ := filepath.Rel(dir, filePath)
+		content, readErr := os.ReadFile(filePath)
+		if readErr != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", rel, readErr))
+			return nil
+		}
+
+		snippet := parseScript(string(content))
+		if strings.TrimSpace(snippet.Command) == "" {
+			errs = append(errs, fmt.Sprintf("%s: no commands", rel))
+			return nil
+		}
+		snippet.Name = strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		snippet.Folder = NormalizeFolder(filepath.ToSlash(filepath.Dir(rel)))
+		snippets = append(snippets, snippet)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read snippet directory: %w", err)
+	}
+
+	return snippets, errs, nil
+}
+
+// parseScript splits a shell script into its leading comment block and its
+// body. The shebang is dropped; "# tags: a, b" and "# description: ..." lines
+// in the comment block are read as such, and other comment lines form the
+// description.
+func parseScript(content string) models.Snippet {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	if len(lines) > 0 && strings.HasPrefix(lines[0], "#!") {
+		lines = lines[1:]
+	}
+
+	var snippet models.Snippet
+	var description []string
+	body := 0
+	for ; body < len(lines); body++ {
+		line := strings.TrimSpace(lines[body])
+		if !strings.HasPrefix(line, "#") {
+			break
+		}
+		comment := strings.TrimSpace(strings.TrimLeft(line, "#"))
+		key, value, _ := strings.Cut(comment, ":")
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "tags":
+			snippet.Tags = normalizeTags(strings.Split(value, ","))
+		case "description":
+			description = append(description, strings.TrimSpace(value))
+		default:
+			if comment != "" {
+				description = append(description, comment)
+			}
+		}
+	}
+
+	snippet.Description = strings.Join(description, " ")
+	snippet.Command = strings.TrimSpace(strings.Join(lines[body:], "\n"))
+	return snippet
+}
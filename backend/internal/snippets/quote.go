@@ -0,0 +1,170 @@
+package snippets
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// shellSafePattern matches values that need no quoting in a POSIX shell.
+var shellSafePattern = regexp.MustCompile(`^[A-Za-z0-9_./:=@%+,-]+$`)
+
+// windowsSafePattern matches values that need no quoting in cmd or
+// PowerShell.
+var windowsSafePattern = regexp.MustCompile(`^[A-Za-z0-9_./:+-]+$`)
+
+// quoteContext is where a placeholder sits in the command around it.
+type quoteContext int
+
+const (
+	contextUnquoted quoteContext = iota
+	contextSingle
+	contextDouble
+	contextBacktick
+	contextANSI
+)
+
+// quoteContexts returns the quote context of each placeholder of command,
+// keyed by the placeholder's offset. Command substitutions start a new
+// unquoted context, even inside double quotes.
+func quoteContexts(command string, windows bool) map[int]quoteContext {
+	placeholders := make(map[int]int)
+	for _, match := range placeholderPattern.FindAllStringIndex(command, -1) {
+		placeholders[match[0]] = match[1]
+	}
+
+	type frame struct {
+		context quoteContext
+		parens  int
+	}
+	stack := []frame{{context: contextUnquoted}}
+	contexts := make(map[int]quoteContext)
+
+	for i := 0; i < len(command); i++ {
+		top := &stack[len(stack)-1]
+		if end, ok := placeholders[i]; ok {
+			contexts[i] = top.context
+			i = end - 1
+			continue
+		}
+
+		c := command[i]
+		if windows {
+			// cmd and PowerShell have no escapes that matter here.
+			switch {
+			case c == '"' && top.context == contextDouble, c == '\'' && top.context == contextSingle:
+				stack = stack[:len(stack)-1]
+			case c == '"' && top.context == contextUnquoted:
+				stack = append(stack, frame{context: contextDouble})
+			case c == '\'' && top.context == contextUnquoted:
+				stack = append(stack, frame{context: contextSingle})
+			}
+			continue
+		}
+
+		switch top.context {
+		case contextSingle:
+			if c == '\'' {
+				stack = stack[:len(stack)-1]
+			}
+		case contextANSI, contextBacktick:
+			closing := byte('\'')
+			if top.context == contextBacktick {
+				closing = '`'
+			}
+			if c == '\\' {
+				i++
+			} else if c == closing {
+				stack = stack[:len(stack)-1]
+			}
+		case contextDouble:
+			switch {
+			case c == '\\':
+				i++
+			case c == '"':
+				stack = stack[:len(stack)-1]
+			case c == '`':
+				stack = append(stack, frame{context: contextBacktick})
+			case c == '$' && i+1 < len(command) && command[i+1] == '(':
+				stack = append(stack, frame{context: contextUnquoted})
+				i++
+			}
+		default:
+			switch {
+			case c == '\\':
+				i++
+			case c == '\'':
+				stack = append(stack, frame{context: contextSingle})
+			case c == '"':
+				stack = append(stack, frame{context: contextDouble})
+			case c == '`':
+				stack = append(stack, frame{context: contextBacktick})
+			case c == '$' && i+1 < len(command) && command[i+1] == '\'':
+				stack = append(stack, frame{context: contextANSI})
+				i++
+			case c == '$' && i+1 < len(command) && command[i+1] == '(':
+				stack = append(stack, frame{context: contextUnquoted})
+				i++
+			case c == '(':
+				top.parens++
+			case c == ')' && top.parens > 0:
+				top.parens--
+			case c == ')' && len(stack) > 1:
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+
+	return contexts
+}
+
+// quotePOSIX quotes value so a POSIX shell reads it as a single literal word
+// wherever the placeholder sits.
+func quotePOSIX(value string, context quoteContext) (string, error) {
+	switch context {
+	case contextSingle:
+		return strings.ReplaceAll(value, "'", `'\''`), nil
+	case contextDouble:
+		var builder strings.Builder
+		for _, r := range value {
+			if strings.ContainsRune("\\\"$`", r) {
+				builder.WriteByte('\\')
+			}
+			builder.WriteRune(r)
+		}
+		return builder.String(), nil
+	case contextBacktick:
+		return "", fmt.Errorf("placeholders inside backticks are not supported, use $(...) instead")
+	case contextANSI:
+		return "", fmt.Errorf("placeholders inside $'...' strings are not supported")
+	default:
+		if shellSafePattern.MatchString(value) {
+			return value, nil
+		}
+		return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'", nil
+	}
+}
+
+// windowsUnsafe are the characters that still expand or end the string inside
+// double quotes in cmd or PowerShell. Neither shell can escape all of them,
+// so values containing them are refused.
+const windowsUnsafe = "\"%!$`\r\n"
+
+// quoteWindows quotes value for cmd and PowerShell alike, as the shell of a
+// Windows host is not known in advance.
+func quoteWindows(value string, context quoteContext) (string, error) {
+	if windowsSafePattern.MatchString(value) {
+		return value, nil
+	}
+	if context == contextSingle {
+		// cmd does not treat single quotes as quotes at all
+		return "", fmt.Errorf("value %q cannot be used inside single quotes on Windows", value)
+	}
+	if strings.ContainsAny(value, windowsUnsafe) {
+		return "", fmt.Errorf("value %q cannot be passed safely to a Windows shell", value)
+	}
+	if context == contextDouble {
+		return value, nil
+	}
+	return `"` + value + `"`, nil
+}
@@ -0,0 +1,229 @@
+package snippets
+
+import (
+	"fmt"
+	"freessh-backend/internal/models"
+	"freessh-backend/internal/osdetect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// placeholderPattern matches {{name}}, {{name:type}}, {{name=default}} and
+// {{name:type=default}}. Dotted names are reserved for built-in variables.
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_.]*)\s*(?::\s*([A-Za-z]+)\s*)?(?:=([^}]*))?\}\}`)
+
+var paramNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// builtinNames are the variables filled from the connection a snippet runs
+// on rather than prompted for.
+var builtinNames = []string{"host", "user", "connection.name", "connection.port"}
+
+// BuiltinValues returns the built-in template variables for config.
+func BuiltinValues(config models.ConnectionConfig) map[string]string {
+	return map[string]string{
+		"host":            config.Host,
+		"user":            config.Username,
+		"connection.name": config.Name,
+		"connection.port": strconv.Itoa(config.Port),
+	}
+}
+
+// Params returns the parameters to prompt for before running command: every
+// placeholder that is not a built-in variable, in order of first use, with
+// the fields of declared filling in or overriding the inline ones.
+func Params(command string, declared []models.SnippetParam) []models.SnippetParam {
+	params := make([]models.SnippetParam, 0)
+	seen := make(map[string]bool)
+
+	for _, match := range placeholderPattern.FindAllStringSubmatch(command, -1) {
+		name := match[1]
+		if seen[name] || isBuiltin(name) {
+			continue
+		}
+		seen[name] = true
+
+		param := models.SnippetParam{
+			Name:    name,
+			Type:    models.SnippetParamType(strings.ToLower(match[2])),
+			Default: strings.TrimSpace(match[3]),
+		}
+		if i := slices.IndexFunc(declared, func(p models.SnippetParam) bool { return p.Name == name }); i >= 0 {
+			param = mergeParam(param, declared[i])
+		}
+		if param.Type == "" {
+			param.Type = models.SnippetParamString
+		}
+		params = append(params, param)
+	}
+
+	return params
+}
+
+// ValidateParams checks the placeholders of command and the declared
+// parameters: names, types, and that defaults and choices fit their type.
+func ValidateParams(command string, declared []models.SnippetParam) error {
+	names := make(map[string]bool)
+	for _, param := range declared {
+		if !paramNamePattern.MatchString(param.Name) {
+			return fmt.Errorf("invalid parameter name: %q", param.Name)
+		}
+		if isBuiltin(param.Name) {
+			return fmt.Errorf("parameter %q is a built-in variable", param.Name)
+		}
+		if names[param.Name] {
+			return fmt.Errorf("parameter %q is declared twice", param.Name)
+		}
+		names[param.Name] = true
+	}
+
+	for _, match := range placeholderPattern.FindAllStringSubmatch(command, -1) {
+		if strings.Contains(match[1], ".") && !isBuiltin(match[1]) {
+			return fmt.Errorf("unknown variable: {{%s}}", match[1])
+		}
+	}
+
+	for _, param := range Params(command, declared) {
+		if !validType(param.Type) {
+			return fmt.Errorf("parameter %q has unknown type %q", param.Name, param.Type)
+		}
+		for _, choice := range param.Choices {
+			if _, err := normalizeValue(param.Type, choice); err != nil {
+				return fmt.Errorf("parameter %q choice %q: %w", param.Name, choice, err)
+			}
+		}
+		if param.Default != "" {
+			if _, err := checkValue(param, param.Default); err != nil {
+				return fmt.Errorf("parameter %q default: %w", param.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Render replaces the placeholders of command with values, falling back to
+// parameter defaults, and built-in variables with builtins, which may be nil
+// when no connection is known. Every value is quoted for the shell of osType
+// and for the quotes the placeholder sits in.
+func Render(command, osType string, declared []models.SnippetParam, values, builtins map[string]string) (string, error) {
+	if err := ValidateParams(command, declared); err != nil {
+		return "", err
+	}
+
+	// Windows hosts run cmd or PowerShell; everything else a POSIX shell.
+	windows := osdetect.OSType(osType) == osdetect.Windows
+	quote := quotePOSIX
+	if windows {
+		quote = quoteWindows
+	}
+	contexts := quoteContexts(command, windows)
+
+	params := make(map[string]models.SnippetParam)
+	for _, param := range Params(command, declared) {
+		params[param.Name] = param
+	}
+
+	var builder strings.Builder
+	last := 0
+	for _, match := range placeholderPattern.FindAllStringSubmatchIndex(command, -1) {
+		builder.WriteString(command[last:match[0]])
+		last = match[1]
+
+		value, err := placeholderValue(command[match[2]:match[3]], params, values, builtins)
+		if err != nil {
+			return "", err
+		}
+		quoted, err := quote(value, contexts[match[0]])
+		if err != nil {
+			return "", fmt.Errorf("{{%s}}: %w", command[match[2]:match[3]], err)
+		}
+		builder.WriteString(quoted)
+	}
+	builder.WriteString(command[last:])
+
+	return builder.String(), nil
+}
+
+// placeholderValue returns the unquoted value for the placeholder name.
+func placeholderValue(name string, params map[string]models.SnippetParam, values, builtins map[string]string) (string, error) {
+	if isBuiltin(name) {
+		value, ok := builtins[name]
+		if !ok {
+			return "", fmt.Errorf("{{%s}} needs a connection", name)
+		}
+		return value, nil
+	}
+
+	param := params[name]
+	value, ok := values[name]
+	if !ok {
+		if param.Default == "" {
+			return "", fmt.Errorf("missing value for parameter %q", name)
+		}
+		value = param.Default
+	}
+
+	value, err := checkValue(param, value)
+	if err != nil {
+		return "", fmt.Errorf("parameter %q: %w", name, err)
+	}
+	return value, nil
+}
+
+// checkValue normalizes value for the type of param and checks it is one of
+// the param's choices, if any.
+func checkValue(param models.SnippetParam, value string) (string, error) {
+	value, err := normalizeValue(param.Type, value)
+	if err != nil {
+		return "", err
+	}
+	if len(param.Choices) > 0 && !slices.Contains(param.Choices, value) {
+		return "", fmt.Errorf("%q is not one of %s", value, strings.Join(param.Choices, ", "))
+	}
+	return value, nil
+}
+
+func normalizeValue(paramType models.SnippetParamType, value string) (string, error) {
+	switch paramType {
+	case models.SnippetParamInt:
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return "", fmt.Errorf("%q is not an integer", value)
+		}
+		return strconv.Itoa(n), nil
+	case models.SnippetParamBool:
+		b, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return "", fmt.Errorf("%q is not a boolean", value)
+		}
+		return strconv.FormatBool(b), nil
+	default:
+		return value, nil
+	}
+}
+
+func mergeParam(inline, declared models.SnippetParam) models.SnippetParam {
+	if declared.Type != "" {
+		inline.Type = declared.Type
+	}
+	if declared.Default != "" {
+		inline.Default = declared.Default
+	}
+	inline.Choices = declared.Choices
+	inline.Description = declared.Description
+	return inline
+}
+
+func validType(paramType models.SnippetParamType) bool {
+	switch paramType {
+	case models.SnippetParamString, models.SnippetParamInt, models.SnippetParamBool:
+		return true
+	}
+	return false
+}
+
+func isBuiltin(name string) bool {
+	return slices.Contains(builtinNames, name)
+}
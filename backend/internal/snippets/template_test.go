@@ -0,0 +1,257 @@
+package snippets
+
+import (
+	"freessh-backend/internal/models"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestQuoteContexts(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		windows bool
+		want    []quoteContext
+	}{
+		{"unquoted", "echo {{a}}", false, []quoteContext{contextUnquoted}},
+		{"single", "echo '{{a}}'", false, []quoteContext{contextSingle}},
+		{"double", `echo "{{a}}"`, false, []quoteContext{contextDouble}},
+		{"backtick", "echo `{{a}}`", false, []quoteContext{contextBacktick}},
+		{"ansi", "echo $'{{a}}'", false, []quoteContext{contextANSI}},
+		{"after closing quote", "echo '{{a}}' {{b}}", false, []quoteContext{contextSingle, contextUnquoted}},
+		{"escaped quote", `echo \'{{a}}`, false, []quoteContext{contextUnquoted}},
+		{"escaped quote in double", `echo "\"{{a}}"`, false, []quoteContext{contextDouble}},
+		{"double inside single", `echo '"{{a}}'`, false, []quoteContext{contextSingle}},
+		{"single inside double", `echo "'{{a}}'"`, false, []quoteContext{contextDouble}},
+		{"substitution in double", `echo "$(cat {{a}})"`, false, []quoteContext{contextUnquoted}},
+		{"quotes in substitution", `echo "$(cat "{{a}}")"`, false, []quoteContext{contextDouble}},
+		{"after substitution", `echo "$(cat {{a}}) {{b}}"`, false, []quoteContext{contextUnquoted, contextDouble}},
+		{"parens in substitution", `echo "$(f() (x); {{a}})" {{b}}`, false, []quoteContext{contextUnquoted, contextUnquoted}},
+		{"backtick in double", "echo \"`{{a}}`\"", false, []quoteContext{contextBacktick}},
+		{"windows double", `echo "{{a}}"`, true, []quoteContext{contextDouble}},
+		{"windows single", `echo '{{a}}'`, true, []quoteContext{contextSingle}},
+		{"windows backslash", `dir "C:\{{a}}"`, true, []quoteContext{contextDouble}},
+		{"windows backtick", "echo `{{a}}", true, []quoteContext{contextUnquoted}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contexts := quoteContexts(tt.command, tt.windows)
+			matches := placeholderPattern.FindAllStringIndex(tt.command, -1)
+			if len(matches) != len(tt.want) {
+				t.Fatalf("found %d placeholders, want %d", len(matches), len(tt.want))
+			}
+			for i, match := range matches {
+				if got := contexts[match[0]]; got != tt.want[i] {
+					t.Errorf("placeholder %d: context %d, want %d", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	builtins := map[string]string{"host": "web1.example.com", "user": "deploy"}
+
+	tests := []struct {
+		name     string
+		command  string
+		osType   string
+		declared []models.SnippetParam
+		values   map[string]string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:    "safe value",
+			command: "tail -n {{lines:int}} /var/log/syslog",
+			values:  map[string]string{"lines": " 50 "},
+			want:    "tail -n 50 /var/log/syslog",
+		},
+		{
+			name:    "unquoted value with spaces",
+			command: "ls {{dir}}",
+			values:  map[string]string{"dir": "my files"},
+			want:    "ls 'my files'",
+		},
+		{
+			name:    "unquoted injection",
+			command: "ls {{dir}}",
+			values:  map[string]string{"dir": "x; rm -rf /"},
+			want:    "ls 'x; rm -rf /'",
+		},
+		{
+			name:    "single quoted",
+			command: "echo '{{msg}}'",
+			values:  map[string]string{"msg": "it's"},
+			want:    `echo 'it'\''s'`,
+		},
+		{
+			name:    "double quoted",
+			command: `echo "{{msg}}"`,
+			values:  map[string]string{"msg": "$HOME `id` \"x\""},
+			want:    "echo \"\\$HOME \\`id\\` \\\"x\\\"\"",
+		},
+		{
+			name:    "default",
+			command: "echo {{name=world}}",
+			want:    "echo world",
+		},
+		{
+			name:     "declared default",
+			command:  "echo {{name}}",
+			declared: []models.SnippetParam{{Name: "name", Default: "world"}},
+			want:     "echo world",
+		},
+		{
+			name:    "bool normalized",
+			command: "run --force={{force:bool}}",
+			values:  map[string]string{"force": "1"},
+			want:    "run --force=true",
+		},
+		{
+			name:    "builtins",
+			command: "ssh {{user}}@{{host}}",
+			want:    "ssh deploy@web1.example.com",
+		},
+		{
+			name:     "choice",
+			command:  "systemctl {{action}} nginx",
+			declared: []models.SnippetParam{{Name: "action", Choices: []string{"start", "stop"}}},
+			values:   map[string]string{"action": "stop"},
+			want:     "systemctl stop nginx",
+		},
+		{
+			name:     "not a choice",
+			command:  "systemctl {{action}} nginx",
+			declared: []models.SnippetParam{{Name: "action", Choices: []string{"start", "stop"}}},
+			values:   map[string]string{"action": "restart"},
+			wantErr:  true,
+		},
+		{
+			name:    "missing value",
+			command: "echo {{name}}",
+			wantErr: true,
+		},
+		{
+			name:    "not an integer",
+			command: "tail -n {{lines:int}} log",
+			values:  map[string]string{"lines": "ten"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown built-in",
+			command: "echo {{connection.secret}}",
+			wantErr: true,
+		},
+		{
+			name:    "backticks refused",
+			command: "echo `cat {{file}}`",
+			values:  map[string]string{"file": "a"},
+			wantErr: true,
+		},
+		{
+			name:    "ansi string refused",
+			command: "echo $'{{msg}}'",
+			values:  map[string]string{"msg": "a"},
+			wantErr: true,
+		},
+		{
+			name:    "windows safe value",
+			command: "dir {{path}}",
+			osType:  "windows",
+			values:  map[string]string{"path": `C:/Users`},
+			want:    "dir C:/Users",
+		},
+		{
+			name:    "windows quoted",
+			command: "dir {{path}}",
+			osType:  "windows",
+			values:  map[string]string{"path": "My Documents"},
+			want:    `dir "My Documents"`,
+		},
+		{
+			name:    "windows inside double quotes",
+			command: `dir "{{path}}"`,
+			osType:  "windows",
+			values:  map[string]string{"path": "My Documents"},
+			want:    `dir "My Documents"`,
+		},
+		{
+			name:    "windows variable refused",
+			command: "echo {{msg}}",
+			osType:  "windows",
+			values:  map[string]string{"msg": "%PATH%"},
+			wantErr: true,
+		},
+		{
+			name:    "windows single quotes refused",
+			command: "echo '{{msg}}'",
+			osType:  "windows",
+			values:  map[string]string{"msg": "a b"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Render(tt.command, tt.osType, tt.declared, tt.values, builtins)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Render() = %q, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Render() error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderQuotesForShell(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no POSIX shell available")
+	}
+
+	values := []string{
+		"plain",
+		"two words",
+		"it's",
+		`back\slash`,
+		"$HOME",
+		"`id`",
+		`"double"`,
+		"$(id)",
+		"semi; colon",
+		"new\nline",
+		"*",
+	}
+	commands := []string{
+		"printf %s {{v}}",
+		"printf %s '{{v}}'",
+		`printf %s "{{v}}"`,
+		`printf %s "$(printf %s {{v}})"`,
+	}
+
+	for _, command := range commands {
+		for _, value := range values {
+			rendered, err := Render(command, "linux", nil, map[string]string{"v": value}, nil)
+			if err != nil {
+				t.Fatalf("Render(%q, %q) error: %v", command, value, err)
+			}
+			output, err := exec.Command(sh, "-c", rendered).Output()
+			if err != nil {
+				t.Fatalf("%s: %v", rendered, err)
+			}
+			if got := string(output); got != value {
+				t.Errorf("%s printed %q, want %q", strings.ReplaceAll(rendered, "\n", `\n`), got, value)
+			}
+		}
+	}
+}
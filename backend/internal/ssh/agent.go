@@ -0,0 +1,47 @@
+package ssh
+
+import (
+	"fmt"
+	"freessh-backend/internal/ssh/auth"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// RequestAgentForwarding forwards the local ssh-agent to session when the
+// connection has ForwardAgent enabled. The channel handler is registered once
+// per underlying transport.
+func (c *Client) RequestAgentForwarding(session *ssh.Session) error {
+	if !c.config.ForwardAgent {
+		return nil
+	}
+	if c.sshClient == nil {
+		return fmt.Errorf("not connected")
+	}
+
+	c.agentMu.Lock()
+	defer c.agentMu.Unlock()
+
+	if c.agentForwarded != c.sshClient {
+		keyring, conn, err := auth.NewAgentClient()
+		if err != nil {
+			return err
+		}
+		if err := agent.ForwardToAgent(c.sshClient, keyring); err != nil {
+			conn.Close()
+			return fmt.Errorf("failed to forward agent: %w", err)
+		}
+		// Forwarded requests use the agent for as long as the transport lives
+		go func(transport *ssh.Client) {
+			transport.Wait()
+			conn.Close()
+		}(c.sshClient)
+		c.agentForwarded = c.sshClient
+	}
+
+	if err := agent.RequestAgentForwarding(session); err != nil {
+		return fmt.Errorf("agent forwarding request failed: %w", err)
+	}
+
+	return nil
+}
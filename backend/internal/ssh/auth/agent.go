@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"runtime"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// windowsAgentPipe is the named pipe used by the Windows OpenSSH agent service.
+const windowsAgentPipe = `\\.\pipe\openssh-ssh-agent`
+
+type AgentAuth struct {
+	attempt func()
+	conn    io.Closer
+}
+
+func (a *AgentAuth) GetAuthMethod() (ssh.AuthMethod, error) {
+	client, conn, err := NewAgentClient()
+	if err != nil {
+		return nil, err
+	}
+	a.conn = conn
+
+	return ssh.PublicKeysCallback(func() ([]ssh.Signer, error) {
+		a.attempt()
+		return client.Signers()
+	}), nil
+}
+
+// Close releases the agent connection once the handshake no longer needs it.
+func (a *AgentAuth) Close() error {
+	if a.conn == nil {
+		return nil
+	}
+	return a.conn.Close()
+}
+
+// NewAgentClient connects to the system ssh-agent via SSH_AUTH_SOCK, or the
+// OpenSSH agent pipe on Windows. The caller closes the returned connection
+// when done with the agent.
+func NewAgentClient() (agent.ExtendedAgent, io.Closer, error) {
+	socket := os.Getenv("SSH_AUTH_SOCK")
+
+	if socket == "" && runtime.GOOS == "windows" {
+		pipe, err := os.OpenFile(windowsAgentPipe, os.O_RDWR, 0)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to connect to ssh-agent: %w", err)
+		}
+		return agent.NewClient(pipe), pipe, nil
+	}
+
+	if socket == "" {
+		return nil, nil, fmt.Errorf("ssh-agent not available: SSH_AUTH_SOCK is not set")
+	}
+
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to ssh-agent: %w", err)
+	}
+
+	return agent.NewClient(conn), conn, nil
+}
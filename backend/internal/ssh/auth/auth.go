@@ -1,7 +1,11 @@
 package auth
 
 import (
+	"errors"
+	"fmt"
 	"freessh-backend/internal/models"
+	"io"
+	"log"
 
 	"golang.org/x/crypto/ssh"
 )
@@ -10,16 +14,77 @@ type Provider interface {
 	GetAuthMethod() (ssh.AuthMethod, error)
 }
 
-func NewProvider(config models.ConnectionConfig) Provider {
-	switch config.AuthMethod {
+// Options carries the hooks shared by every provider. Both fields may be nil.
+type Options struct {
+	// Challenge relays prompts to the user.
+	Challenge ssh.KeyboardInteractiveChallenge
+	// OnAttempt is called each time the handshake tries a method.
+	OnAttempt func(models.AuthMethod)
+}
+
+func NewProvider(method models.AuthMethod, config models.ConnectionConfig, opts Options) Provider {
+	attempt := func() {
+		if opts.OnAttempt != nil {
+			opts.OnAttempt(method)
+		}
+	}
+
+	switch method {
 	case models.AuthPassword:
-		return &PasswordAuth{password: config.Password}
+		return &PasswordAuth{password: config.Password, challenge: opts.Challenge, attempt: attempt}
 	case models.AuthPublicKey:
 		return &PublicKeyAuth{
-			privateKey: config.PrivateKey,
-			passphrase: config.Passphrase,
+			privateKey:  config.PrivateKey,
+			passphrase:  config.Passphrase,
+			certificate: config.Certificate,
+			attempt:     attempt,
 		}
+	case models.AuthAgent:
+		return &AgentAuth{attempt: attempt}
+	case models.AuthKeyboardInteractive:
+		return &KeyboardInteractiveAuth{challenge: opts.Challenge, attempt: attempt}
 	default:
-		return &PasswordAuth{password: config.Password}
+		return &PasswordAuth{password: config.Password, challenge: opts.Challenge, attempt: attempt}
+	}
+}
+
+// NewMethods builds the ordered auth methods for config. When a challenge is
+// available, keyboard-interactive is appended so hosts that require a second
+// factor (e.g. "AuthenticationMethods publickey,keyboard-interactive") work.
+// A method that cannot be built, such as the agent when no agent is running,
+// is logged and skipped; an error is returned only when none is left.
+// The returned func releases resources the methods hold, such as the agent
+// connection, and must be called once the handshake is over.
+func NewMethods(config models.ConnectionConfig, opts Options) ([]ssh.AuthMethod, func(), error) {
+	configured := config.EffectiveAuthMethods()
+	if opts.Challenge != nil && !config.UsesAuthMethod(models.AuthKeyboardInteractive) {
+		configured = append(configured, models.AuthKeyboardInteractive)
+	}
+
+	methods := make([]ssh.AuthMethod, 0, len(configured))
+	var closers []io.Closer
+	release := func() {
+		for _, closer := range closers {
+			closer.Close()
+		}
+	}
+
+	var failures []error
+	for _, method := range configured {
+		provider := NewProvider(method, config, opts)
+		authMethod, err := provider.GetAuthMethod()
+		if err != nil {
+			log.Printf("Skipping %s auth for %s: %v", method, config.Host, err)
+			failures = append(failures, fmt.Errorf("%s: %w", method, err))
+			continue
+		}
+		if closer, ok := provider.(io.Closer); ok {
+			closers = append(closers, closer)
+		}
+		methods = append(methods, authMethod)
+	}
+	if len(methods) == 0 {
+		return nil, nil, errors.Join(failures...)
 	}
+	return methods, release, nil
 }
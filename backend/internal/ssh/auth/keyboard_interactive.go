@@ -0,0 +1,23 @@
+package auth
+
+import (
+	"fmt"
+
+	"golang.org/x/crypto/ssh"
+)
+
+type KeyboardInteractiveAuth struct {
+	challenge ssh.KeyboardInteractiveChallenge
+	attempt   func()
+}
+
+func (k *KeyboardInteractiveAuth) GetAuthMethod() (ssh.AuthMethod, error) {
+	if k.challenge == nil {
+		return nil, fmt.Errorf("keyboard-interactive auth requires a prompt handler")
+	}
+
+	return ssh.KeyboardInteractive(func(name, instruction string, questions []string, echos []bool) ([]string, error) {
+		k.attempt()
+		return k.challenge(name, instruction, questions, echos)
+	}), nil
+}
@@ -1,11 +1,32 @@
 package auth
 
-import "golang.org/x/crypto/ssh"
+import (
+	"fmt"
+
+	"golang.org/x/crypto/ssh"
+)
 
 type PasswordAuth struct {
-	password string
+	password  string
+	challenge ssh.KeyboardInteractiveChallenge
+	attempt   func()
 }
 
 func (p *PasswordAuth) GetAuthMethod() (ssh.AuthMethod, error) {
-	return ssh.Password(p.password), nil
+	return ssh.PasswordCallback(func() (string, error) {
+		p.attempt()
+		if p.password != "" || p.challenge == nil {
+			return p.password, nil
+		}
+
+		// No stored password: ask for it through the interactive prompt instead.
+		answers, err := p.challenge("", "", []string{"Password: "}, []bool{false})
+		if err != nil {
+			return "", err
+		}
+		if len(answers) != 1 {
+			return "", fmt.Errorf("password prompt returned %d answers", len(answers))
+		}
+		return answers[0], nil
+	}), nil
 }
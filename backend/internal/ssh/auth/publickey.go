@@ -1,14 +1,17 @@
 package auth
 
 import (
+	"bytes"
 	"fmt"
 
 	"golang.org/x/crypto/ssh"
 )
 
 type PublicKeyAuth struct {
-	privateKey string
-	passphrase string
+	privateKey  string
+	passphrase  string
+	certificate string
+	attempt     func()
 }
 
 func (p *PublicKeyAuth) GetAuthMethod() (ssh.AuthMethod, error) {
@@ -25,5 +28,36 @@ func (p *PublicKeyAuth) GetAuthMethod() (ssh.AuthMethod, error) {
 		return nil, fmt.Errorf("failed to parse private key: %w", err)
 	}
 
-	return ssh.PublicKeys(signer), nil
+	signers := []ssh.Signer{signer}
+	if p.certificate != "" {
+		certSigner, err := newCertSigner(p.certificate, signer)
+		if err != nil {
+			return nil, err
+		}
+		// Offer the certificate first and the plain key as a fallback, as
+		// OpenSSH does.
+		signers = []ssh.Signer{certSigner, signer}
+	}
+
+	return ssh.PublicKeysCallback(func() ([]ssh.Signer, error) {
+		p.attempt()
+		return signers, nil
+	}), nil
+}
+
+func newCertSigner(certificate string, signer ssh.Signer) (ssh.Signer, error) {
+	pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(certificate))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %w", err)
+	}
+
+	cert, ok := pub.(*ssh.Certificate)
+	if !ok {
+		return nil, fmt.Errorf("attached key is not a certificate")
+	}
+	if !bytes.Equal(cert.Key.Marshal(), signer.PublicKey().Marshal()) {
+		return nil, fmt.Errorf("certificate does not match private key")
+	}
+
+	return ssh.NewCertSigner(cert, signer)
 }
@@ -1,37 +1,73 @@
 package ssh
 
 import (
+	"errors"
 	"fmt"
 	"freessh-backend/internal/config"
 	"freessh-backend/internal/models"
-	"freessh-backend/internal/reconnect"
+	"freessh-backend/internal/proxy"
 	"freessh-backend/internal/ssh/auth"
 	"net"
+	"os"
+	"strconv"
 	"sync"
 	"time"
 
 	"golang.org/x/crypto/ssh"
 )
 
+var errPingTimeout = errors.New("keepalive timed out")
+
 type Client struct {
-	config           models.ConnectionConfig
-	sshClient        *ssh.Client
-	sshConfig        *ssh.ClientConfig
-	stopKeepAlive    chan struct{}
-	keepAliveMu      sync.Mutex
-	keepAliveRunning bool
-	reconnectEnabled bool
-	onReconnecting   func(attempt int)
-	onReconnected    func()
-	onReconnectFailed func(err error)
-	hostKeyCallback  ssh.HostKeyCallback
+	config             models.ConnectionConfig
+	sshClient          *ssh.Client
+	sshConfig          *ssh.ClientConfig
+	stopKeepAlive      chan struct{}
+	keepAliveMu        sync.Mutex
+	keepAliveRunning   bool
+	reconnectEnabled   bool
+	onConnectionLost   func(err error)
+	hostKeyCallback    ssh.HostKeyCallback
+	authPrompt         func(*models.AuthPrompt) ([]string, error)
+	authMethodUsed     models.AuthMethod
+	hostKeyAlgorithms  []string
+	cryptoPolicy       *models.CryptoPolicy
+	negotiated         *models.NegotiatedAlgorithms
+	proxy              *models.ProxyConfig
+	timeout            time.Duration
+	keepAliveInterval  time.Duration
+	keepAliveMaxMissed int
+	onHostKeys         func(keys []ssh.PublicKey) error
+	jumpHost           *Client
+	agentMu            sync.Mutex
+	agentForwarded     *ssh.Client
+	x11Mu              sync.Mutex
+	x11Forwarded       *ssh.Client
+	x11                *x11Forwarder
 }
 
 func NewClient(connConfig models.ConnectionConfig) *Client {
 	return &Client{
-		config:           connConfig,
-		reconnectEnabled: true,
-		hostKeyCallback:  ssh.InsecureIgnoreHostKey(), // Default to insecure for now
+		config:             connConfig,
+		reconnectEnabled:   true,
+		hostKeyCallback:    ssh.InsecureIgnoreHostKey(), // Default to insecure for now
+		timeout:            config.DefaultTimeout,
+		keepAliveInterval:  config.DefaultKeepAlive,
+		keepAliveMaxMissed: config.DefaultKeepAliveMaxMissed,
+	}
+}
+
+// SetNetworkOptions applies the dial timeout and keepalive settings of opts.
+// Unset fields keep the defaults.
+func (c *Client) SetNetworkOptions(opts models.NetworkOptions) {
+	if opts.ConnectTimeout > 0 {
+		c.timeout = time.Duration(opts.ConnectTimeout) * time.Second
+	}
+	if opts.KeepAliveInterval > 0 {
+		c.keepAliveInterval = time.Duration(opts.KeepAliveInterval) * time.Second
+	}
+	if opts.KeepAliveMaxMissed > 0 {
+		c.keepAliveMaxMissed = opts.KeepAliveMaxMissed
 	}
 }
 
@@ -39,10 +75,51 @@ func (c *Client) SetHostKeyCallback(callback ssh.HostKeyCallback) {
 	c.hostKeyCallback = callback
 }
 
-func (c *Client) SetReconnectCallbacks(onReconnecting func(int), onReconnected func(), onFailed func(error)) {
-	c.onReconnecting = onReconnecting
-	c.onReconnected = onReconnected
-	c.onReconnectFailed = onFailed
+// SetHostKeyAlgorithms sets the host key algorithms to negotiate, in order of
+// preference. Nil keeps the library defaults.
+func (c *Client) SetHostKeyAlgorithms(algorithms []string) {
+	c.hostKeyAlgorithms = algorithms
+}
+
+// SetCryptoPolicy restricts the ciphers, key exchanges, MACs and host key
+// algorithms offered during the handshake. Nil keeps the library defaults.
+func (c *Client) SetCryptoPolicy(policy *models.CryptoPolicy) {
+	c.cryptoPolicy = policy
+}
+
+// NegotiatedAlgorithms returns the algorithms agreed in the last handshake.
+func (c *Client) NegotiatedAlgorithms() *models.NegotiatedAlgorithms {
+	return c.negotiated
+}
+
+// SetConnectionLostCallback registers callback to run, in its own goroutine,
+// when a keepalive fails. The owner is expected to call Reconnect.
+func (c *Client) SetConnectionLostCallback(callback func(err error)) {
+	c.onConnectionLost = callback
+}
+
+// SetAuthPromptCallback enables keyboard-interactive authentication. Server
+// challenges, and the password prompt when no password is stored, are passed
+// to callback, which returns one answer per question.
+func (c *Client) SetAuthPromptCallback(callback func(*models.AuthPrompt) ([]string, error)) {
+	c.authPrompt = callback
+}
+
+// AuthMethodUsed returns the auth method that completed the last handshake.
+func (c *Client) AuthMethodUsed() models.AuthMethod {
+	return c.authMethodUsed
+}
+
+// SetProxy dials the connection (or the first jump host) through proxy.
+// Nil connects directly.
+func (c *Client) SetProxy(proxy *models.ProxyConfig) {
+	c.proxy = proxy
+}
+
+// SetJumpHost routes the connection through hop, which is connected on demand
+// and may itself have a jump host, forming a ProxyJump chain.
+func (c *Client) SetJumpHost(hop *Client) {
+	c.jumpHost = hop
 }
 
 func (c *Client) DisableReconnect() {
@@ -50,39 +127,269 @@ func (c *Client) DisableReconnect() {
 }
 
 func (c *Client) Connect() error {
-	authProvider := auth.NewProvider(c.config)
-	authMethod, err := authProvider.GetAuthMethod()
+	// The last method the handshake tries is the one that authenticated.
+	var lastAttempt models.AuthMethod
+	deadline := &handshakeDeadline{timeout: c.timeout}
+	authMethods, releaseAuth, err := auth.NewMethods(c.config, auth.Options{
+		Challenge: c.keyboardInteractiveChallenge(deadline),
+		OnAttempt: func(method models.AuthMethod) { lastAttempt = method },
+	})
 	if err != nil {
 		return fmt.Errorf("auth failed: %w", err)
 	}
+	defer releaseAuth()
+
+	algorithms, err := CryptoAlgorithms(c.cryptoPolicy)
+	if err != nil {
+		return fmt.Errorf("invalid crypto policy: %w", err)
+	}
 
+	// Remember the verified host key for the hostkeys-00 extension
+	var presentedKey ssh.PublicKey
+	hostKeyCallback := c.hostKeyCallback
 	c.sshConfig = &ssh.ClientConfig{
-		User:            c.config.Username,
-		Auth:            []ssh.AuthMethod{authMethod},
-		HostKeyCallback: c.hostKeyCallback,
-		Timeout:         config.DefaultTimeout,
+		User: c.config.Username,
+		Auth: authMethods,
+		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			// Unknown keys wait on the user to approve them
+			resume := deadline.suspend()
+			err := hostKeyCallback(hostname, remote, key)
+			resume()
+			if err != nil {
+				return err
+			}
+			presentedKey = key
+			return nil
+		},
+		HostKeyAlgorithms: orderHostKeyAlgorithms(c.hostKeyAlgorithms, algorithms.HostKeys),
+		Timeout:           c.timeout,
 	}
+	c.sshConfig.KeyExchanges = algorithms.KeyExchanges
+	c.sshConfig.Ciphers = algorithms.Ciphers
+	c.sshConfig.MACs = algorithms.MACs
 
-	addr := fmt.Sprintf("%s:%d", c.config.Host, c.config.Port)
-	conn, err := net.DialTimeout("tcp", addr, config.DefaultTimeout)
+	addr := net.JoinHostPort(c.config.Host, strconv.Itoa(c.config.Port))
+	conn, err := c.dial(addr)
 	if err != nil {
 		return fmt.Errorf("connection failed: %w", err)
 	}
 
+	// The timeout covers the handshake too, so a silent server cannot hang it
+	deadline.arm(conn)
 	sshConn, chans, reqs, err := ssh.NewClientConn(conn, addr, c.sshConfig)
 	if err != nil {
 		conn.Close()
+		if deadline.timedOut() {
+			err = os.ErrDeadlineExceeded
+		}
 		return fmt.Errorf("ssh handshake failed: %w", err)
 	}
+	deadline.clear()
 
+	reqs = c.interceptHostKeys(sshConn, reqs, presentedKey)
 	c.sshClient = ssh.NewClient(sshConn, chans, reqs)
-	
-	c.startKeepAlive()
-	
+	c.authMethodUsed = lastAttempt
+	c.negotiated = negotiatedAlgorithms(sshConn)
+
+	c.startKeepAlive(c.sshClient)
+
 	return nil
 }
 
-func (c *Client) startKeepAlive() {
+// handshakeDeadline bounds the handshake with the connect timeout, except
+// while it waits on the user for a host key decision or an auth prompt.
+// Channels through a jump host do not support deadlines, so for them the
+// deadline is kept with a timer that closes the connection when it expires.
+type handshakeDeadline struct {
+	mu      sync.Mutex
+	conn    net.Conn
+	timeout time.Duration
+	timer   *time.Timer
+	// closeOnExpiry is set once conn has refused a deadline.
+	closeOnExpiry bool
+	expired       bool
+}
+
+func (d *handshakeDeadline) arm(conn net.Conn) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.conn = conn
+	d.set(conn, time.Now().Add(d.timeout))
+}
+
+func (d *handshakeDeadline) clear() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.set(d.conn, time.Time{})
+	d.conn = nil
+}
+
+// suspend lifts the deadline until the returned func re-arms it with a fresh
+// timeout.
+func (d *handshakeDeadline) suspend() (resume func()) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	conn := d.conn
+	if conn == nil {
+		return func() {}
+	}
+	d.set(conn, time.Time{})
+	return func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if d.conn == conn {
+			d.set(conn, time.Now().Add(d.timeout))
+		}
+	}
+}
+
+// timedOut reports whether the deadline closed the connection.
+func (d *handshakeDeadline) timedOut() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.expired
+}
+
+// set moves the deadline of conn to t; a zero t clears it. The caller holds
+// d.mu.
+func (d *handshakeDeadline) set(conn net.Conn, t time.Time) {
+	if d.timeout <= 0 {
+		return
+	}
+	if !d.closeOnExpiry {
+		if err := conn.SetDeadline(t); err == nil {
+			return
+		}
+		d.closeOnExpiry = true
+	}
+
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	if !t.IsZero() {
+		var timer *time.Timer
+		timer = time.AfterFunc(time.Until(t), func() {
+			d.mu.Lock()
+			defer d.mu.Unlock()
+			// A deadline moved while this one fired no longer applies
+			if d.timer != timer {
+				return
+			}
+			d.expired = true
+			conn.Close()
+		})
+		d.timer = timer
+	}
+}
+
+func (c *Client) keyboardInteractiveChallenge(deadline *handshakeDeadline) ssh.KeyboardInteractiveChallenge {
+	if c.authPrompt == nil {
+		return nil
+	}
+
+	return func(name, instruction string, questions []string, echos []bool) ([]string, error) {
+		// Servers may send an empty round that only carries an instruction.
+		if len(questions) == 0 {
+			return []string{}, nil
+		}
+
+		prompt := &models.AuthPrompt{
+			Hostname:    c.config.Host,
+			Port:        c.config.Port,
+			Username:    c.config.Username,
+			Name:        name,
+			Instruction: instruction,
+			Questions:   make([]models.AuthPromptQuestion, len(questions)),
+		}
+		for i, question := range questions {
+			prompt.Questions[i] = models.AuthPromptQuestion{Prompt: question, Echo: echos[i]}
+		}
+
+		resume := deadline.suspend()
+		answers, err := c.authPrompt(prompt)
+		resume()
+		if err != nil {
+			return nil, err
+		}
+		if len(answers) != len(questions) {
+			return nil, fmt.Errorf("expected %d answers, got %d", len(questions), len(answers))
+		}
+		return answers, nil
+	}
+}
+
+func (c *Client) dial(addr string) (net.Conn, error) {
+	if c.jumpHost == nil {
+		if c.config.ProxyCommand != "" {
+			return proxy.DialCommand(proxy.ExpandCommand(c.config.ProxyCommand, c.config.Host, c.config.Port, c.config.Username))
+		}
+		return proxy.Dial(c.proxy, addr, c.timeout)
+	}
+
+	if !c.jumpHost.IsConnected() {
+		if err := c.jumpHost.Connect(); err != nil {
+			return nil, fmt.Errorf("jump host %s: %w", c.jumpHost.config.Host, err)
+		}
+	}
+
+	// Open a direct-tcpip channel through the previous hop. The hop only
+	// answers once it has reached addr, so a stuck hop must not hang us.
+	hop := c.jumpHost.sshClient
+	return dialWithin(c.timeout, func() (net.Conn, error) {
+		return hop.Dial("tcp", addr)
+	})
+}
+
+// dialWithin runs dial, giving up after timeout. A connection that is made
+// after all is closed.
+func dialWithin(timeout time.Duration, dial func() (net.Conn, error)) (net.Conn, error) {
+	if timeout <= 0 {
+		return dial()
+	}
+
+	type result struct {
+		conn net.Conn
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		conn, err := dial()
+		done <- result{conn, err}
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case r := <-done:
+		return r.conn, r.err
+	case <-timer.C:
+		go func() {
+			if r := <-done; r.conn != nil {
+				r.conn.Close()
+			}
+		}()
+		return nil, fmt.Errorf("dial through jump host: %w", os.ErrDeadlineExceeded)
+	}
+}
+
+// closeTransport closes the SSH connection and every jump host behind it so
+// the next Connect rebuilds the whole chain.
+func (c *Client) closeTransport() {
+	if c.sshClient != nil {
+		c.sshClient.Close()
+		c.sshClient = nil
+	}
+	if c.jumpHost != nil {
+		c.jumpHost.stopKeepAliveRoutine()
+		c.jumpHost.closeTransport()
+	}
+}
+
+// startKeepAlive watches sshClient, the transport Connect just set up. The
+// routine is handed the client rather than reading c.sshClient, which
+// Reconnect replaces while an old routine may still be running.
+func (c *Client) startKeepAlive(sshClient *ssh.Client) {
 	c.keepAliveMu.Lock()
 	defer c.keepAliveMu.Unlock()
 
@@ -93,37 +400,73 @@ func (c *Client) startKeepAlive() {
 	c.stopKeepAlive = make(chan struct{})
 	c.keepAliveRunning = true
 
-	go c.keepAlive()
+	go c.keepAlive(sshClient, c.stopKeepAlive)
 }
 
-func (c *Client) keepAlive() {
-	ticker := time.NewTicker(config.DefaultKeepAlive)
+func (c *Client) keepAlive(sshClient *ssh.Client, stop <-chan struct{}) {
+	ticker := time.NewTicker(c.keepAliveInterval)
 	defer ticker.Stop()
-	defer func() {
-		c.keepAliveMu.Lock()
-		c.keepAliveRunning = false
-		c.keepAliveMu.Unlock()
-	}()
 
+	missed := 0
 	for {
 		select {
 		case <-ticker.C:
-			if c.sshClient != nil {
-				_, _, err := c.sshClient.SendRequest("keepalive@openssh.com", true, nil)
-				if err != nil {
-					// Connection lost, attempt reconnect
-					if c.reconnectEnabled {
-						c.attemptReconnect()
-					}
-					return
+			err := ping(sshClient, c.keepAliveInterval)
+			if err == nil {
+				missed = 0
+				continue
+			}
+			// A slow link may still answer; a closed transport never will
+			if errors.Is(err, errPingTimeout) {
+				missed++
+				if missed < c.keepAliveMaxMissed {
+					continue
 				}
 			}
-		case <-c.stopKeepAlive:
+
+			// Mark the routine stopped first so Reconnect can start a new one,
+			// unless this transport has already been replaced
+			c.keepAliveMu.Lock()
+			current := c.stopKeepAlive == stop
+			if current {
+				c.keepAliveRunning = false
+			}
+			c.keepAliveMu.Unlock()
+
+			if current && c.reconnectEnabled && c.onConnectionLost != nil {
+				go c.onConnectionLost(err)
+			}
+			return
+		case <-stop:
 			return
 		}
 	}
 }
 
+// Ping sends a keepalive request and waits up to timeout for the reply.
+func (c *Client) Ping(timeout time.Duration) error {
+	sshClient := c.sshClient
+	if sshClient == nil {
+		return fmt.Errorf("not connected")
+	}
+	return ping(sshClient, timeout)
+}
+
+func ping(sshClient *ssh.Client, timeout time.Duration) error {
+	result := make(chan error, 1)
+	go func() {
+		_, _, err := sshClient.SendRequest("keepalive@openssh.com", true, nil)
+		result <- err
+	}()
+
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(timeout):
+		return errPingTimeout
+	}
+}
+
 func (c *Client) stopKeepAliveRoutine() {
 	c.keepAliveMu.Lock()
 	defer c.keepAliveMu.Unlock()
@@ -134,50 +477,24 @@ func (c *Client) stopKeepAliveRoutine() {
 	}
 }
 
-func (c *Client) attemptReconnect() {
-	backoff := reconnect.NewBackoff(reconnect.DefaultConfig())
-
-	for {
-		delay, ok := backoff.Next()
-		if !ok {
-			// Max attempts reached
-			if c.onReconnectFailed != nil {
-				c.onReconnectFailed(fmt.Errorf("max reconnect attempts reached"))
-			}
-			return
-		}
-
-		if c.onReconnecting != nil {
-			c.onReconnecting(backoff.Attempt())
-		}
-
-		time.Sleep(delay)
-
-		// Close old connection
-		if c.sshClient != nil {
-			c.sshClient.Close()
-			c.sshClient = nil
-		}
-
-		// Attempt reconnection
-		err := c.Connect()
-		if err == nil {
-			// Reconnection successful
-			backoff.Reset()
-			if c.onReconnected != nil {
-				c.onReconnected()
-			}
-			return
-		}
-	}
+// Reconnect drops the current transport, including any jump hosts, and runs
+// the handshake again. Channels opened on the old connection are not restored.
+func (c *Client) Reconnect() error {
+	c.stopKeepAliveRoutine()
+	c.closeTransport()
+	return c.Connect()
 }
 
 func (c *Client) Disconnect() error {
 	c.stopKeepAliveRoutine()
+	var err error
 	if c.sshClient != nil {
-		return c.sshClient.Close()
+		err = c.sshClient.Close()
 	}
-	return nil
+	if c.jumpHost != nil {
+		c.jumpHost.Disconnect()
+	}
+	return err
 }
 
 func (c *Client) NewSession() (*ssh.Session, error) {
@@ -0,0 +1,138 @@
+package ssh
+
+import (
+	"errors"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+// noDeadlineConn refuses deadlines like a channel through a jump host.
+type noDeadlineConn struct {
+	net.Conn
+}
+
+func (noDeadlineConn) SetDeadline(time.Time) error {
+	return errors.New("deadline not supported")
+}
+
+func TestHandshakeDeadline(t *testing.T) {
+	tests := []struct {
+		name string
+		wrap func(net.Conn) net.Conn
+	}{
+		{"conn with deadlines", func(c net.Conn) net.Conn { return c }},
+		{"conn without deadlines", func(c net.Conn) net.Conn { return noDeadlineConn{c} }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Run("expires", func(t *testing.T) {
+				client, server := net.Pipe()
+				defer server.Close()
+				conn := tt.wrap(client)
+
+				deadline := &handshakeDeadline{timeout: 50 * time.Millisecond}
+				deadline.arm(conn)
+
+				read := make(chan error, 1)
+				go func() {
+					_, err := conn.Read(make([]byte, 1))
+					read <- err
+				}()
+				select {
+				case err := <-read:
+					if err == nil {
+						t.Fatal("read succeeded on a silent peer")
+					}
+				case <-time.After(2 * time.Second):
+					t.Fatal("deadline did not interrupt the read")
+				}
+			})
+
+			t.Run("cleared", func(t *testing.T) {
+				client, server := net.Pipe()
+				defer server.Close()
+				conn := tt.wrap(client)
+				defer conn.Close()
+
+				deadline := &handshakeDeadline{timeout: 50 * time.Millisecond}
+				deadline.arm(conn)
+				deadline.clear()
+				time.Sleep(100 * time.Millisecond)
+
+				if deadline.timedOut() {
+					t.Fatal("cleared deadline expired")
+				}
+				go server.Write([]byte{1})
+				if _, err := conn.Read(make([]byte, 1)); err != nil {
+					t.Fatalf("read after clear: %v", err)
+				}
+			})
+
+			t.Run("suspended", func(t *testing.T) {
+				client, server := net.Pipe()
+				defer server.Close()
+				conn := tt.wrap(client)
+				defer conn.Close()
+
+				deadline := &handshakeDeadline{timeout: 50 * time.Millisecond}
+				deadline.arm(conn)
+				resume := deadline.suspend()
+				time.Sleep(100 * time.Millisecond)
+				resume()
+				deadline.clear()
+
+				go server.Write([]byte{1})
+				if _, err := conn.Read(make([]byte, 1)); err != nil {
+					t.Fatalf("read after a suspended deadline: %v", err)
+				}
+			})
+		})
+	}
+}
+
+func TestDialWithin(t *testing.T) {
+	t.Run("in time", func(t *testing.T) {
+		client, server := net.Pipe()
+		defer server.Close()
+
+		conn, err := dialWithin(time.Second, func() (net.Conn, error) { return client, nil })
+		if err != nil || conn != client {
+			t.Fatalf("dialWithin = %v, %v", conn, err)
+		}
+		conn.Close()
+	})
+
+	t.Run("stuck", func(t *testing.T) {
+		release := make(chan struct{})
+		closed := make(chan struct{})
+		client, server := net.Pipe()
+		defer server.Close()
+		go func() {
+			// The late connection must be closed
+			server.Read(make([]byte, 1))
+			close(closed)
+		}()
+
+		start := time.Now()
+		_, err := dialWithin(50*time.Millisecond, func() (net.Conn, error) {
+			<-release
+			return client, nil
+		})
+		if !errors.Is(err, os.ErrDeadlineExceeded) {
+			t.Fatalf("dialWithin error = %v, want a deadline error", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Fatalf("dialWithin took %v", elapsed)
+		}
+
+		close(release)
+		select {
+		case <-closed:
+		case <-time.After(2 * time.Second):
+			t.Fatal("connection made after the timeout was left open")
+		}
+	})
+}
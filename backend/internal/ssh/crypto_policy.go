@@ -0,0 +1,157 @@
+package ssh
+
+import (
+	"fmt"
+	"freessh-backend/internal/models"
+	"slices"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// cryptoPresets lists the algorithms of each preset in preference order. A
+// nil list keeps the library default for that category.
+var cryptoPresets = map[models.CryptoPreset]ssh.Algorithms{
+	models.CryptoPresetModern: {
+		KeyExchanges: []string{
+			ssh.KeyExchangeMLKEM768X25519,
+			ssh.KeyExchangeCurve25519,
+			ssh.KeyExchangeECDHP256,
+			ssh.KeyExchangeECDHP384,
+			ssh.KeyExchangeECDHP521,
+			ssh.KeyExchangeDH16SHA512,
+			ssh.KeyExchangeDHGEXSHA256,
+		},
+		Ciphers: []string{
+			ssh.CipherChaCha20Poly1305,
+			ssh.CipherAES256GCM,
+			ssh.CipherAES128GCM,
+			ssh.CipherAES256CTR,
+			ssh.CipherAES192CTR,
+			ssh.CipherAES128CTR,
+		},
+		MACs: []string{
+			ssh.HMACSHA256ETM,
+			ssh.HMACSHA512ETM,
+			ssh.HMACSHA256,
+			ssh.HMACSHA512,
+		},
+		HostKeys: []string{
+			ssh.CertAlgoED25519v01,
+			ssh.CertAlgoECDSA256v01,
+			ssh.CertAlgoECDSA384v01,
+			ssh.CertAlgoECDSA521v01,
+			ssh.CertAlgoRSASHA512v01,
+			ssh.CertAlgoRSASHA256v01,
+			ssh.KeyAlgoED25519,
+			ssh.KeyAlgoECDSA256,
+			ssh.KeyAlgoECDSA384,
+			ssh.KeyAlgoECDSA521,
+			ssh.KeyAlgoRSASHA512,
+			ssh.KeyAlgoRSASHA256,
+		},
+	},
+	models.CryptoPresetCompatible: {},
+	models.CryptoPresetLegacy: {
+		KeyExchanges: concat(ssh.SupportedAlgorithms().KeyExchanges, ssh.InsecureAlgorithms().KeyExchanges),
+		Ciphers:      concat(ssh.SupportedAlgorithms().Ciphers, ssh.InsecureAlgorithms().Ciphers),
+		MACs:         concat(ssh.SupportedAlgorithms().MACs, ssh.InsecureAlgorithms().MACs),
+		HostKeys:     concat(ssh.SupportedAlgorithms().HostKeys, ssh.InsecureAlgorithms().HostKeys),
+	},
+}
+
+// CryptoAlgorithms resolves policy into the algorithm lists to offer. Empty
+// lists mean the library defaults. Unknown presets and algorithm names are
+// rejected so a typo does not silently weaken or break the policy.
+func CryptoAlgorithms(policy *models.CryptoPolicy) (ssh.Algorithms, error) {
+	if policy.IsZero() {
+		return ssh.Algorithms{}, nil
+	}
+
+	preset := policy.Preset
+	if preset == "" {
+		preset = models.CryptoPresetCompatible
+	}
+	base, ok := cryptoPresets[preset]
+	if !ok {
+		return ssh.Algorithms{}, fmt.Errorf("unknown crypto preset: %s", policy.Preset)
+	}
+
+	known := cryptoPresets[models.CryptoPresetLegacy]
+	result := ssh.Algorithms{
+		KeyExchanges: slices.Clone(base.KeyExchanges),
+		Ciphers:      slices.Clone(base.Ciphers),
+		MACs:         slices.Clone(base.MACs),
+		HostKeys:     slices.Clone(base.HostKeys),
+	}
+
+	overrides := []struct {
+		kind  string
+		list  []string
+		known []string
+		dest  *[]string
+	}{
+		{"key exchange", policy.KeyExchanges, known.KeyExchanges, &result.KeyExchanges},
+		{"cipher", policy.Ciphers, known.Ciphers, &result.Ciphers},
+		{"MAC", policy.MACs, known.MACs, &result.MACs},
+		{"host key algorithm", policy.HostKeyAlgorithms, known.HostKeys, &result.HostKeys},
+	}
+	for _, override := range overrides {
+		if len(override.list) == 0 {
+			continue
+		}
+		for _, algo := range override.list {
+			if !slices.Contains(override.known, algo) {
+				return ssh.Algorithms{}, fmt.Errorf("unsupported %s: %s", override.kind, algo)
+			}
+		}
+		*override.dest = slices.Clone(override.list)
+	}
+
+	return result, nil
+}
+
+// orderHostKeyAlgorithms keeps the algorithms allowed by policy, ordered as
+// in preferred first. Nil allowed means the preferred list is used as is.
+func orderHostKeyAlgorithms(preferred, allowed []string) []string {
+	if allowed == nil {
+		return preferred
+	}
+
+	ordered := make([]string, 0, len(allowed))
+	for _, algo := range preferred {
+		if slices.Contains(allowed, algo) {
+			ordered = append(ordered, algo)
+		}
+	}
+	for _, algo := range allowed {
+		if !slices.Contains(ordered, algo) {
+			ordered = append(ordered, algo)
+		}
+	}
+	return ordered
+}
+
+func negotiatedAlgorithms(conn ssh.Conn) *models.NegotiatedAlgorithms {
+	meta, ok := conn.(ssh.AlgorithmsConnMetadata)
+	if !ok {
+		return nil
+	}
+
+	algorithms := meta.Algorithms()
+	return &models.NegotiatedAlgorithms{
+		KeyExchange:          algorithms.KeyExchange,
+		HostKey:              algorithms.HostKey,
+		CipherClientToServer: algorithms.Write.Cipher,
+		CipherServerToClient: algorithms.Read.Cipher,
+		MACClientToServer:    algorithms.Write.MAC,
+		MACServerToClient:    algorithms.Read.MAC,
+	}
+}
+
+func concat(lists ...[]string) []string {
+	var result []string
+	for _, list := range lists {
+		result = append(result, list...)
+	}
+	return result
+}
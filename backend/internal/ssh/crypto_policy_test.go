@@ -0,0 +1,176 @@
+package ssh
+
+import (
+	"freessh-backend/internal/models"
+	"slices"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestCryptoAlgorithms(t *testing.T) {
+	modern := cryptoPresets[models.CryptoPresetModern]
+	legacy := cryptoPresets[models.CryptoPresetLegacy]
+	insecureCipher := ssh.InsecureAlgorithms().Ciphers[0]
+
+	tests := []struct {
+		name    string
+		policy  *models.CryptoPolicy
+		want    ssh.Algorithms
+		wantErr string
+	}{
+		{name: "nil", policy: nil},
+		{name: "zero", policy: &models.CryptoPolicy{}},
+		{name: "compatible", policy: &models.CryptoPolicy{Preset: models.CryptoPresetCompatible}},
+		{name: "modern", policy: &models.CryptoPolicy{Preset: models.CryptoPresetModern}, want: modern},
+		{name: "legacy", policy: &models.CryptoPolicy{Preset: models.CryptoPresetLegacy}, want: legacy},
+		{
+			name:   "override on a preset",
+			policy: &models.CryptoPolicy{Preset: models.CryptoPresetModern, Ciphers: []string{ssh.CipherAES256GCM}},
+			want: ssh.Algorithms{
+				KeyExchanges: modern.KeyExchanges,
+				Ciphers:      []string{ssh.CipherAES256GCM},
+				MACs:         modern.MACs,
+				HostKeys:     modern.HostKeys,
+			},
+		},
+		{
+			name:   "override without a preset",
+			policy: &models.CryptoPolicy{MACs: []string{ssh.HMACSHA512ETM, ssh.HMACSHA256ETM}},
+			want:   ssh.Algorithms{MACs: []string{ssh.HMACSHA512ETM, ssh.HMACSHA256ETM}},
+		},
+		{
+			name:   "every category",
+			policy: &models.CryptoPolicy{KeyExchanges: []string{ssh.KeyExchangeCurve25519}, Ciphers: []string{ssh.CipherChaCha20Poly1305}, MACs: []string{ssh.HMACSHA256ETM}, HostKeyAlgorithms: []string{ssh.KeyAlgoED25519}},
+			want: ssh.Algorithms{
+				KeyExchanges: []string{ssh.KeyExchangeCurve25519},
+				Ciphers:      []string{ssh.CipherChaCha20Poly1305},
+				MACs:         []string{ssh.HMACSHA256ETM},
+				HostKeys:     []string{ssh.KeyAlgoED25519},
+			},
+		},
+		{
+			name:   "insecure algorithm named explicitly",
+			policy: &models.CryptoPolicy{Ciphers: []string{insecureCipher}},
+			want:   ssh.Algorithms{Ciphers: []string{insecureCipher}},
+		},
+		{
+			name:    "unknown preset",
+			policy:  &models.CryptoPolicy{Preset: "paranoid"},
+			wantErr: "unknown crypto preset: paranoid",
+		},
+		{
+			name:    "unknown cipher",
+			policy:  &models.CryptoPolicy{Ciphers: []string{"aes256-gcm"}},
+			wantErr: "unsupported cipher: aes256-gcm",
+		},
+		{
+			name:    "unknown key exchange",
+			policy:  &models.CryptoPolicy{Preset: models.CryptoPresetModern, KeyExchanges: []string{ssh.KeyExchangeCurve25519, "curve448-sha512"}},
+			wantErr: "unsupported key exchange: curve448-sha512",
+		},
+		{
+			name:    "unknown MAC",
+			policy:  &models.CryptoPolicy{MACs: []string{"hmac-md5"}},
+			wantErr: "unsupported MAC: hmac-md5",
+		},
+		{
+			name:    "cipher given as host key algorithm",
+			policy:  &models.CryptoPolicy{HostKeyAlgorithms: []string{ssh.CipherAES128GCM}},
+			wantErr: "unsupported host key algorithm",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CryptoAlgorithms(tt.policy)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CryptoAlgorithms error: %v", err)
+			}
+
+			lists := []struct {
+				kind      string
+				got, want []string
+			}{
+				{"key exchanges", got.KeyExchanges, tt.want.KeyExchanges},
+				{"ciphers", got.Ciphers, tt.want.Ciphers},
+				{"MACs", got.MACs, tt.want.MACs},
+				{"host keys", got.HostKeys, tt.want.HostKeys},
+			}
+			for _, list := range lists {
+				if !slices.Equal(list.got, list.want) {
+					t.Errorf("%s = %v, want %v", list.kind, list.got, list.want)
+				}
+			}
+		})
+	}
+}
+
+func TestCryptoAlgorithmsDoesNotShareThePreset(t *testing.T) {
+	got, err := CryptoAlgorithms(&models.CryptoPolicy{Preset: models.CryptoPresetModern})
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := cryptoPresets[models.CryptoPresetModern].Ciphers[0]
+	got.Ciphers[0] = "changed"
+	if cryptoPresets[models.CryptoPresetModern].Ciphers[0] != original {
+		t.Fatal("changing the result changed the preset")
+	}
+}
+
+func TestModernPresetIsSupported(t *testing.T) {
+	supported := ssh.SupportedAlgorithms()
+	insecure := ssh.InsecureAlgorithms()
+	modern := cryptoPresets[models.CryptoPresetModern]
+
+	lists := []struct {
+		kind              string
+		preset, supported []string
+		insecure          []string
+	}{
+		{"key exchange", modern.KeyExchanges, supported.KeyExchanges, insecure.KeyExchanges},
+		{"cipher", modern.Ciphers, supported.Ciphers, insecure.Ciphers},
+		{"MAC", modern.MACs, supported.MACs, insecure.MACs},
+		{"host key algorithm", modern.HostKeys, supported.HostKeys, insecure.HostKeys},
+	}
+	for _, list := range lists {
+		for _, algo := range list.preset {
+			if !slices.Contains(list.supported, algo) {
+				t.Errorf("modern %s %s is not supported by the SSH library", list.kind, algo)
+			}
+			if slices.Contains(list.insecure, algo) {
+				t.Errorf("modern %s %s is insecure", list.kind, algo)
+			}
+		}
+	}
+}
+
+func TestOrderHostKeyAlgorithms(t *testing.T) {
+	preferred := []string{ssh.KeyAlgoED25519, ssh.KeyAlgoECDSA256, ssh.KeyAlgoRSASHA512}
+
+	tests := []struct {
+		name    string
+		allowed []string
+		want    []string
+	}{
+		{"no policy", nil, preferred},
+		{"reordered by preference", []string{ssh.KeyAlgoRSASHA512, ssh.KeyAlgoED25519}, []string{ssh.KeyAlgoED25519, ssh.KeyAlgoRSASHA512}},
+		{"unpreferred kept last", []string{ssh.KeyAlgoRSASHA256, ssh.KeyAlgoECDSA256}, []string{ssh.KeyAlgoECDSA256, ssh.KeyAlgoRSASHA256}},
+		{"empty policy", []string{}, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := orderHostKeyAlgorithms(preferred, tt.allowed); !slices.Equal(got, tt.want) {
+				t.Errorf("orderHostKeyAlgorithms = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
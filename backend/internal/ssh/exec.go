@@ -0,0 +1,77 @@
+package ssh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// ExitStatus is how a remote command ended. Code is -1 when the server sent
+// no exit status.
+type ExitStatus struct {
+	Code   int
+	Signal string
+}
+
+// Exec runs command in a new channel on the client's connection, copying its
+// output to stdout and stderr as it arrives. stdin may be nil. When ctx ends
+// the command is sent SIGKILL and its channel closed; ctx.Err() is returned.
+func (c *Client) Exec(ctx context.Context, command string, stdin io.Reader, stdout, stderr io.Writer) (ExitStatus, error) {
+	status := ExitStatus{Code: -1}
+
+	session, err := c.NewSession()
+	if err != nil {
+		return status, fmt.Errorf("failed to create session: %w", err)
+	}
+	defer session.Close()
+
+	if stdin != nil {
+		session.Stdin = stdin
+	}
+	session.Stdout = stdout
+	session.Stderr = stderr
+
+	if err := session.Start(command); err != nil {
+		return status, fmt.Errorf("failed to start command: %w", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- session.Wait()
+	}()
+
+	select {
+	case err = <-done:
+		return exitStatus(err)
+	case <-ctx.Done():
+		// Servers may ignore signals, so the channel is closed as well. The
+		// exit status is kept in case the server sent one before the close.
+		_ = session.Signal(ssh.SIGKILL)
+		session.Close()
+		status, _ = exitStatus(<-done)
+		return status, ctx.Err()
+	}
+}
+
+// exitStatus converts the result of ssh.Session.Wait into an ExitStatus.
+// Errors that only describe how the command ended are not returned.
+func exitStatus(err error) (ExitStatus, error) {
+	if err == nil {
+		return ExitStatus{Code: 0}, nil
+	}
+
+	var exitErr *ssh.ExitError
+	if errors.As(err, &exitErr) {
+		return ExitStatus{Code: exitErr.ExitStatus(), Signal: exitErr.Signal()}, nil
+	}
+
+	status := ExitStatus{Code: -1}
+	var missingErr *ssh.ExitMissingError
+	if errors.As(err, &missingErr) {
+		return status, nil
+	}
+	return status, err
+}
@@ -3,9 +3,12 @@ package ssh
 import (
 	"encoding/base64"
 	"fmt"
+	"freessh-backend/internal/knownhosts"
 	"freessh-backend/internal/models"
 	"freessh-backend/internal/storage"
 	"net"
+	"strconv"
+	"strings"
 
 	"golang.org/x/crypto/ssh"
 )
@@ -21,66 +24,215 @@ func NewHostKeyVerifier(storage *storage.KnownHostStorage) *HostKeyVerifier {
 }
 
 func (v *HostKeyVerifier) VerifyHostKey(hostname string, port int, remote net.Addr, key ssh.PublicKey) (*models.HostKeyVerification, error) {
-	fingerprint := ssh.FingerprintSHA256(key)
-	keyType := key.Type()
+	verification := &models.HostKeyVerification{
+		Hostname:    hostname,
+		Port:        port,
+		Fingerprint: ssh.FingerprintSHA256(key),
+		KeyType:     key.Type(),
+	}
+
+	// Revoked keys are rejected outright, without asking the user
+	if v.isRevoked(key, hostname, port) {
+		verification.Status = "revoked"
+		return verification, fmt.Errorf("host key verification failed: key is revoked")
+	}
+
+	// Fingerprint recorded by older versions for certificate host keys
+	legacyFingerprint := ""
+
+	if cert, ok := key.(*ssh.Certificate); ok {
+		if v.isHostAuthority(cert.SignatureKey, hostname, port) {
+			checker := &ssh.CertChecker{
+				IsHostAuthority: func(auth ssh.PublicKey, address string) bool {
+					return v.isHostAuthority(auth, hostname, port)
+				},
+				IsRevoked: func(cert *ssh.Certificate) bool {
+					return v.isRevoked(cert, hostname, port)
+				},
+			}
+			address := net.JoinHostPort(hostname, strconv.Itoa(port))
+			if err := checker.CheckHostKey(address, remote, cert); err != nil {
+				verification.Status = "invalid"
+				return verification, fmt.Errorf("host key verification failed: %w", err)
+			}
+
+			verification.Status = "known"
+			return verification, nil
+		}
+
+		// Not signed by a trusted CA: verify the plain key underneath
+		legacyFingerprint = verification.Fingerprint
+		key = cert.Key
+		verification.Fingerprint = ssh.FingerprintSHA256(key)
+		verification.KeyType = key.Type()
+	}
+
+	verification.PublicKey = base64.StdEncoding.EncodeToString(key.Marshal())
 
 	// Check if host is known
-	knownHost := v.storage.Get(hostname, port)
+	knownKeys := v.knownKeys(hostname, port)
 
-	if knownHost == nil {
+	if len(knownKeys) == 0 {
 		// New host - return verification request
-		return &models.HostKeyVerification{
-			Status:      "new",
-			Hostname:    hostname,
-			Port:        port,
-			Fingerprint: fingerprint,
-			KeyType:     keyType,
-		}, nil
-	}
-
-	// Host is known - verify fingerprint
-	if knownHost.Fingerprint == fingerprint {
-		return &models.HostKeyVerification{
-			Status:      "known",
-			Hostname:    hostname,
-			Port:        port,
-			Fingerprint: fingerprint,
-			KeyType:     keyType,
-		}, nil
-	}
-
-	// Fingerprint changed - security warning
-	return &models.HostKeyVerification{
-		Status:         "changed",
-		Hostname:       hostname,
-		Port:           port,
-		Fingerprint:    fingerprint,
-		KeyType:        keyType,
-		OldFingerprint: knownHost.Fingerprint,
-	}, fmt.Errorf("host key verification failed: fingerprint changed")
+		verification.Status = "new"
+		return verification, nil
+	}
+
+	// Host is known - look for this exact key
+	for _, known := range knownKeys {
+		if known.Fingerprint == verification.Fingerprint || known.Fingerprint == legacyFingerprint {
+			verification.Status = "known"
+			return verification, nil
+		}
+	}
+
+	// A different key of a type we already trust (or of an unrecorded type)
+	// is a changed key - security warning
+	for _, known := range knownKeys {
+		if known.KeyType == verification.KeyType || known.KeyType == "" {
+			verification.Status = "changed"
+			verification.OldFingerprint = known.Fingerprint
+			return verification, fmt.Errorf("host key verification failed: fingerprint changed")
+		}
+	}
+
+	// Known host offering a key type we have not seen for it yet. Keys
+	// announced over hostkeys-00 are recorded silently once the host is
+	// verified; a new type offered in the handshake itself is what a
+	// man-in-the-middle would do, so it always needs explicit approval.
+	verification.Status = "new_key_type"
+	for _, known := range knownKeys {
+		verification.KnownKeyTypes = append(verification.KnownKeyTypes, known.KeyType)
+	}
+	return verification, fmt.Errorf("host key verification failed: unrecognised key type %s", verification.KeyType)
 }
 
-func (v *HostKeyVerifier) TrustHost(hostname string, port int, key ssh.PublicKey) error {
-	fingerprint := ssh.FingerprintSHA256(key)
-	publicKeyStr := base64.StdEncoding.EncodeToString(key.Marshal())
+// knownKeys returns the host keys recorded for hostname:port, including
+// wildcard and hashed entries that match it.
+func (v *HostKeyVerifier) knownKeys(hostname string, port int) []*models.KnownHost {
+	keys := v.storage.GetKeys(hostname, port)
+	for _, entry := range v.storage.GetPatternEntries() {
+		if knownhosts.Match(entry.Hostname, hostname, port) {
+			keys = append(keys, entry)
+		}
+	}
+	return keys
+}
+
+// isHostAuthority reports whether key is a @cert-authority entry whose host
+// patterns match hostname:port.
+func (v *HostKeyVerifier) isHostAuthority(key ssh.PublicKey, hostname string, port int) bool {
+	for _, entry := range v.storage.GetByMarker(models.KnownHostMarkerCertAuthority) {
+		if knownhosts.Match(entry.Hostname, hostname, port) && entryMatchesKey(entry, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// isRevoked reports whether key, or for a certificate its key or signing CA,
+// is listed in a matching @revoked entry.
+func (v *HostKeyVerifier) isRevoked(key ssh.PublicKey, hostname string, port int) bool {
+	candidates := []ssh.PublicKey{key}
+	if cert, ok := key.(*ssh.Certificate); ok {
+		candidates = append(candidates, cert.Key, cert.SignatureKey)
+	}
 
-	// Check if already exists
-	existing := v.storage.Get(hostname, port)
-	if existing != nil {
-		// Update existing
-		existing.Fingerprint = fingerprint
-		existing.PublicKey = publicKeyStr
-		return v.storage.Update(existing)
+	for _, entry := range v.storage.GetByMarker(models.KnownHostMarkerRevoked) {
+		if !knownhosts.Match(entry.Hostname, hostname, port) {
+			continue
+		}
+		for _, candidate := range candidates {
+			if entryMatchesKey(entry, candidate) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func entryMatchesKey(entry *models.KnownHost, key ssh.PublicKey) bool {
+	if entry.PublicKey != "" {
+		return entry.PublicKey == base64.StdEncoding.EncodeToString(key.Marshal())
+	}
+	return entry.Fingerprint == ssh.FingerprintSHA256(key)
+}
+
+func (v *HostKeyVerifier) TrustHost(hostname string, port int, key ssh.PublicKey) error {
+	// Pin the key underneath a certificate; the certificate changes on re-issue
+	if cert, ok := key.(*ssh.Certificate); ok {
+		key = cert.Key
 	}
 
-	// Add new
-	host := &models.KnownHost{
+	return v.storage.SaveHostKey(&models.KnownHost{
 		Hostname:    hostname,
 		Port:        port,
-		Fingerprint: fingerprint,
-		PublicKey:   publicKeyStr,
+		Fingerprint: ssh.FingerprintSHA256(key),
+		PublicKey:   base64.StdEncoding.EncodeToString(key.Marshal()),
+		KeyType:     key.Type(),
+	})
+}
+
+// PreferredHostKeyAlgorithms orders the supported host key algorithms so the
+// key types already recorded for hostname:port are negotiated first. It
+// returns nil for unknown hosts, leaving the library defaults in place.
+func (v *HostKeyVerifier) PreferredHostKeyAlgorithms(hostname string, port int) []string {
+	knownTypes := make(map[string]bool)
+	for _, known := range v.knownKeys(hostname, port) {
+		if known.KeyType != "" {
+			knownTypes[known.KeyType] = true
+		}
 	}
-	return v.storage.Add(host)
+	if len(knownTypes) == 0 {
+		return nil
+	}
+
+	supported := ssh.SupportedAlgorithms().HostKeys
+	preferred := make([]string, 0, len(supported))
+	others := make([]string, 0, len(supported))
+	for _, algo := range supported {
+		if knownTypes[keyTypeForAlgorithm(algo)] {
+			preferred = append(preferred, algo)
+		} else {
+			others = append(others, algo)
+		}
+	}
+	return append(preferred, others...)
+}
+
+// keyTypeForAlgorithm maps a host key algorithm to the key type it uses, e.g.
+// "rsa-sha2-512" and "rsa-sha2-256-cert-v01@openssh.com" to "ssh-rsa".
+func keyTypeForAlgorithm(algo string) string {
+	switch algo {
+	case ssh.KeyAlgoRSASHA256, ssh.KeyAlgoRSASHA512,
+		ssh.CertAlgoRSAv01, ssh.CertAlgoRSASHA256v01, ssh.CertAlgoRSASHA512v01:
+		return ssh.KeyAlgoRSA
+	case ssh.CertAlgoSKED25519v01:
+		return ssh.KeyAlgoSKED25519
+	case ssh.CertAlgoSKECDSA256v01:
+		return ssh.KeyAlgoSKECDSA256
+	}
+	return strings.TrimSuffix(algo, "-cert-v01@openssh.com")
+}
+
+// RecordHostKeys stores keys announced by an already verified host, replacing
+// older keys of the same type. Keys of types the host no longer announces are
+// kept; removing them is left to the user.
+func (v *HostKeyVerifier) RecordHostKeys(hostname string, port int, keys []ssh.PublicKey) error {
+	known := make(map[string]bool)
+	for _, host := range v.knownKeys(hostname, port) {
+		known[host.Fingerprint] = true
+	}
+
+	for _, key := range keys {
+		if known[ssh.FingerprintSHA256(key)] || v.isRevoked(key, hostname, port) {
+			continue
+		}
+		if err := v.TrustHost(hostname, port, key); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (v *HostKeyVerifier) CreateCallback(hostname string, port int, onVerification func(*models.HostKeyVerification) error) ssh.HostKeyCallback {
@@ -92,7 +244,13 @@ func (v *HostKeyVerifier) CreateCallback(hostname string, port int, onVerificati
 			return nil
 		}
 
-		// For new or changed hosts, call verification handler
+		// Revoked keys and invalid certificates from a trusted CA are never
+		// offered to the user
+		if verification.Status == "revoked" || verification.Status == "invalid" {
+			return err
+		}
+
+		// For new hosts, new key types and changed keys, call verification handler
 		if onVerification != nil {
 			// Handler is responsible for trusting the host if approved
 			return onVerification(verification)
@@ -103,7 +261,7 @@ func (v *HostKeyVerifier) CreateCallback(hostname string, port int, onVerificati
 			return v.TrustHost(hostname, port, key)
 		}
 
-		// For changed keys without handler, return error
+		// For new key types and changed keys without handler, return error
 		if err != nil {
 			return err
 		}
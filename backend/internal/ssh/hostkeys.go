@@ -0,0 +1,151 @@
+package ssh
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// OpenSSH host key rotation extension (PROTOCOL, section 2.5).
+const (
+	hostKeysRequest      = "hostkeys-00@openssh.com"
+	hostKeysProveRequest = "hostkeys-prove-00@openssh.com"
+)
+
+// SetHostKeysCallback registers callback to receive host keys the server
+// announces after authentication. Only keys the server proved it holds are
+// passed on.
+func (c *Client) SetHostKeysCallback(callback func(keys []ssh.PublicKey) error) {
+	c.onHostKeys = callback
+}
+
+// interceptHostKeys handles hostkeys-00@openssh.com and forwards every other
+// global request unchanged.
+func (c *Client) interceptHostKeys(conn ssh.Conn, reqs <-chan *ssh.Request, presented ssh.PublicKey) <-chan *ssh.Request {
+	forwarded := make(chan *ssh.Request)
+	go func() {
+		defer close(forwarded)
+		for req := range reqs {
+			if req.Type != hostKeysRequest {
+				forwarded <- req
+				continue
+			}
+
+			if req.WantReply {
+				req.Reply(false, nil)
+			}
+			// Hosts verified through a certificate authority need no pinning
+			if _, isCert := presented.(*ssh.Certificate); isCert || c.onHostKeys == nil {
+				continue
+			}
+			go c.updateHostKeys(conn, req.Payload)
+		}
+	}()
+	return forwarded
+}
+
+func (c *Client) updateHostKeys(conn ssh.Conn, payload []byte) {
+	keys, blobs, err := parseHostKeyBlobs(payload)
+	if err != nil {
+		log.Printf("Ignoring host key announcement from %s: %v", c.config.Host, err)
+		return
+	}
+	if len(keys) == 0 {
+		return
+	}
+
+	proven, err := proveHostKeys(conn, keys, blobs)
+	if err != nil {
+		log.Printf("Host key proof from %s failed: %v", c.config.Host, err)
+		return
+	}
+
+	if err := c.onHostKeys(proven); err != nil {
+		log.Printf("Failed to record host keys for %s: %v", c.config.Host, err)
+	}
+}
+
+// proveHostKeys asks the server to sign the session identifier with each
+// announced key and returns the keys whose signatures verify.
+func proveHostKeys(conn ssh.Conn, keys []ssh.PublicKey, blobs [][]byte) ([]ssh.PublicKey, error) {
+	var request []byte
+	for _, blob := range blobs {
+		request = appendString(request, blob)
+	}
+
+	ok, response, err := conn.SendRequest(hostKeysProveRequest, true, request)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("server refused %s", hostKeysProveRequest)
+	}
+
+	proven := make([]ssh.PublicKey, 0, len(keys))
+	for i, key := range keys {
+		sigBlob, rest, ok := readString(response)
+		if !ok {
+			return nil, fmt.Errorf("missing signature for key %d", i)
+		}
+		response = rest
+
+		var sig ssh.Signature
+		if err := ssh.Unmarshal(sigBlob, &sig); err != nil {
+			return nil, fmt.Errorf("invalid signature for key %d: %w", i, err)
+		}
+
+		var signed []byte
+		signed = appendString(signed, []byte(hostKeysProveRequest))
+		signed = appendString(signed, conn.SessionID())
+		signed = appendString(signed, blobs[i])
+		if err := key.Verify(signed, &sig); err != nil {
+			return nil, fmt.Errorf("signature for %s key does not verify: %w", key.Type(), err)
+		}
+		proven = append(proven, key)
+	}
+	return proven, nil
+}
+
+// parseHostKeyBlobs decodes the announced keys, skipping types this client
+// cannot parse, as the extension requires.
+func parseHostKeyBlobs(payload []byte) ([]ssh.PublicKey, [][]byte, error) {
+	var keys []ssh.PublicKey
+	var blobs [][]byte
+	for len(payload) > 0 {
+		blob, rest, ok := readString(payload)
+		if !ok {
+			return nil, nil, fmt.Errorf("malformed %s payload", hostKeysRequest)
+		}
+		payload = rest
+
+		key, err := ssh.ParsePublicKey(blob)
+		if err != nil {
+			continue
+		}
+		if _, isCert := key.(*ssh.Certificate); isCert {
+			continue
+		}
+		keys = append(keys, key)
+		blobs = append(blobs, bytes.Clone(blob))
+	}
+	return keys, blobs, nil
+}
+
+func appendString(buf []byte, s []byte) []byte {
+	n := len(s)
+	buf = append(buf, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	return append(buf, s...)
+}
+
+func readString(buf []byte) ([]byte, []byte, bool) {
+	if len(buf) < 4 {
+		return nil, nil, false
+	}
+	n := int(buf[0])<<24 | int(buf[1])<<16 | int(buf[2])<<8 | int(buf[3])
+	if n < 0 || len(buf)-4 < n {
+		return nil, nil, false
+	}
+	return buf[4 : 4+n], buf[4+n:], true
+}
@@ -0,0 +1,145 @@
+package ssh
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func testPublicKey(t *testing.T, kind string) ssh.PublicKey {
+	t.Helper()
+
+	var raw any
+	switch kind {
+	case "ed25519":
+		public, _, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		raw = public
+	case "ecdsa":
+		private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		raw = &private.PublicKey
+	}
+
+	key, err := ssh.NewPublicKey(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func testCertificate(t *testing.T, key ssh.PublicKey) ssh.PublicKey {
+	t.Helper()
+
+	_, caKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := &ssh.Certificate{
+		Key:         key,
+		CertType:    ssh.HostCert,
+		ValidBefore: ssh.CertTimeInfinity,
+	}
+	if err := cert.SignCert(rand.Reader, signer); err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestParseHostKeyBlobs(t *testing.T) {
+	ed := testPublicKey(t, "ed25519")
+	ec := testPublicKey(t, "ecdsa")
+	cert := testCertificate(t, ed)
+
+	unknownType := appendString(appendString(nil, []byte("ssh-unknown@example.com")), []byte("opaque"))
+
+	payload := func(blobs ...[]byte) []byte {
+		var buf []byte
+		for _, blob := range blobs {
+			buf = appendString(buf, blob)
+		}
+		return buf
+	}
+
+	tests := []struct {
+		name    string
+		payload []byte
+		want    []ssh.PublicKey
+		wantErr bool
+	}{
+		{name: "empty", payload: nil},
+		{name: "one key", payload: payload(ed.Marshal()), want: []ssh.PublicKey{ed}},
+		{name: "several keys in order", payload: payload(ec.Marshal(), ed.Marshal()), want: []ssh.PublicKey{ec, ed}},
+		{name: "unknown key type skipped", payload: payload(unknownType, ed.Marshal()), want: []ssh.PublicKey{ed}},
+		{name: "garbage blob skipped", payload: payload([]byte("garbage"), ec.Marshal()), want: []ssh.PublicKey{ec}},
+		{name: "certificate skipped", payload: payload(cert.Marshal(), ec.Marshal()), want: []ssh.PublicKey{ec}},
+		{name: "truncated length", payload: append(payload(ed.Marshal()), 0, 0), wantErr: true},
+		{name: "length past the end", payload: payload(ed.Marshal())[:20], wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, blobs, err := parseHostKeyBlobs(tt.payload)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseHostKeyBlobs accepted a malformed payload")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseHostKeyBlobs error: %v", err)
+			}
+			if len(keys) != len(tt.want) || len(blobs) != len(tt.want) {
+				t.Fatalf("got %d keys and %d blobs, want %d", len(keys), len(blobs), len(tt.want))
+			}
+			for i, want := range tt.want {
+				if ssh.FingerprintSHA256(keys[i]) != ssh.FingerprintSHA256(want) {
+					t.Errorf("key %d is %s, want %s", i, keys[i].Type(), want.Type())
+				}
+				if string(blobs[i]) != string(want.Marshal()) {
+					t.Errorf("blob %d does not match its key", i)
+				}
+			}
+		})
+	}
+}
+
+func TestReadString(t *testing.T) {
+	tests := []struct {
+		name   string
+		buf    []byte
+		want   string
+		rest   string
+		wantOK bool
+	}{
+		{"empty string", []byte{0, 0, 0, 0}, "", "", true},
+		{"with rest", []byte{0, 0, 0, 2, 'a', 'b', 'c'}, "ab", "c", true},
+		{"short header", []byte{0, 0, 1}, "", "", false},
+		{"short body", []byte{0, 0, 0, 3, 'a'}, "", "", false},
+		{"huge length", []byte{0xff, 0xff, 0xff, 0xff, 'a'}, "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, rest, ok := readString(tt.buf)
+			if ok != tt.wantOK {
+				t.Fatalf("readString ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && (string(got) != tt.want || string(rest) != tt.rest) {
+				t.Errorf("readString = %q, %q; want %q, %q", got, rest, tt.want, tt.rest)
+			}
+		})
+	}
+}
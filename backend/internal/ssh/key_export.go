@@ -2,15 +2,19 @@ package ssh
 
 import (
 	"fmt"
+	appconfig "freessh-backend/internal/config"
 	"freessh-backend/internal/keychain"
 	"freessh-backend/internal/models"
+	"freessh-backend/internal/proxy"
+	"net"
+	"strconv"
 	"strings"
 	"time"
 
 	"golang.org/x/crypto/ssh"
 )
 
-func ExportKeyToConnection(config models.ConnectionConfig, publicKey string, generatedPrivateKey string, keyName string) error {
+func ExportKeyToConnection(config models.ConnectionConfig, proxyConfig *models.ProxyConfig, publicKey string, generatedPrivateKey string, keyName string) error {
 	kc := keychain.New()
 	clientConfig := &ssh.ClientConfig{
 		User:            config.Username,
@@ -47,11 +51,23 @@ func ExportKeyToConnection(config models.ConnectionConfig, publicKey string, gen
 		return fmt.Errorf("no valid authentication method configured for this connection")
 	}
 
-	addr := fmt.Sprintf("%s:%d", config.Host, config.Port)
-	client, err := ssh.Dial("tcp", addr, clientConfig)
+	addr := net.JoinHostPort(config.Host, strconv.Itoa(config.Port))
+	var conn net.Conn
+	var err error
+	if config.ProxyCommand != "" {
+		conn, err = proxy.DialCommand(proxy.ExpandCommand(config.ProxyCommand, config.Host, config.Port, config.Username))
+	} else {
+		conn, err = proxy.Dial(proxyConfig, addr, appconfig.DefaultTimeout)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, addr, clientConfig)
 	if err != nil {
+		conn.Close()
 		return fmt.Errorf("failed to connect: %w", err)
 	}
+	client := ssh.NewClient(sshConn, chans, reqs)
 	defer client.Close()
 
 	sshSession, err := client.NewSession()
@@ -0,0 +1,271 @@
+package ssh
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const x11AuthProtocol = "MIT-MAGIC-COOKIE-1"
+
+// x11Display is a parsed local $DISPLAY.
+type x11Display struct {
+	network string // "unix" or "tcp"
+	address string
+	screen  uint32
+}
+
+// x11Forwarder proxies x11 channels of one transport to the local display,
+// swapping the fake cookie given to the server for the real one.
+type x11Forwarder struct {
+	display    x11Display
+	fakeCookie []byte
+	realCookie []byte
+}
+
+type x11Request struct {
+	SingleConnection bool
+	AuthProtocol     string
+	AuthCookie       string
+	ScreenNumber     uint32
+}
+
+type x11ChannelData struct {
+	OriginatorAddress string
+	OriginatorPort    uint32
+}
+
+// RequestX11Forwarding asks the server to forward X11 connections made from
+// session to the local $DISPLAY when the connection has ForwardX11 enabled.
+// The x11 channel handler is registered once per underlying transport.
+func (c *Client) RequestX11Forwarding(session *ssh.Session) error {
+	if !c.config.ForwardX11 {
+		return nil
+	}
+	if c.sshClient == nil {
+		return fmt.Errorf("not connected")
+	}
+
+	c.x11Mu.Lock()
+	defer c.x11Mu.Unlock()
+
+	if c.x11Forwarded != c.sshClient {
+		forwarder, err := newX11Forwarder(os.Getenv("DISPLAY"))
+		if err != nil {
+			return err
+		}
+		channels := c.sshClient.HandleChannelOpen("x11")
+		if channels == nil {
+			return fmt.Errorf("x11 channels are already handled")
+		}
+		go forwarder.serve(channels)
+		c.x11 = forwarder
+		c.x11Forwarded = c.sshClient
+	}
+
+	request := x11Request{
+		AuthProtocol: x11AuthProtocol,
+		AuthCookie:   hex.EncodeToString(c.x11.fakeCookie),
+		ScreenNumber: c.x11.display.screen,
+	}
+	ok, err := session.SendRequest("x11-req", true, ssh.Marshal(&request))
+	if err != nil {
+		return fmt.Errorf("x11 forwarding request failed: %w", err)
+	}
+	if !ok {
+		return fmt.Errorf("x11 forwarding request refused by server")
+	}
+
+	return nil
+}
+
+func newX11Forwarder(display string) (*x11Forwarder, error) {
+	parsed, err := parseX11Display(display)
+	if err != nil {
+		return nil, err
+	}
+
+	fakeCookie := make([]byte, 16)
+	if _, err := rand.Read(fakeCookie); err != nil {
+		return nil, fmt.Errorf("failed to generate x11 cookie: %w", err)
+	}
+
+	return &x11Forwarder{
+		display:    parsed,
+		fakeCookie: fakeCookie,
+		// Without xauth the X server is expected to accept unauthenticated
+		// local clients.
+		realCookie: xauthCookie(display),
+	}, nil
+}
+
+// parseX11Display understands ":0", "unix:0.1", "host:10.0" and the socket
+// paths macOS launchd puts in $DISPLAY.
+func parseX11Display(display string) (x11Display, error) {
+	if display == "" {
+		return x11Display{}, fmt.Errorf("DISPLAY is not set")
+	}
+
+	colon := strings.LastIndex(display, ":")
+	if colon < 0 {
+		return x11Display{}, fmt.Errorf("invalid DISPLAY: %s", display)
+	}
+	host := display[:colon]
+	number, screen, _ := strings.Cut(display[colon+1:], ".")
+
+	displayNumber, err := strconv.Atoi(number)
+	if err != nil || displayNumber < 0 {
+		return x11Display{}, fmt.Errorf("invalid DISPLAY: %s", display)
+	}
+	result := x11Display{}
+	if screen != "" {
+		screenNumber, err := strconv.ParseUint(screen, 10, 32)
+		if err != nil {
+			return x11Display{}, fmt.Errorf("invalid DISPLAY: %s", display)
+		}
+		result.screen = uint32(screenNumber)
+	}
+
+	switch {
+	case strings.HasPrefix(display, "/"):
+		result.network = "unix"
+		result.address = display
+	case host == "" || host == "unix":
+		result.network = "unix"
+		result.address = filepath.Join("/tmp/.X11-unix", "X"+number)
+	default:
+		result.network = "tcp"
+		result.address = net.JoinHostPort(host, strconv.Itoa(6000+displayNumber))
+	}
+	return result, nil
+}
+
+// xauthCookie returns the MIT-MAGIC-COOKIE-1 of display from xauth, or nil.
+func xauthCookie(display string) []byte {
+	output, err := exec.Command("xauth", "list", display).Output()
+	if err != nil {
+		return nil
+	}
+
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 3 && fields[1] == x11AuthProtocol {
+			if cookie, err := hex.DecodeString(fields[2]); err == nil {
+				return cookie
+			}
+		}
+	}
+	return nil
+}
+
+func (f *x11Forwarder) serve(channels <-chan ssh.NewChannel) {
+	for newChannel := range channels {
+		var data x11ChannelData
+		if err := ssh.Unmarshal(newChannel.ExtraData(), &data); err != nil {
+			newChannel.Reject(ssh.ConnectionFailed, "invalid x11 channel data")
+			continue
+		}
+
+		local, err := net.DialTimeout(f.display.network, f.display.address, 5*time.Second)
+		if err != nil {
+			newChannel.Reject(ssh.ConnectionFailed, fmt.Sprintf("cannot reach local display: %v", err))
+			continue
+		}
+
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			local.Close()
+			continue
+		}
+		go ssh.DiscardRequests(requests)
+		go f.proxy(channel, local)
+	}
+}
+
+func (f *x11Forwarder) proxy(channel ssh.Channel, local net.Conn) {
+	defer channel.Close()
+	defer local.Close()
+
+	setup, err := f.rewriteSetup(channel)
+	if err != nil {
+		return
+	}
+	if _, err := local.Write(setup); err != nil {
+		return
+	}
+
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(local, channel)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(channel, local)
+		channel.CloseWrite()
+		done <- struct{}{}
+	}()
+	<-done
+}
+
+// rewriteSetup reads the X11 connection setup from the remote client, checks
+// its cookie against the fake one and returns the setup with the real cookie.
+func (f *x11Forwarder) rewriteSetup(r io.Reader) ([]byte, error) {
+	header := make([]byte, 12)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+
+	var order binary.ByteOrder
+	switch header[0] {
+	case 'B':
+		order = binary.BigEndian
+	case 'l':
+		order = binary.LittleEndian
+	default:
+		return nil, fmt.Errorf("invalid x11 byte order")
+	}
+
+	nameLen := int(order.Uint16(header[6:8]))
+	dataLen := int(order.Uint16(header[8:10]))
+	auth := make([]byte, pad4(nameLen)+pad4(dataLen))
+	if _, err := io.ReadFull(r, auth); err != nil {
+		return nil, err
+	}
+
+	name := string(auth[:nameLen])
+	cookie := auth[pad4(nameLen) : pad4(nameLen)+dataLen]
+	if name != x11AuthProtocol || !bytes.Equal(cookie, f.fakeCookie) {
+		return nil, fmt.Errorf("x11 authentication mismatch")
+	}
+
+	var setup bytes.Buffer
+	if f.realCookie == nil {
+		order.PutUint16(header[6:8], 0)
+		order.PutUint16(header[8:10], 0)
+		setup.Write(header)
+		return setup.Bytes(), nil
+	}
+
+	order.PutUint16(header[8:10], uint16(len(f.realCookie)))
+	setup.Write(header)
+	setup.Write(auth[:pad4(nameLen)])
+	setup.Write(f.realCookie)
+	setup.Write(make([]byte, pad4(len(f.realCookie))-len(f.realCookie)))
+	return setup.Bytes(), nil
+}
+
+func pad4(n int) int {
+	return (n + 3) &^ 3
+}
@@ -39,6 +39,9 @@ func (s *ConnectionStorage) Save(config models.ConnectionConfig) error {
 	if config.ID == "" {
 		config.ID = uuid.New().String()
 	}
+	if config.AuthMethod == "" && len(config.AuthMethods) > 0 {
+		config.AuthMethod = config.AuthMethods[0]
+	}
 	if config.AuthMethod == "" {
 		if config.KeyID != "" || config.PrivateKey != "" {
 			config.AuthMethod = models.AuthPublicKey
@@ -60,10 +63,60 @@ func (s *ConnectionStorage) Save(config models.ConnectionConfig) error {
 		profileJSON = string(encoded)
 	}
 
-	_, err := s.db.Exec(`
+	authMethodsJSON := ""
+	if len(config.AuthMethods) > 0 {
+		encoded, err := json.Marshal(config.AuthMethods)
+		if err != nil {
+			return fmt.Errorf("failed to marshal auth methods: %w", err)
+		}
+		authMethodsJSON = string(encoded)
+	}
+
+	jumpHostsJSON := ""
+	if len(config.JumpHosts) > 0 {
+		encoded, err := json.Marshal(config.JumpHosts)
+		if err != nil {
+			return fmt.Errorf("failed to marshal jump hosts: %w", err)
+		}
+		jumpHostsJSON = string(encoded)
+	}
+
+	proxyJSON := ""
+	if config.Proxy != nil {
+		encoded, err := json.Marshal(config.Proxy)
+		if err != nil {
+			return fmt.Errorf("failed to marshal proxy: %w", err)
+		}
+		proxyJSON = string(encoded)
+	}
+
+	networkJSON := ""
+	if config.Network != nil {
+		encoded, err := json.Marshal(config.Network)
+		if err != nil {
+			return fmt.Errorf("failed to marshal network options: %w", err)
+		}
+		networkJSON = string(encoded)
+	}
+
+	cryptoJSON := ""
+	if !config.Crypto.IsZero() {
+		encoded, err := json.Marshal(config.Crypto)
+		if err != nil {
+			return fmt.Errorf("failed to marshal crypto policy: %w", err)
+		}
+		cryptoJSON = string(encoded)
+	}
+
+	environmentJSON, err := marshalEnvironment(config.Environment)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(`
 		INSERT OR REPLACE INTO connections (
-			id, name, host, port, username, auth_method, private_key, passphrase, key_id, password, "group", profile
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			id, name, host, port, username, auth_method, private_key, passphrase, key_id, password, "group", profile, jump_hosts, forward_agent, auth_methods, proxy, proxy_command, network, crypto_policy, forward_x11, environment
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`,
 		config.ID,
 		config.Name,
@@ -77,6 +130,15 @@ func (s *ConnectionStorage) Save(config models.ConnectionConfig) error {
 		nullIfEmpty(config.Password),
 		nullIfEmpty(config.Group),
 		nullIfEmpty(profileJSON),
+		nullIfEmpty(jumpHostsJSON),
+		boolToInt(config.ForwardAgent),
+		nullIfEmpty(authMethodsJSON),
+		nullIfEmpty(proxyJSON),
+		nullIfEmpty(config.ProxyCommand),
+		nullIfEmpty(networkJSON),
+		nullIfEmpty(cryptoJSON),
+		boolToInt(config.ForwardX11),
+		nullIfEmpty(environmentJSON),
 	)
 	if err != nil {
 		return fmt.Errorf("failed to save connection: %w", err)
@@ -90,7 +152,7 @@ func (s *ConnectionStorage) Get(id string) (*models.ConnectionConfig, error) {
 		return nil, fmt.Errorf("connection storage unavailable")
 	}
 	row := s.db.QueryRow(`
-		SELECT id, name, host, port, username, auth_method, private_key, passphrase, key_id, password, "group", profile
+		SELECT id, name, host, port, username, auth_method, private_key, passphrase, key_id, password, "group", profile, jump_hosts, forward_agent, auth_methods, proxy, proxy_command, network, crypto_policy, forward_x11, environment
 		FROM connections WHERE id = ?
 	`, id)
 
@@ -110,7 +172,7 @@ func (s *ConnectionStorage) List() []models.ConnectionConfig {
 		return nil
 	}
 	rows, err := s.db.Query(`
-		SELECT id, name, host, port, username, auth_method, private_key, passphrase, key_id, password, "group", profile
+		SELECT id, name, host, port, username, auth_method, private_key, passphrase, key_id, password, "group", profile, jump_hosts, forward_agent, auth_methods, proxy, proxy_command, network, crypto_policy, forward_x11, environment
 		FROM connections
 	`)
 	if err != nil {
@@ -239,18 +301,27 @@ func scanConnection(scanner interface {
 	Scan(dest ...any) error
 }) (models.ConnectionConfig, error) {
 	var (
-		id          string
-		name        string
-		host        string
-		port        int
-		username    string
-		authMethod  string
-		privateKey  sql.NullString
-		passphrase  sql.NullString
-		keyID       sql.NullString
-		password    sql.NullString
-		group       sql.NullString
-		profileJSON sql.NullString
+		id           string
+		name         string
+		host         string
+		port         int
+		username     string
+		authMethod   string
+		privateKey   sql.NullString
+		passphrase   sql.NullString
+		keyID        sql.NullString
+		password     sql.NullString
+		group        sql.NullString
+		profileJSON  sql.NullString
+		jumpHosts    sql.NullString
+		forwardAgent sql.NullInt64
+		authMethods  sql.NullString
+		proxyJSON    sql.NullString
+		proxyCommand sql.NullString
+		networkJSON  sql.NullString
+		cryptoJSON   sql.NullString
+		forwardX11   sql.NullInt64
+		environment  sql.NullString
 	)
 
 	if err := scanner.Scan(
@@ -266,6 +337,15 @@ func scanConnection(scanner interface {
 		&password,
 		&group,
 		&profileJSON,
+		&jumpHosts,
+		&forwardAgent,
+		&authMethods,
+		&proxyJSON,
+		&proxyCommand,
+		&networkJSON,
+		&cryptoJSON,
+		&forwardX11,
+		&environment,
 	); err != nil {
 		return models.ConnectionConfig{}, err
 	}
@@ -278,6 +358,40 @@ func scanConnection(scanner interface {
 		}
 	}
 
+	var jumpHostIDs []string
+	if jumpHosts.Valid && jumpHosts.String != "" {
+		_ = json.Unmarshal([]byte(jumpHosts.String), &jumpHostIDs)
+	}
+
+	var authMethodList []models.AuthMethod
+	if authMethods.Valid && authMethods.String != "" {
+		_ = json.Unmarshal([]byte(authMethods.String), &authMethodList)
+	}
+
+	var proxy *models.ProxyConfig
+	if proxyJSON.Valid && proxyJSON.String != "" {
+		var parsed models.ProxyConfig
+		if err := json.Unmarshal([]byte(proxyJSON.String), &parsed); err == nil {
+			proxy = &parsed
+		}
+	}
+
+	var network *models.NetworkOptions
+	if networkJSON.Valid && networkJSON.String != "" {
+		var parsed models.NetworkOptions
+		if err := json.Unmarshal([]byte(networkJSON.String), &parsed); err == nil {
+			network = &parsed
+		}
+	}
+
+	var crypto *models.CryptoPolicy
+	if cryptoJSON.Valid && cryptoJSON.String != "" {
+		var parsed models.CryptoPolicy
+		if err := json.Unmarshal([]byte(cryptoJSON.String), &parsed); err == nil {
+			crypto = &parsed
+		}
+	}
+
 	config := models.ConnectionConfig{
 		ID:         id,
 		Name:       name,
@@ -289,6 +403,16 @@ func scanConnection(scanner interface {
 		KeyID:      keyID.String,
 		Group:      group.String,
 		Profile:    models.NormalizeSessionProfile(profile),
+		JumpHosts:  jumpHostIDs,
+
+		AuthMethods:  authMethodList,
+		ForwardAgent: forwardAgent.Int64 != 0,
+		ForwardX11:   forwardX11.Int64 != 0,
+		Environment:  unmarshalEnvironment(environment),
+		Proxy:        proxy,
+		ProxyCommand: proxyCommand.String,
+		Network:      network,
+		Crypto:       crypto,
 	}
 
 	if passphrase.Valid {
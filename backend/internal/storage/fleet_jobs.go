@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"freessh-backend/internal/db"
+	"freessh-backend/internal/models"
+)
+
+// FleetJobStorage keeps the records of fleet runs for later review.
+type FleetJobStorage struct {
+	db *sql.DB
+}
+
+func NewFleetJobStorage() (*FleetJobStorage, error) {
+	database, err := db.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	return &FleetJobStorage{
+		db: database,
+	}, nil
+}
+
+// Save inserts job or replaces the stored record with the same ID.
+func (s *FleetJobStorage) Save(job models.FleetJob) error {
+	hostsJSON, err := json.Marshal(job.Hosts)
+	if err != nil {
+		return fmt.Errorf("failed to encode fleet job hosts: %w", err)
+	}
+
+	_, err = s.db.Exec(`
+		INSERT OR REPLACE INTO fleet_jobs (
+			id, snippet_id, snippet_name, command, status, concurrency,
+			total, succeeded, failed, cancelled, hosts, created_at, finished_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		job.ID,
+		nullIfEmpty(job.SnippetID),
+		nullIfEmpty(job.SnippetName),
+		job.Command,
+		string(job.Status),
+		job.Concurrency,
+		job.Summary.Total,
+		job.Summary.Succeeded,
+		job.Summary.Failed,
+		job.Summary.Cancelled,
+		string(hostsJSON),
+		formatTime(job.CreatedAt),
+		formatTime(job.FinishedAt),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save fleet job: %w", err)
+	}
+	return nil
+}
+
+// List returns all jobs, newest first, without their per-host results.
+func (s *FleetJobStorage) List() []models.FleetJob {
+	rows, err := s.db.Query(`
+		SELECT id, snippet_id, snippet_name, command, status, concurrency,
+			total, succeeded, failed, cancelled, NULL, created_at, finished_at
+		FROM fleet_jobs ORDER BY created_at DESC
+	`)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	jobs := make([]models.FleetJob, 0)
+	for rows.Next() {
+		job, err := scanFleetJob(rows)
+		if err != nil {
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs
+}
+
+func (s *FleetJobStorage) Get(id string) (*models.FleetJob, error) {
+	row := s.db.QueryRow(`
+		SELECT id, snippet_id, snippet_name, command, status, concurrency,
+			total, succeeded, failed, cancelled, hosts, created_at, finished_at
+		FROM fleet_jobs WHERE id = ?
+	`, id)
+
+	job, err := scanFleetJob(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("fleet job not found: %s", id)
+		}
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+func (s *FleetJobStorage) Delete(id string) error {
+	result, err := s.db.Exec(`DELETE FROM fleet_jobs WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete fleet job: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("fleet job not found: %s", id)
+	}
+	return nil
+}
+
+// MarkInterrupted fails jobs left running by a previous process, so their
+// records do not claim to be in progress forever.
+func (s *FleetJobStorage) MarkInterrupted() error {
+	_, err := s.db.Exec(`
+		UPDATE fleet_jobs SET status = ?, finished_at = COALESCE(finished_at, datetime('now', 'localtime'))
+		WHERE status = ?
+	`, string(models.FleetFailed), string(models.FleetRunning))
+	if err != nil {
+		return fmt.Errorf("failed to update interrupted fleet jobs: %w", err)
+	}
+	return nil
+}
+
+func scanFleetJob(scanner interface {
+	Scan(dest ...any) error
+}) (models.FleetJob, error) {
+	var job models.FleetJob
+	var snippetID, snippetName, hostsJSON, createdAt, finishedAt sql.NullString
+	var status string
+
+	if err := scanner.Scan(
+		&job.ID,
+		&snippetID,
+		&snippetName,
+		&job.Command,
+		&status,
+		&job.Concurrency,
+		&job.Summary.Total,
+		&job.Summary.Succeeded,
+		&job.Summary.Failed,
+		&job.Summary.Cancelled,
+		&hostsJSON,
+		&createdAt,
+		&finishedAt,
+	); err != nil {
+		return job, err
+	}
+
+	job.SnippetID = snippetID.String
+	job.SnippetName = snippetName.String
+	job.Status = models.FleetJobStatus(status)
+	if hostsJSON.Valid && hostsJSON.String != "" {
+		if err := json.Unmarshal([]byte(hostsJSON.String), &job.Hosts); err != nil {
+			return job, fmt.Errorf("failed to decode fleet job hosts: %w", err)
+		}
+	}
+	job.CreatedAt, _ = parseTime(createdAt.String)
+	job.FinishedAt, _ = parseTime(finishedAt.String)
+
+	return job, nil
+}
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"freessh-backend/internal/db"
 	"freessh-backend/internal/models"
@@ -34,10 +35,15 @@ func (s *GroupStorage) Create(group models.Group) error {
 		group.CreatedAt = time.Now()
 	}
 
-	_, err := s.db.Exec(`
-		INSERT INTO groups (id, name, connection_count, created_at)
-		VALUES (?, ?, ?, ?)
-	`, group.ID, group.Name, group.ConnectionCount, formatTime(group.CreatedAt))
+	environmentJSON, err := marshalEnvironment(group.Environment)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO groups (id, name, connection_count, created_at, environment)
+		VALUES (?, ?, ?, ?, ?)
+	`, group.ID, group.Name, group.ConnectionCount, formatTime(group.CreatedAt), nullIfEmpty(environmentJSON))
 	if err != nil {
 		return fmt.Errorf("failed to create group: %w", err)
 	}
@@ -46,7 +52,7 @@ func (s *GroupStorage) Create(group models.Group) error {
 
 func (s *GroupStorage) Get(id string) (*models.Group, error) {
 	row := s.db.QueryRow(`
-		SELECT id, name, connection_count, created_at
+		SELECT id, name, connection_count, created_at, environment
 		FROM groups WHERE id = ?
 	`, id)
 
@@ -63,7 +69,7 @@ func (s *GroupStorage) Get(id string) (*models.Group, error) {
 
 func (s *GroupStorage) GetByName(name string) (*models.Group, error) {
 	row := s.db.QueryRow(`
-		SELECT id, name, connection_count, created_at
+		SELECT id, name, connection_count, created_at, environment
 		FROM groups WHERE name = ?
 	`, name)
 
@@ -80,7 +86,7 @@ func (s *GroupStorage) GetByName(name string) (*models.Group, error) {
 
 func (s *GroupStorage) List() []models.Group {
 	rows, err := s.db.Query(`
-		SELECT id, name, connection_count, created_at
+		SELECT id, name, connection_count, created_at, environment
 		FROM groups
 	`)
 	if err != nil {
@@ -101,10 +107,15 @@ func (s *GroupStorage) List() []models.Group {
 }
 
 func (s *GroupStorage) Update(group models.Group) error {
+	environmentJSON, err := marshalEnvironment(group.Environment)
+	if err != nil {
+		return err
+	}
+
 	result, err := s.db.Exec(`
-		UPDATE groups SET name = ?, connection_count = ?, created_at = ?
+		UPDATE groups SET name = ?, connection_count = ?, created_at = ?, environment = ?
 		WHERE id = ?
-	`, group.Name, group.ConnectionCount, formatTime(group.CreatedAt), group.ID)
+	`, group.Name, group.ConnectionCount, formatTime(group.CreatedAt), nullIfEmpty(environmentJSON), group.ID)
 	if err != nil {
 		return fmt.Errorf("failed to update group: %w", err)
 	}
@@ -182,9 +193,10 @@ func scanGroup(scanner interface {
 		name            string
 		connectionCount int
 		createdAt       sql.NullString
+		environment     sql.NullString
 	)
 
-	if err := scanner.Scan(&id, &name, &connectionCount, &createdAt); err != nil {
+	if err := scanner.Scan(&id, &name, &connectionCount, &createdAt, &environment); err != nil {
 		return models.Group{}, err
 	}
 
@@ -195,5 +207,26 @@ func scanGroup(scanner interface {
 		Name:            name,
 		ConnectionCount: connectionCount,
 		CreatedAt:       parsedCreatedAt,
+		Environment:     unmarshalEnvironment(environment),
 	}, nil
 }
+
+func marshalEnvironment(vars []models.EnvVar) (string, error) {
+	if len(vars) == 0 {
+		return "", nil
+	}
+	encoded, err := json.Marshal(vars)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal environment: %w", err)
+	}
+	return string(encoded), nil
+}
+
+func unmarshalEnvironment(value sql.NullString) []models.EnvVar {
+	if !value.Valid || value.String == "" {
+		return nil
+	}
+	var vars []models.EnvVar
+	_ = json.Unmarshal([]byte(value.String), &vars)
+	return vars
+}
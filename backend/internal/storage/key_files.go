@@ -50,3 +50,36 @@ func (s *KeyFileStorage) DeletePrivateKey(keyID string) error {
 	}
 	return nil
 }
+
+// certificatePath follows the OpenSSH convention of storing a certificate next
+// to its private key with a "-cert.pub" suffix.
+func (s *KeyFileStorage) certificatePath(keyID string) string {
+	return filepath.Join(s.keysDir, keyID+".pem-cert.pub")
+}
+
+func (s *KeyFileStorage) SaveCertificate(keyID string, certificate string) error {
+	if err := os.WriteFile(s.certificatePath(keyID), []byte(certificate), 0644); err != nil {
+		return fmt.Errorf("failed to write certificate: %w", err)
+	}
+	return nil
+}
+
+// GetCertificate returns the certificate attached to keyID, or an empty string
+// if there is none.
+func (s *KeyFileStorage) GetCertificate(keyID string) (string, error) {
+	data, err := os.ReadFile(s.certificatePath(keyID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read certificate: %w", err)
+	}
+	return string(data), nil
+}
+
+func (s *KeyFileStorage) DeleteCertificate(keyID string) error {
+	if err := os.Remove(s.certificatePath(keyID)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete certificate: %w", err)
+	}
+	return nil
+}
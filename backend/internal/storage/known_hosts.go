@@ -2,11 +2,13 @@ package storage
 
 import (
 	"database/sql"
+	"encoding/base64"
 	"fmt"
 	"freessh-backend/internal/db"
 	"freessh-backend/internal/models"
 
 	"github.com/google/uuid"
+	"golang.org/x/crypto/ssh"
 )
 
 type KnownHostStorage struct {
@@ -32,7 +34,7 @@ func NewKnownHostStorage() (*KnownHostStorage, error) {
 
 func (s *KnownHostStorage) GetAll() []*models.KnownHost {
 	rows, err := s.db.Query(`
-		SELECT id, hostname, port, fingerprint, publicKey
+		SELECT id, hostname, port, fingerprint, publicKey, marker, key_type
 		FROM known_hosts
 	`)
 	if err != nil {
@@ -51,10 +53,56 @@ func (s *KnownHostStorage) GetAll() []*models.KnownHost {
 	return hosts
 }
 
+// GetKeys returns every plain host key recorded for hostname:port, one per
+// key type.
+func (s *KnownHostStorage) GetKeys(hostname string, port int) []*models.KnownHost {
+	rows, err := s.db.Query(`
+		SELECT id, hostname, port, fingerprint, publicKey, marker, key_type
+		FROM known_hosts WHERE hostname = ? AND port = ? AND marker = ''
+	`, hostname, port)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	hosts := make([]*models.KnownHost, 0)
+	for rows.Next() {
+		host, err := scanKnownHost(rows)
+		if err != nil {
+			continue
+		}
+		hosts = append(hosts, host)
+	}
+	return hosts
+}
+
+// GetPatternEntries returns plain host keys recorded for wildcard or hashed
+// hosts (port 0), which can only be matched against a concrete address.
+func (s *KnownHostStorage) GetPatternEntries() []*models.KnownHost {
+	rows, err := s.db.Query(`
+		SELECT id, hostname, port, fingerprint, publicKey, marker, key_type
+		FROM known_hosts WHERE port = 0 AND marker = ''
+	`)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	hosts := make([]*models.KnownHost, 0)
+	for rows.Next() {
+		host, err := scanKnownHost(rows)
+		if err != nil {
+			continue
+		}
+		hosts = append(hosts, host)
+	}
+	return hosts
+}
+
 func (s *KnownHostStorage) Get(hostname string, port int) *models.KnownHost {
 	row := s.db.QueryRow(`
-		SELECT id, hostname, port, fingerprint, publicKey
-		FROM known_hosts WHERE hostname = ? AND port = ?
+		SELECT id, hostname, port, fingerprint, publicKey, marker, key_type
+		FROM known_hosts WHERE hostname = ? AND port = ? AND marker = ''
 	`, hostname, port)
 
 	host, err := scanKnownHost(row)
@@ -64,15 +112,37 @@ func (s *KnownHostStorage) Get(hostname string, port int) *models.KnownHost {
 	return host
 }
 
+// GetByMarker returns the @cert-authority or @revoked entries.
+func (s *KnownHostStorage) GetByMarker(marker string) []*models.KnownHost {
+	rows, err := s.db.Query(`
+		SELECT id, hostname, port, fingerprint, publicKey, marker, key_type
+		FROM known_hosts WHERE marker = ?
+	`, marker)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	hosts := make([]*models.KnownHost, 0)
+	for rows.Next() {
+		host, err := scanKnownHost(rows)
+		if err != nil {
+			continue
+		}
+		hosts = append(hosts, host)
+	}
+	return hosts
+}
+
 func (s *KnownHostStorage) Add(host *models.KnownHost) error {
 	if host.ID == "" {
 		host.ID = uuid.New().String()
 	}
 
 	_, err := s.db.Exec(`
-		INSERT INTO known_hosts (id, hostname, port, fingerprint, publicKey)
-		VALUES (?, ?, ?, ?, ?)
-	`, host.ID, host.Hostname, host.Port, host.Fingerprint, host.PublicKey)
+		INSERT INTO known_hosts (id, hostname, port, fingerprint, publicKey, marker, key_type)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, host.ID, host.Hostname, host.Port, host.Fingerprint, host.PublicKey, host.Marker, host.KeyType)
 	if err != nil {
 		return fmt.Errorf("failed to add known host: %w", err)
 	}
@@ -82,9 +152,9 @@ func (s *KnownHostStorage) Add(host *models.KnownHost) error {
 func (s *KnownHostStorage) Update(host *models.KnownHost) error {
 	result, err := s.db.Exec(`
 		UPDATE known_hosts
-		SET hostname = ?, port = ?, fingerprint = ?, publicKey = ?
+		SET hostname = ?, port = ?, fingerprint = ?, publicKey = ?, marker = ?, key_type = ?
 		WHERE id = ?
-	`, host.Hostname, host.Port, host.Fingerprint, host.PublicKey, host.ID)
+	`, host.Hostname, host.Port, host.Fingerprint, host.PublicKey, host.Marker, host.KeyType, host.ID)
 	if err != nil {
 		return fmt.Errorf("failed to update known host: %w", err)
 	}
@@ -95,6 +165,49 @@ func (s *KnownHostStorage) Update(host *models.KnownHost) error {
 	return nil
 }
 
+// SaveHostKey records host as the key of its type for its hostname and port,
+// replacing any previous key of the same type.
+func (s *KnownHostStorage) SaveHostKey(host *models.KnownHost) error {
+	if host.ID == "" {
+		host.ID = uuid.New().String()
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to save host key: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		DELETE FROM known_hosts
+		WHERE hostname = ? AND port = ? AND marker = '' AND key_type = ?
+	`, host.Hostname, host.Port, host.KeyType); err != nil {
+		return fmt.Errorf("failed to replace host key: %w", err)
+	}
+
+	if _, err := tx.Exec(`
+		INSERT INTO known_hosts (id, hostname, port, fingerprint, publicKey, marker, key_type)
+		VALUES (?, ?, ?, ?, ?, '', ?)
+	`, host.ID, host.Hostname, host.Port, host.Fingerprint, host.PublicKey, host.KeyType); err != nil {
+		return fmt.Errorf("failed to save host key: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// DeleteByFingerprint removes the plain host key with fingerprint for
+// hostname:port.
+func (s *KnownHostStorage) DeleteByFingerprint(hostname string, port int, fingerprint string) error {
+	_, err := s.db.Exec(`
+		DELETE FROM known_hosts
+		WHERE hostname = ? AND port = ? AND marker = '' AND fingerprint = ?
+	`, hostname, port, fingerprint)
+	if err != nil {
+		return fmt.Errorf("failed to delete known host: %w", err)
+	}
+	return nil
+}
+
 func (s *KnownHostStorage) Delete(id string) error {
 	result, err := s.db.Exec(`DELETE FROM known_hosts WHERE id = ?`, id)
 	if err != nil {
@@ -108,7 +221,7 @@ func (s *KnownHostStorage) Delete(id string) error {
 }
 
 func (s *KnownHostStorage) DeleteByHostname(hostname string, port int) error {
-	result, err := s.db.Exec(`DELETE FROM known_hosts WHERE hostname = ? AND port = ?`, hostname, port)
+	result, err := s.db.Exec(`DELETE FROM known_hosts WHERE hostname = ? AND port = ? AND marker = ''`, hostname, port)
 	if err != nil {
 		return fmt.Errorf("failed to delete known host: %w", err)
 	}
@@ -170,8 +283,17 @@ func scanKnownHost(scanner interface {
 	Scan(dest ...any) error
 }) (*models.KnownHost, error) {
 	host := &models.KnownHost{}
-	if err := scanner.Scan(&host.ID, &host.Hostname, &host.Port, &host.Fingerprint, &host.PublicKey); err != nil {
+	if err := scanner.Scan(&host.ID, &host.Hostname, &host.Port, &host.Fingerprint, &host.PublicKey, &host.Marker, &host.KeyType); err != nil {
 		return nil, err
 	}
+
+	// Rows written before key types were tracked: derive it from the key
+	if host.KeyType == "" && host.PublicKey != "" {
+		if keyBytes, err := base64.StdEncoding.DecodeString(host.PublicKey); err == nil {
+			if pubKey, err := ssh.ParsePublicKey(keyBytes); err == nil {
+				host.KeyType = pubKey.Type()
+			}
+		}
+	}
 	return host, nil
 }
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"freessh-backend/internal/db"
 	"freessh-backend/internal/models"
@@ -31,7 +32,8 @@ func NewSnippetStorage() (*SnippetStorage, error) {
 
 func (s *SnippetStorage) List() []models.Snippet {
 	rows, err := s.db.Query(`
-		SELECT id, name, command, created_at
+		SELECT id, name, command, created_at, params, folder, tags, description,
+			variants, usage_count, last_used_at
 		FROM snippets
 	`)
 	if err != nil {
@@ -53,7 +55,8 @@ func (s *SnippetStorage) List() []models.Snippet {
 
 func (s *SnippetStorage) Get(id string) (*models.Snippet, error) {
 	row := s.db.QueryRow(`
-		SELECT id, name, command, created_at
+		SELECT id, name, command, created_at, params, folder, tags, description,
+			variants, usage_count, last_used_at
 		FROM snippets WHERE id = ?
 	`, id)
 
@@ -73,10 +76,34 @@ func (s *SnippetStorage) Create(snippet models.Snippet) error {
 		snippet.CreatedAt = time.Now()
 	}
 
-	_, err := s.db.Exec(`
-		INSERT INTO snippets (id, name, command, created_at)
-		VALUES (?, ?, ?, ?)
-	`, snippet.ID, snippet.Name, snippet.Command, formatTime(snippet.CreatedAt))
+	encoded, err := encodeSnippetFields(snippet)
+	if err != nil {
+		return err
+	}
+
+	var lastUsedAt interface{}
+	if snippet.LastUsedAt != nil {
+		lastUsedAt = formatTime(*snippet.LastUsedAt)
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO snippets (
+			id, name, command, created_at, params, folder, tags, description,
+			variants, usage_count, last_used_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		snippet.ID,
+		snippet.Name,
+		snippet.Command,
+		formatTime(snippet.CreatedAt),
+		nullIfEmpty(encoded.params),
+		nullIfEmpty(snippet.Folder),
+		nullIfEmpty(encoded.tags),
+		nullIfEmpty(snippet.Description),
+		nullIfEmpty(encoded.variants),
+		snippet.UsageCount,
+		lastUsedAt,
+	)
 	if err != nil {
 		return fmt.Errorf("failed to create snippet: %w", err)
 	}
@@ -84,10 +111,27 @@ func (s *SnippetStorage) Create(snippet models.Snippet) error {
 }
 
 func (s *SnippetStorage) Update(snippet models.Snippet) error {
+	encoded, err := encodeSnippetFields(snippet)
+	if err != nil {
+		return err
+	}
+
+	// Usage statistics are only changed by RecordUse
 	result, err := s.db.Exec(`
-		UPDATE snippets SET name = ?, command = ?, created_at = ?
+		UPDATE snippets SET name = ?, command = ?, created_at = ?, params = ?,
+			folder = ?, tags = ?, description = ?, variants = ?
 		WHERE id = ?
-	`, snippet.Name, snippet.Command, formatTime(snippet.CreatedAt), snippet.ID)
+	`,
+		snippet.Name,
+		snippet.Command,
+		formatTime(snippet.CreatedAt),
+		nullIfEmpty(encoded.params),
+		nullIfEmpty(snippet.Folder),
+		nullIfEmpty(encoded.tags),
+		nullIfEmpty(snippet.Description),
+		nullIfEmpty(encoded.variants),
+		snippet.ID,
+	)
 	if err != nil {
 		return fmt.Errorf("failed to update snippet: %w", err)
 	}
@@ -110,6 +154,22 @@ func (s *SnippetStorage) Delete(id string) error {
 	return nil
 }
 
+// RecordUse counts one use of the snippet at usedAt.
+func (s *SnippetStorage) RecordUse(id string, usedAt time.Time) error {
+	result, err := s.db.Exec(`
+		UPDATE snippets SET usage_count = usage_count + 1, last_used_at = ?
+		WHERE id = ?
+	`, formatTime(usedAt), id)
+	if err != nil {
+		return fmt.Errorf("failed to record snippet use: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("snippet not found: %s", id)
+	}
+	return nil
+}
+
 func (s *SnippetStorage) migrateFromJSON() error {
 	tracker, err := NewMigrationTracker()
 	if err != nil {
@@ -161,22 +221,100 @@ func scanSnippet(scanner interface {
 	Scan(dest ...any) error
 }) (models.Snippet, error) {
 	var (
-		id        string
-		name      string
-		command   string
-		createdAt sql.NullString
+		id          string
+		name        string
+		command     string
+		createdAt   sql.NullString
+		params      sql.NullString
+		folder      sql.NullString
+		tags        sql.NullString
+		description sql.NullString
+		variants    sql.NullString
+		usageCount  sql.NullInt64
+		lastUsedAt  sql.NullString
 	)
 
-	if err := scanner.Scan(&id, &name, &command, &createdAt); err != nil {
+	if err := scanner.Scan(
+		&id,
+		&name,
+		&command,
+		&createdAt,
+		&params,
+		&folder,
+		&tags,
+		&description,
+		&variants,
+		&usageCount,
+		&lastUsedAt,
+	); err != nil {
 		return models.Snippet{}, err
 	}
 
 	parsedCreatedAt, _ := parseTime(createdAt.String)
 
-	return models.Snippet{
-		ID:        id,
-		Name:      name,
-		Command:   command,
-		CreatedAt: parsedCreatedAt,
-	}, nil
+	snippet := models.Snippet{
+		ID:          id,
+		Name:        name,
+		Command:     command,
+		Folder:      folder.String,
+		Description: description.String,
+		UsageCount:  int(usageCount.Int64),
+		CreatedAt:   parsedCreatedAt,
+	}
+	if params.Valid && params.String != "" {
+		if err := json.Unmarshal([]byte(params.String), &snippet.Params); err != nil {
+			return models.Snippet{}, fmt.Errorf("failed to unmarshal snippet params: %w", err)
+		}
+	}
+	if tags.Valid && tags.String != "" {
+		if err := json.Unmarshal([]byte(tags.String), &snippet.Tags); err != nil {
+			return models.Snippet{}, fmt.Errorf("failed to unmarshal snippet tags: %w", err)
+		}
+	}
+	if variants.Valid && variants.String != "" {
+		if err := json.Unmarshal([]byte(variants.String), &snippet.Variants); err != nil {
+			return models.Snippet{}, fmt.Errorf("failed to unmarshal snippet variants: %w", err)
+		}
+	}
+	if lastUsedAt.Valid && lastUsedAt.String != "" {
+		if parsed, err := parseTime(lastUsedAt.String); err == nil {
+			snippet.LastUsedAt = &parsed
+		}
+	}
+
+	return snippet, nil
+}
+
+// encodedSnippetFields holds the JSON columns of a snippet; empty values are
+// stored as NULL.
+type encodedSnippetFields struct {
+	params   string
+	tags     string
+	variants string
+}
+
+func encodeSnippetFields(snippet models.Snippet) (encodedSnippetFields, error) {
+	var encoded encodedSnippetFields
+	if len(snippet.Params) > 0 {
+		data, err := json.Marshal(snippet.Params)
+		if err != nil {
+			return encoded, fmt.Errorf("failed to marshal snippet params: %w", err)
+		}
+		encoded.params = string(data)
+	}
+	if len(snippet.Tags) > 0 {
+		data, err := json.Marshal(snippet.Tags)
+		if err != nil {
+			return encoded, fmt.Errorf("failed to marshal snippet tags: %w", err)
+		}
+		encoded.tags = string(data)
+	}
+	if len(snippet.Variants) > 0 {
+		data, err := json.Marshal(snippet.Variants)
+		if err != nil {
+			return encoded, fmt.Errorf("failed to marshal snippet variants: %w", err)
+		}
+		encoded.variants = string(data)
+	}
+	return encoded, nil
 }
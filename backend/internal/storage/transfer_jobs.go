@@ -0,0 +1,202 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"freessh-backend/internal/db"
+	"freessh-backend/internal/models"
+)
+
+// TransferJobStorage persists the transfer queue.
+type TransferJobStorage struct {
+	db *sql.DB
+}
+
+func NewTransferJobStorage() (*TransferJobStorage, error) {
+	database, err := db.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	return &TransferJobStorage{
+		db: database,
+	}, nil
+}
+
+const transferJobColumns = `id, connection_id, connection_name, direction, local_path, remote_path,
+	priority, position, state, attempts, max_attempts, size, transferred, source_mod_time,
+	rate_limit, verify, unverified, error, next_attempt_at, created_at, started_at, finished_at, updated_at`
+
+// transferJobOrder is the order in which queued jobs are started.
+const transferJobOrder = ` ORDER BY priority DESC, position ASC, created_at ASC`
+
+// Save inserts job or replaces the stored job with the same ID.
+func (s *TransferJobStorage) Save(job models.TransferJob) error {
+	_, err := s.db.Exec(`
+		INSERT OR REPLACE INTO transfer_jobs (`+transferJobColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		job.ID,
+		job.ConnectionID,
+		nullIfEmpty(job.ConnectionName),
+		string(job.Direction),
+		job.LocalPath,
+		job.RemotePath,
+		job.Priority,
+		job.Position,
+		string(job.State),
+		job.Attempts,
+		job.MaxAttempts,
+		job.Size,
+		job.Transferred,
+		job.SourceModTime,
+		job.RateLimit,
+		boolToInt(job.Verify),
+		nullIfEmpty(job.Unverified),
+		nullIfEmpty(job.Error),
+		formatTime(job.NextAttemptAt),
+		formatTime(job.CreatedAt),
+		formatTime(job.StartedAt),
+		formatTime(job.FinishedAt),
+		formatTime(job.UpdatedAt),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save transfer job: %w", err)
+	}
+	return nil
+}
+
+// List returns the jobs matching the request in queue order.
+func (s *TransferJobStorage) List(filter models.TransferQueueListRequest) []models.TransferJob {
+	query := `SELECT ` + transferJobColumns + ` FROM transfer_jobs WHERE 1 = 1`
+	args := []any{}
+	if filter.ConnectionID != "" {
+		query += ` AND connection_id = ?`
+		args = append(args, filter.ConnectionID)
+	}
+	if filter.State != "" {
+		query += ` AND state = ?`
+		args = append(args, string(filter.State))
+	}
+
+	return s.query(query+transferJobOrder, args...)
+}
+
+// Queued returns the jobs waiting to start, in the order they should start.
+func (s *TransferJobStorage) Queued() []models.TransferJob {
+	return s.List(models.TransferQueueListRequest{State: models.TransferQueued})
+}
+
+func (s *TransferJobStorage) Get(id string) (*models.TransferJob, error) {
+	row := s.db.QueryRow(`SELECT `+transferJobColumns+` FROM transfer_jobs WHERE id = ?`, id)
+
+	job, err := scanTransferJob(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("transfer job not found: %s", id)
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (s *TransferJobStorage) Delete(id string) error {
+	result, err := s.db.Exec(`DELETE FROM transfer_jobs WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete transfer job: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("transfer job not found: %s", id)
+	}
+	return nil
+}
+
+// NextPosition returns a position after every stored job.
+func (s *TransferJobStorage) NextPosition() (int64, error) {
+	var position sql.NullInt64
+	if err := s.db.QueryRow(`SELECT MAX(position) FROM transfer_jobs`).Scan(&position); err != nil {
+		return 0, fmt.Errorf("failed to read transfer queue position: %w", err)
+	}
+	return position.Int64 + 1, nil
+}
+
+// Requeue puts jobs left running by a previous process back in the queue.
+func (s *TransferJobStorage) Requeue() error {
+	_, err := s.db.Exec(`
+		UPDATE transfer_jobs SET state = ? WHERE state = ?
+	`, string(models.TransferQueued), string(models.TransferRunning))
+	if err != nil {
+		return fmt.Errorf("failed to requeue interrupted transfer jobs: %w", err)
+	}
+	return nil
+}
+
+func (s *TransferJobStorage) query(query string, args ...any) []models.TransferJob {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	jobs := make([]models.TransferJob, 0)
+	for rows.Next() {
+		job, err := scanTransferJob(rows)
+		if err != nil {
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs
+}
+
+func scanTransferJob(scanner interface {
+	Scan(dest ...any) error
+}) (models.TransferJob, error) {
+	var job models.TransferJob
+	var connectionName, unverified, errMsg, nextAttemptAt, createdAt, startedAt, finishedAt, updatedAt sql.NullString
+	var direction, state string
+	var verify int64
+
+	if err := scanner.Scan(
+		&job.ID,
+		&job.ConnectionID,
+		&connectionName,
+		&direction,
+		&job.LocalPath,
+		&job.RemotePath,
+		&job.Priority,
+		&job.Position,
+		&state,
+		&job.Attempts,
+		&job.MaxAttempts,
+		&job.Size,
+		&job.Transferred,
+		&job.SourceModTime,
+		&job.RateLimit,
+		&verify,
+		&unverified,
+		&errMsg,
+		&nextAttemptAt,
+		&createdAt,
+		&startedAt,
+		&finishedAt,
+		&updatedAt,
+	); err != nil {
+		return job, err
+	}
+
+	job.ConnectionName = connectionName.String
+	job.Direction = models.TransferDirection(direction)
+	job.State = models.TransferJobState(state)
+	job.Verify = verify != 0
+	job.Unverified = unverified.String
+	job.Error = errMsg.String
+	job.NextAttemptAt, _ = parseTime(nextAttemptAt.String)
+	job.CreatedAt, _ = parseTime(createdAt.String)
+	job.StartedAt, _ = parseTime(startedAt.String)
+	job.FinishedAt, _ = parseTime(finishedAt.String)
+	job.UpdatedAt, _ = parseTime(updatedAt.String)
+
+	return job, nil
+}
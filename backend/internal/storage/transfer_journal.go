@@ -0,0 +1,175 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"freessh-backend/internal/db"
+	"freessh-backend/internal/models"
+	"time"
+)
+
+// TransferJournalStorage records unfinished single-file transfers so they can
+// be resumed after a reconnect or a restart.
+type TransferJournalStorage struct {
+	db *sql.DB
+}
+
+func NewTransferJournalStorage() (*TransferJournalStorage, error) {
+	database, err := db.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	return &TransferJournalStorage{
+		db: database,
+	}, nil
+}
+
+const transferJournalColumns = `id, connection_id, direction, local_path, remote_path, size,
+	transferred, source_mod_time, status, error, created_at, updated_at`
+
+// Save inserts entry or replaces the stored entry with the same ID.
+func (s *TransferJournalStorage) Save(entry models.PartialTransfer) error {
+	_, err := s.db.Exec(`
+		INSERT OR REPLACE INTO transfer_journal (`+transferJournalColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		entry.ID,
+		nullIfEmpty(entry.ConnectionID),
+		string(entry.Direction),
+		entry.LocalPath,
+		entry.RemotePath,
+		entry.Size,
+		entry.Transferred,
+		entry.SourceModTime,
+		string(entry.Status),
+		nullIfEmpty(entry.Error),
+		formatTime(entry.CreatedAt),
+		formatTime(entry.UpdatedAt),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save transfer journal entry: %w", err)
+	}
+	return nil
+}
+
+// UpdateProgress records how far a running transfer has got.
+func (s *TransferJournalStorage) UpdateProgress(id string, transferred int64) error {
+	_, err := s.db.Exec(`
+		UPDATE transfer_journal SET transferred = ?, updated_at = ? WHERE id = ?
+	`, transferred, formatTime(time.Now()), id)
+	if err != nil {
+		return fmt.Errorf("failed to update transfer journal entry: %w", err)
+	}
+	return nil
+}
+
+// List returns the entries for connectionID, or all entries when it is empty,
+// most recently updated first.
+func (s *TransferJournalStorage) List(connectionID string) []models.PartialTransfer {
+	query := `SELECT ` + transferJournalColumns + ` FROM transfer_journal`
+	args := []any{}
+	if connectionID != "" {
+		query += ` WHERE connection_id = ?`
+		args = append(args, connectionID)
+	}
+	query += ` ORDER BY updated_at DESC`
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	entries := make([]models.PartialTransfer, 0)
+	for rows.Next() {
+		entry, err := scanPartialTransfer(rows)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func (s *TransferJournalStorage) Get(id string) (*models.PartialTransfer, error) {
+	row := s.db.QueryRow(`SELECT `+transferJournalColumns+` FROM transfer_journal WHERE id = ?`, id)
+
+	entry, err := scanPartialTransfer(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("partial transfer not found: %s", id)
+		}
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// Find returns the entry for the same transfer between the same paths, or nil
+// when there is none.
+func (s *TransferJournalStorage) Find(connectionID string, direction models.TransferDirection, localPath, remotePath string) *models.PartialTransfer {
+	row := s.db.QueryRow(`
+		SELECT `+transferJournalColumns+` FROM transfer_journal
+		WHERE COALESCE(connection_id, '') = ? AND direction = ? AND local_path = ? AND remote_path = ?
+		ORDER BY updated_at DESC LIMIT 1
+	`, connectionID, string(direction), localPath, remotePath)
+
+	entry, err := scanPartialTransfer(row)
+	if err != nil {
+		return nil
+	}
+	return &entry
+}
+
+func (s *TransferJournalStorage) Delete(id string) error {
+	if _, err := s.db.Exec(`DELETE FROM transfer_journal WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete transfer journal entry: %w", err)
+	}
+	return nil
+}
+
+// MarkInterrupted flags entries left running by a previous process, which
+// ended without recording why.
+func (s *TransferJournalStorage) MarkInterrupted() error {
+	_, err := s.db.Exec(`
+		UPDATE transfer_journal SET status = ? WHERE status = ?
+	`, string(models.PartialInterrupted), string(models.PartialRunning))
+	if err != nil {
+		return fmt.Errorf("failed to update interrupted transfers: %w", err)
+	}
+	return nil
+}
+
+func scanPartialTransfer(scanner interface {
+	Scan(dest ...any) error
+}) (models.PartialTransfer, error) {
+	var entry models.PartialTransfer
+	var connectionID, errMsg, createdAt, updatedAt sql.NullString
+	var direction, status string
+
+	if err := scanner.Scan(
+		&entry.ID,
+		&connectionID,
+		&direction,
+		&entry.LocalPath,
+		&entry.RemotePath,
+		&entry.Size,
+		&entry.Transferred,
+		&entry.SourceModTime,
+		&status,
+		&errMsg,
+		&createdAt,
+		&updatedAt,
+	); err != nil {
+		return entry, err
+	}
+
+	entry.ConnectionID = connectionID.String
+	entry.Direction = models.TransferDirection(direction)
+	entry.Status = models.PartialTransferStatus(status)
+	entry.Error = errMsg.String
+	entry.CreatedAt, _ = parseTime(createdAt.String)
+	entry.UpdatedAt, _ = parseTime(updatedAt.String)
+
+	return entry, nil
+}
@@ -0,0 +1,48 @@
+package terminal
+
+import (
+	"freessh-backend/internal/models"
+	"log"
+	"strings"
+
+	sshpkg "golang.org/x/crypto/ssh"
+)
+
+// sendEnvironment requests each variable with Setenv and returns those the
+// server refused, usually because they are not listed in its AcceptEnv.
+// Variables with names a shell cannot export are dropped, as configs reaching
+// a session are not all validated and the names end up in exportPrelude.
+func sendEnvironment(session *sshpkg.Session, vars []models.EnvVar) []models.EnvVar {
+	var refused []models.EnvVar
+	for _, v := range vars {
+		if !models.ValidEnvName(v.Name) {
+			log.Printf("Skipping environment variable with invalid name %q", v.Name)
+			continue
+		}
+		if err := session.Setenv(v.Name, v.Value); err != nil {
+			refused = append(refused, v)
+		}
+	}
+	return refused
+}
+
+// exportPrelude builds a shell line exporting vars, skipping names a shell
+// cannot export. The leading space keeps it out of the history of shells that
+// ignore space-prefixed commands.
+func exportPrelude(vars []models.EnvVar) string {
+	parts := make([]string, 0, len(vars))
+	for _, v := range vars {
+		if !models.ValidEnvName(v.Name) {
+			continue
+		}
+		parts = append(parts, "export "+v.Name+"="+shellQuote(v.Value))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " " + strings.Join(parts, "; ") + "\n"
+}
+
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
@@ -2,6 +2,8 @@ package terminal
 
 import (
 	"fmt"
+	"freessh-backend/internal/models"
+	"freessh-backend/internal/osdetect"
 	"freessh-backend/internal/ssh"
 	"sync"
 
@@ -14,6 +16,13 @@ type Terminal struct {
 	pty       *PTY
 	shell     *Shell
 	io        *IO
+	termType  string
+	rows      int
+	cols      int
+	env       []models.EnvVar
+	osType    string
+	refused   []string
+	x11Err    error
 	mu        sync.Mutex
 }
 
@@ -27,6 +36,60 @@ func (t *Terminal) Initialize(termType string, rows, cols int) error {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
+	return t.open(termType, rows, cols)
+}
+
+// SetEnvironment sets the variables passed to the shell on Initialize and
+// every Reopen.
+func (t *Terminal) SetEnvironment(vars []models.EnvVar) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.env = append([]models.EnvVar(nil), vars...)
+}
+
+// SetOSType sets the detected OS of the host. Refused variables are exported
+// with a POSIX export line, which is not typed into Windows shells.
+func (t *Terminal) SetOSType(osType string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.osType = osType
+}
+
+// RefusedEnvironment returns the names of the variables the server refused
+// with Setenv when the shell was last opened. Unless the host runs Windows,
+// they were exported by the shell instead.
+func (t *Terminal) RefusedEnvironment() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string(nil), t.refused...)
+}
+
+// X11ForwardingError returns why X11 forwarding could not be set up when the
+// shell was last opened, or nil if it was or is not enabled.
+func (t *Terminal) X11ForwardingError() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.x11Err
+}
+
+// Reopen replaces the shell with a new one on the client's current
+// connection, keeping the terminal type and last known size. Callers must
+// attach to the new GetIO streams.
+func (t *Terminal) Reopen() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.session != nil {
+		t.session.Close()
+	}
+	return t.open(t.termType, t.rows, t.cols)
+}
+
+func (t *Terminal) open(termType string, rows, cols int) error {
+	t.termType = termType
+	t.rows = rows
+	t.cols = cols
+
 	session, err := t.sshClient.NewSession()
 	if err != nil {
 		return fmt.Errorf("failed to create session: %w", err)
@@ -40,18 +103,40 @@ func (t *Terminal) Initialize(termType string, rows, cols int) error {
 	}
 	t.io = io
 
+	// Agent and X11 forwarding are best effort, like OpenSSH: the shell still
+	// works when the agent or display is unavailable or the server refuses.
+	// X11 failures are kept to be reported, as there is no other sign of them.
+	_ = t.sshClient.RequestAgentForwarding(session)
+	t.x11Err = t.sshClient.RequestX11Forwarding(session)
+
 	t.pty = NewPTY(session)
 	if err := t.pty.Request(termType, rows, cols); err != nil {
 		session.Close()
 		return err
 	}
 
+	// Variables must be requested before the shell starts; refused ones are
+	// exported once it is running
+	refused := sendEnvironment(session, t.env)
+	t.refused = t.refused[:0]
+	for _, v := range refused {
+		t.refused = append(t.refused, v.Name)
+	}
+
 	t.shell = NewShell(session)
 	if err := t.shell.Start(); err != nil {
 		session.Close()
 		return err
 	}
 
+	// cmd and PowerShell would choke on the export line
+	if prelude := exportPrelude(refused); prelude != "" && osdetect.OSType(t.osType) != osdetect.Windows {
+		if _, err := t.io.Write([]byte(prelude)); err != nil {
+			session.Close()
+			return fmt.Errorf("failed to export environment: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -68,6 +153,8 @@ func (t *Terminal) GetIO() *IO {
 func (t *Terminal) Resize(rows, cols int) error {
 	t.mu.Lock()
 	defer t.mu.Unlock()
+	t.rows = rows
+	t.cols = cols
 	return t.pty.Resize(rows, cols)
 }
 
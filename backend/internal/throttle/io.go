@@ -0,0 +1,49 @@
+package throttle
+
+import "io"
+
+type reader struct {
+	r      io.Reader
+	limit  Chain
+	cancel <-chan struct{}
+}
+
+// NewReader returns a reader that holds each read back until limit lets the
+// bytes read pass.
+func NewReader(r io.Reader, limit Chain, cancel <-chan struct{}) io.Reader {
+	if len(limit) == 0 {
+		return r
+	}
+	return &reader{r: r, limit: limit, cancel: cancel}
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		if werr := r.limit.Wait(n, r.cancel); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
+
+type writer struct {
+	w      io.Writer
+	limit  Chain
+	cancel <-chan struct{}
+}
+
+// NewWriter returns a writer that waits for limit before each write.
+func NewWriter(w io.Writer, limit Chain, cancel <-chan struct{}) io.Writer {
+	if len(limit) == 0 {
+		return w
+	}
+	return &writer{w: w, limit: limit, cancel: cancel}
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	if err := w.limit.Wait(len(p), w.cancel); err != nil {
+		return 0, err
+	}
+	return w.w.Write(p)
+}
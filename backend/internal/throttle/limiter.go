@@ -0,0 +1,131 @@
+package throttle
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCancelled is returned by Wait when the cancel channel closes first.
+var ErrCancelled = errors.New("wait cancelled")
+
+// minBurst is the smallest number of bytes a limiter lets through at once,
+// so very low rates still move reasonably sized blocks.
+const minBurst = 32 * 1024
+
+// Limiter is a token bucket limiting throughput to a number of bytes per
+// second. All transfers sharing a limiter share its rate. The rate can change
+// at any time; waiting transfers pick up the new rate right away.
+type Limiter struct {
+	rate    int64
+	tokens  float64
+	last    time.Time
+	changed chan struct{}
+	mu      sync.Mutex
+}
+
+// NewLimiter returns a limiter for rate bytes per second. A rate of zero or
+// less means unlimited.
+func NewLimiter(rate int64) *Limiter {
+	return &Limiter{
+		rate:    rate,
+		last:    time.Now(),
+		changed: make(chan struct{}),
+	}
+}
+
+func (l *Limiter) Rate() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rate
+}
+
+// SetRate changes the limit and wakes every waiting transfer.
+func (l *Limiter) SetRate(rate int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if rate == l.rate {
+		return
+	}
+	l.refill(time.Now())
+	l.rate = rate
+	if burst := l.burst(); l.tokens > burst {
+		l.tokens = burst
+	}
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// Wait blocks until n bytes may pass, taking them from the bucket in pieces
+// no larger than the burst size.
+func (l *Limiter) Wait(n int, cancel <-chan struct{}) error {
+	remaining := float64(n)
+	for remaining > 0 {
+		l.mu.Lock()
+		if l.rate <= 0 {
+			l.mu.Unlock()
+			return nil
+		}
+
+		now := time.Now()
+		l.refill(now)
+		need := min(remaining, l.burst())
+		if l.tokens >= need {
+			l.tokens -= need
+			remaining -= need
+			l.mu.Unlock()
+			continue
+		}
+
+		delay := time.Duration((need - l.tokens) / float64(l.rate) * float64(time.Second))
+		changed := l.changed
+		l.mu.Unlock()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-changed:
+			timer.Stop()
+		case <-cancel:
+			timer.Stop()
+			return ErrCancelled
+		}
+	}
+	return nil
+}
+
+// refill adds the tokens earned since the last call. The caller holds l.mu.
+func (l *Limiter) refill(now time.Time) {
+	if l.rate > 0 {
+		l.tokens += now.Sub(l.last).Seconds() * float64(l.rate)
+		if burst := l.burst(); l.tokens > burst {
+			l.tokens = burst
+		}
+	}
+	l.last = now
+}
+
+// burst is how many bytes may pass at once: a quarter second's worth, but
+// at least minBurst. The caller holds l.mu.
+func (l *Limiter) burst() float64 {
+	return max(float64(l.rate)/4, minBurst)
+}
+
+// Chain is the set of limiters one transfer is subject to, such as its own,
+// its connection's and the global one. Nil entries are skipped, so an empty
+// chain does not throttle at all.
+type Chain []*Limiter
+
+// Wait blocks until n bytes may pass every limiter in the chain.
+func (c Chain) Wait(n int, cancel <-chan struct{}) error {
+	for _, limiter := range c {
+		if limiter == nil {
+			continue
+		}
+		if err := limiter.Wait(n, cancel); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package throttle
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiterWait(t *testing.T) {
+	const rate = 1 << 20
+
+	tests := []struct {
+		name string
+		rate int64
+		n    int
+		min  time.Duration
+		max  time.Duration
+	}{
+		{"unlimited", 0, 10 << 20, 0, 50 * time.Millisecond},
+		{"negative is unlimited", -1, 10 << 20, 0, 50 * time.Millisecond},
+		{"one burst", rate, rate / 4, 200 * time.Millisecond, time.Second},
+		{"several bursts", rate, rate / 2, 400 * time.Millisecond, 2 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := NewLimiter(tt.rate)
+			start := time.Now()
+			if err := limiter.Wait(tt.n, nil); err != nil {
+				t.Fatalf("Wait error: %v", err)
+			}
+			if elapsed := time.Since(start); elapsed < tt.min || elapsed > tt.max {
+				t.Errorf("Wait(%d) at %d B/s took %v, want %v to %v", tt.n, tt.rate, elapsed, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestLimiterBurst(t *testing.T) {
+	tests := []struct {
+		rate int64
+		want float64
+	}{
+		{1, minBurst},
+		{4 * minBurst, minBurst},
+		{1 << 20, 1 << 18},
+	}
+
+	for _, tt := range tests {
+		limiter := NewLimiter(tt.rate)
+		if got := limiter.burst(); got != tt.want {
+			t.Errorf("burst at %d B/s = %v, want %v", tt.rate, got, tt.want)
+		}
+	}
+}
+
+func TestLimiterCancel(t *testing.T) {
+	limiter := NewLimiter(1)
+	cancel := make(chan struct{})
+
+	done := make(chan error, 1)
+	go func() { done <- limiter.Wait(1<<20, cancel) }()
+	close(cancel)
+
+	select {
+	case err := <-done:
+		if err != ErrCancelled {
+			t.Fatalf("Wait error = %v, want ErrCancelled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Wait did not return after cancel")
+	}
+}
+
+func TestLimiterSetRateWakesWaiters(t *testing.T) {
+	limiter := NewLimiter(1)
+
+	done := make(chan error, 1)
+	go func() { done <- limiter.Wait(1<<20, nil) }()
+
+	time.Sleep(20 * time.Millisecond)
+	limiter.SetRate(0)
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Wait error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Wait did not pick up the new rate")
+	}
+	if got := limiter.Rate(); got != 0 {
+		t.Errorf("Rate() = %d, want 0", got)
+	}
+}
+
+func TestChainSkipsNil(t *testing.T) {
+	chain := Chain{nil, NewLimiter(0), nil}
+	if err := chain.Wait(1<<20, nil); err != nil {
+		t.Fatalf("Wait error: %v", err)
+	}
+	if err := Chain(nil).Wait(1<<20, nil); err != nil {
+		t.Fatalf("empty chain Wait error: %v", err)
+	}
+}
+
+func TestChainWaitsForSlowest(t *testing.T) {
+	chain := Chain{NewLimiter(0), NewLimiter(1 << 20)}
+	start := time.Now()
+	if err := chain.Wait(1<<18, nil); err != nil {
+		t.Fatalf("Wait error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("Wait through a 1 MiB/s limiter took only %v", elapsed)
+	}
+}
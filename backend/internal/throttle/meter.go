@@ -0,0 +1,48 @@
+package throttle
+
+import (
+	"sync"
+	"time"
+)
+
+// meterWindow is the span over which a Meter averages the rate.
+const meterWindow = 3 * time.Second
+
+type sample struct {
+	at    time.Time
+	total int64
+}
+
+// Meter measures the current rate of a transfer from its running total.
+type Meter struct {
+	samples []sample
+	mu      sync.Mutex
+}
+
+func NewMeter() *Meter {
+	return &Meter{}
+}
+
+// Update records the bytes transferred so far and returns the average rate
+// in bytes per second over the last few seconds.
+func (m *Meter) Update(total int64) int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.samples = append(m.samples, sample{at: now, total: total})
+
+	// Keep one sample older than the window so the average spans all of it.
+	drop := 0
+	for drop+1 < len(m.samples) && now.Sub(m.samples[drop+1].at) >= meterWindow {
+		drop++
+	}
+	m.samples = m.samples[drop:]
+
+	first := m.samples[0]
+	elapsed := now.Sub(first.at).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return int64(float64(total-first.total) / elapsed)
+}
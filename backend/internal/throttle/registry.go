@@ -0,0 +1,106 @@
+package throttle
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrTransferExists is returned by Begin for an ID that is already running.
+var ErrTransferExists = errors.New("transfer ID already in use")
+
+// Registry holds the limiters shared by all transfers: one global limiter,
+// one per connection and one per running transfer. Rates can change at any
+// time and apply to transfers already running.
+type Registry struct {
+	global      *Limiter
+	connections map[string]*Limiter
+	transfers   map[string]*Limiter
+	mu          sync.Mutex
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		global:      NewLimiter(0),
+		connections: make(map[string]*Limiter),
+		transfers:   make(map[string]*Limiter),
+	}
+}
+
+// Configure sets the global rate and the per-connection rates, replacing
+// any earlier per-connection rates. Zero means unlimited.
+func (r *Registry) Configure(global int64, connections map[string]int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.global.SetRate(global)
+	for id, limiter := range r.connections {
+		limiter.SetRate(connections[id])
+	}
+	for id, rate := range connections {
+		r.connection(id).SetRate(rate)
+	}
+}
+
+func (r *Registry) SetGlobalRate(rate int64) {
+	r.global.SetRate(rate)
+}
+
+func (r *Registry) SetConnectionRate(connectionID string, rate int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.connection(connectionID).SetRate(rate)
+}
+
+// SetTransferRate changes the rate of a running transfer and reports whether
+// it was found.
+func (r *Registry) SetTransferRate(transferID string, rate int64) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	limiter, ok := r.transfers[transferID]
+	if ok {
+		limiter.SetRate(rate)
+	}
+	return ok
+}
+
+// Begin registers a transfer with its own rate and returns the limiters it
+// has to respect: its own, those of the connections it uses and the global
+// one. End must be called when the transfer stops. An ID already registered
+// is refused with ErrTransferExists, so a duplicate cannot take over another
+// transfer's limiter.
+func (r *Registry) Begin(transferID string, rate int64, connectionIDs ...string) (Chain, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.transfers[transferID]; ok {
+		return nil, ErrTransferExists
+	}
+	limiter := NewLimiter(rate)
+	r.transfers[transferID] = limiter
+
+	chain := Chain{limiter}
+	for _, id := range connectionIDs {
+		if id != "" {
+			chain = append(chain, r.connection(id))
+		}
+	}
+	return append(chain, r.global), nil
+}
+
+func (r *Registry) End(transferID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.transfers, transferID)
+}
+
+// connection returns the limiter of a connection, creating an unlimited one
+// on first use. The caller holds r.mu.
+func (r *Registry) connection(id string) *Limiter {
+	limiter, ok := r.connections[id]
+	if !ok {
+		limiter = NewLimiter(0)
+		r.connections[id] = limiter
+	}
+	return limiter
+}
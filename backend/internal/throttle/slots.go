@@ -0,0 +1,144 @@
+package throttle
+
+import (
+	"slices"
+	"sync"
+)
+
+// Slots bounds how many transfers run at once, overall and per connection.
+// The transfer queue and transfers started directly share one Slots so the
+// overall limit holds across both. Only the queue takes per-connection
+// slots, so the user can always start transfers of their own on a host the
+// queue is busy with.
+type Slots struct {
+	global  int
+	perHost int
+	running int
+	hosts   map[string]int
+	// changed is closed and replaced whenever a slot may have come free.
+	changed chan struct{}
+	mu      sync.Mutex
+}
+
+// NewSlots returns Slots allowing global transfers at once, and perHost on
+// any one connection. Zero means unlimited.
+func NewSlots(global, perHost int) *Slots {
+	return &Slots{
+		global:  global,
+		perHost: perHost,
+		hosts:   make(map[string]int),
+		changed: make(chan struct{}),
+	}
+}
+
+// SetLimits changes the limits. Transfers already running over a lowered
+// limit are allowed to finish.
+func (s *Slots) SetLimits(global, perHost int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.global = global
+	s.perHost = perHost
+	s.broadcast()
+}
+
+// TryAcquire takes a slot on every connection in hosts, and one overall,
+// if all are free. A connection listed twice takes one slot.
+func (s *Slots) TryAcquire(hosts ...string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.take(hosts)
+}
+
+// Acquire waits for a slot on every connection in hosts, and one overall.
+// It returns ErrCancelled if cancel is closed first.
+func (s *Slots) Acquire(cancel <-chan struct{}, hosts ...string) error {
+	for {
+		s.mu.Lock()
+		if s.take(hosts) {
+			s.mu.Unlock()
+			return nil
+		}
+		changed := s.changed
+		s.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-cancel:
+			return ErrCancelled
+		}
+	}
+}
+
+// Release returns the slots taken for hosts.
+func (s *Slots) Release(hosts ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.running--
+	for _, host := range distinct(hosts) {
+		if s.hosts[host]--; s.hosts[host] <= 0 {
+			delete(s.hosts, host)
+		}
+	}
+	s.broadcast()
+}
+
+// Changed returns a channel closed the next time a slot may come free.
+func (s *Slots) Changed() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.changed
+}
+
+// For binds the slots to hosts for transfers that take them one at a time.
+func (s *Slots) For(hosts ...string) *Gate {
+	return &Gate{slots: s, hosts: hosts}
+}
+
+func (s *Slots) take(hosts []string) bool {
+	if s.global > 0 && s.running >= s.global {
+		return false
+	}
+	hosts = distinct(hosts)
+	for _, host := range hosts {
+		if s.perHost > 0 && s.hosts[host] >= s.perHost {
+			return false
+		}
+	}
+
+	s.running++
+	for _, host := range hosts {
+		s.hosts[host]++
+	}
+	return true
+}
+
+// distinct returns hosts without repeats, so a transfer between two sessions
+// of one connection counts against it once.
+func distinct(hosts []string) []string {
+	return slices.Compact(slices.Sorted(slices.Values(hosts)))
+}
+
+func (s *Slots) broadcast() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// Gate takes slots for a fixed set of connections. A nil Gate never waits.
+type Gate struct {
+	slots *Slots
+	hosts []string
+}
+
+func (g *Gate) Acquire(cancel <-chan struct{}) error {
+	if g == nil {
+		return nil
+	}
+	return g.slots.Acquire(cancel, g.hosts...)
+}
+
+func (g *Gate) Release() {
+	if g != nil {
+		g.slots.Release(g.hosts...)
+	}
+}
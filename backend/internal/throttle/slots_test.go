@@ -0,0 +1,128 @@
+package throttle
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSlotsTryAcquire(t *testing.T) {
+	tests := []struct {
+		name    string
+		global  int
+		perHost int
+		held    [][]string
+		acquire []string
+		want    bool
+	}{
+		{"free", 2, 1, nil, []string{"a"}, true},
+		{"global full", 2, 0, [][]string{{"a"}, {"b"}}, []string{"c"}, false},
+		{"host full", 5, 1, [][]string{{"a"}}, []string{"a"}, false},
+		{"other host free", 5, 1, [][]string{{"a"}}, []string{"b"}, true},
+		{"any host full", 5, 1, [][]string{{"b"}}, []string{"a", "b"}, false},
+		{"same host twice takes one slot", 5, 1, nil, []string{"a", "a"}, true},
+		{"no hosts ignores host limit", 5, 1, [][]string{{"a"}}, nil, true},
+		{"unlimited", 0, 0, [][]string{{"a"}, {"a"}, {"a"}}, []string{"a"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slots := NewSlots(tt.global, tt.perHost)
+			for _, hosts := range tt.held {
+				if !slots.TryAcquire(hosts...) {
+					t.Fatalf("could not take %v", hosts)
+				}
+			}
+			if got := slots.TryAcquire(tt.acquire...); got != tt.want {
+				t.Errorf("TryAcquire(%v) = %v, want %v", tt.acquire, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlotsReleaseSameHostTwice(t *testing.T) {
+	slots := NewSlots(0, 2)
+	if !slots.TryAcquire("a", "a") {
+		t.Fatal("could not take a transfer within one host")
+	}
+	if !slots.TryAcquire("a") {
+		t.Fatal("a transfer within one host took both of its slots")
+	}
+	slots.Release("a", "a")
+	if !slots.TryAcquire("a") {
+		t.Fatal("release did not free the host slot")
+	}
+	if slots.TryAcquire("a") {
+		t.Fatal("release freed more than one host slot")
+	}
+}
+
+func TestSlotsAcquireWaitsForRelease(t *testing.T) {
+	slots := NewSlots(1, 0)
+	if !slots.TryAcquire("a") {
+		t.Fatal("could not take the only slot")
+	}
+
+	acquired := make(chan error, 1)
+	go func() { acquired <- slots.Acquire(nil, "b") }()
+
+	select {
+	case <-acquired:
+		t.Fatal("Acquire returned while the slot was held")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	slots.Release("a")
+	select {
+	case err := <-acquired:
+		if err != nil {
+			t.Fatalf("Acquire error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Acquire did not return after Release")
+	}
+}
+
+func TestSlotsAcquireCancel(t *testing.T) {
+	slots := NewSlots(1, 0)
+	slots.TryAcquire()
+
+	cancel := make(chan struct{})
+	acquired := make(chan error, 1)
+	go func() { acquired <- slots.Acquire(cancel) }()
+	close(cancel)
+
+	select {
+	case err := <-acquired:
+		if err != ErrCancelled {
+			t.Fatalf("Acquire error = %v, want ErrCancelled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Acquire did not return after cancel")
+	}
+}
+
+func TestSlotsSetLimitsWakesWaiters(t *testing.T) {
+	slots := NewSlots(1, 0)
+	slots.TryAcquire()
+
+	acquired := make(chan error, 1)
+	go func() { acquired <- slots.Acquire(nil) }()
+
+	slots.SetLimits(2, 0)
+	select {
+	case err := <-acquired:
+		if err != nil {
+			t.Fatalf("Acquire error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Acquire did not return after the limit was raised")
+	}
+}
+
+func TestNilGate(t *testing.T) {
+	var gate *Gate
+	if err := gate.Acquire(nil); err != nil {
+		t.Fatalf("nil Gate Acquire error: %v", err)
+	}
+	gate.Release()
+}
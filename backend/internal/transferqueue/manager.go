@@ -0,0 +1,525 @@
+package transferqueue
+
+import (
+	"fmt"
+	"freessh-backend/internal/config"
+	"freessh-backend/internal/models"
+	"freessh-backend/internal/session"
+	"freessh-backend/internal/settings"
+	"freessh-backend/internal/storage"
+	"freessh-backend/internal/throttle"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// flushInterval bounds how often a running job's progress is written
+	// to the database.
+	flushInterval = 2 * time.Second
+	// notifyInterval bounds how often progress of a running job is
+	// reported to the listener.
+	notifyInterval = 250 * time.Millisecond
+)
+
+// Manager runs the persistent transfer queue. Jobs are stored in SQLite and
+// started by a single dispatcher within the global and per-host limits, so
+// the queue survives restarts and is shared by all sessions.
+type Manager struct {
+	sessions *session.Manager
+	jobs     *storage.TransferJobStorage
+	settings *settings.TransferSettingsStorage
+	listener func(models.TransferJob)
+
+	running map[string]*runningJob
+	slots   *throttle.Slots
+	wake    chan struct{}
+	mu      sync.Mutex
+}
+
+type runningJob struct {
+	job    models.TransferJob
+	cancel chan struct{}
+	meter  *throttle.Meter
+	// paused and removed record why the job was stopped early.
+	paused     bool
+	removed    bool
+	lastFlush  time.Time
+	lastNotify time.Time
+}
+
+func NewManager(sessions *session.Manager, jobs *storage.TransferJobStorage, transferSettings *settings.TransferSettingsStorage) *Manager {
+	if err := jobs.Requeue(); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+
+	m := &Manager{
+		sessions: sessions,
+		jobs:     jobs,
+		settings: transferSettings,
+		running:  make(map[string]*runningJob),
+		slots:    sessions.Slots(),
+		wake:     make(chan struct{}, 1),
+	}
+	m.applyLimits()
+	return m
+}
+
+// SetListener registers fn to receive every change to a job, including
+// progress of running jobs. It must be set before Start.
+func (m *Manager) SetListener(fn func(models.TransferJob)) {
+	m.listener = fn
+}
+
+// Start launches the dispatcher. Jobs queued by an earlier run of the app
+// start right away.
+func (m *Manager) Start() {
+	go m.loop()
+}
+
+// Limits returns the queue limits in effect.
+func (m *Manager) Limits() settings.TransferSettings {
+	if m.settings == nil {
+		return settings.TransferSettings{
+			MaxConcurrent: config.DefaultTransferConcurrency,
+			MaxPerHost:    config.DefaultTransferPerHost,
+			MaxAttempts:   config.DefaultTransferAttempts,
+		}
+	}
+	return m.settings.Limits()
+}
+
+// Settings returns the stored queue settings, where zero means the default.
+func (m *Manager) Settings() settings.TransferSettings {
+	if m.settings == nil {
+		return settings.TransferSettings{}
+	}
+	return m.settings.Get()
+}
+
+// UpdateSettings stores new limits. Raised concurrency limits take effect
+// immediately; lowered ones let running transfers finish. Rate limits apply to
+// every running transfer at once, queued or not.
+func (m *Manager) UpdateSettings(transferSettings settings.TransferSettings) error {
+	if m.settings == nil {
+		return fmt.Errorf("transfer settings storage not available")
+	}
+	if err := m.settings.Update(transferSettings); err != nil {
+		return err
+	}
+	m.applyLimits()
+	m.kick()
+	return nil
+}
+
+// SetRateLimit changes one bandwidth limit while transfers run. Global and
+// per-connection limits are stored with the transfer settings. A transfer
+// limit applies to the running transfer with that ID and, for a queue job,
+// is stored with the job so later attempts keep it.
+func (m *Manager) SetRateLimit(req models.RateLimitRequest) error {
+	if req.Rate < 0 {
+		return fmt.Errorf("invalid rate limit: %d", req.Rate)
+	}
+
+	switch req.Scope {
+	case models.RateLimitGlobal:
+		transferSettings := m.Settings()
+		transferSettings.RateLimit = req.Rate
+		return m.UpdateSettings(transferSettings)
+
+	case models.RateLimitConnection:
+		if req.ID == "" {
+			return fmt.Errorf("connection ID is required")
+		}
+		transferSettings := m.Settings()
+		// The stored map is shared with the settings storage, so edit a copy
+		rates := make(map[string]int64, len(transferSettings.ConnectionRateLimits)+1)
+		for id, rate := range transferSettings.ConnectionRateLimits {
+			rates[id] = rate
+		}
+		if req.Rate > 0 {
+			rates[req.ID] = req.Rate
+		} else {
+			delete(rates, req.ID)
+		}
+		transferSettings.ConnectionRateLimits = rates
+		return m.UpdateSettings(transferSettings)
+
+	case models.RateLimitTransfer:
+		return m.setTransferRateLimit(req.ID, req.Rate)
+
+	default:
+		return fmt.Errorf("invalid rate limit scope: %q", req.Scope)
+	}
+}
+
+func (m *Manager) setTransferRateLimit(transferID string, rate int64) error {
+	m.mu.Lock()
+	running := m.sessions.Throttle().SetTransferRate(transferID, rate)
+
+	var job *models.TransferJob
+	var err error
+	if rj, ok := m.running[transferID]; ok {
+		rj.job.RateLimit = rate
+		current := rj.job
+		job, err = &current, m.jobs.Save(current)
+	} else {
+		job, err = m.update(transferID, func(job *models.TransferJob) error {
+			job.RateLimit = rate
+			return nil
+		})
+	}
+	m.mu.Unlock()
+
+	if err != nil {
+		if running {
+			// Not a queue job, but a transfer started elsewhere
+			return nil
+		}
+		return fmt.Errorf("transfer not found: %s", transferID)
+	}
+
+	m.notify(*job)
+	return nil
+}
+
+// applyLimits hands the stored concurrency limits and the global and
+// per-connection rate limits to the slots and limiters shared by all
+// transfers, queued or not.
+func (m *Manager) applyLimits() {
+	limits := m.Limits()
+	m.slots.SetLimits(limits.MaxConcurrent, limits.MaxPerHost)
+
+	transferSettings := m.Settings()
+	m.sessions.Throttle().Configure(transferSettings.RateLimit, transferSettings.ConnectionRateLimits)
+}
+
+// Enqueue adds a job for req. sessionID supplies the connection when the
+// request does not name one.
+func (m *Manager) Enqueue(req models.TransferEnqueueRequest, sessionID string) (*models.TransferJob, error) {
+	if req.Direction != models.TransferUpload && req.Direction != models.TransferDownload {
+		return nil, fmt.Errorf("invalid transfer direction: %q", req.Direction)
+	}
+	if strings.TrimSpace(req.LocalPath) == "" || strings.TrimSpace(req.RemotePath) == "" {
+		return nil, fmt.Errorf("local and remote paths are required")
+	}
+	if req.Direction == models.TransferUpload {
+		info, err := os.Stat(req.LocalPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat local file: %w", err)
+		}
+		if !info.Mode().IsRegular() {
+			return nil, fmt.Errorf("not a regular file: %s", req.LocalPath)
+		}
+	}
+
+	connectionID := req.ConnectionID
+	if connectionID == "" {
+		active, err := m.sessions.GetSession(sessionID)
+		if err != nil {
+			return nil, err
+		}
+		connectionID = active.Config.ID
+	}
+	connection, err := m.connection(connectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.RateLimit < 0 {
+		return nil, fmt.Errorf("invalid rate limit: %d", req.RateLimit)
+	}
+
+	maxAttempts := req.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = m.Limits().MaxAttempts
+	}
+
+	state := models.TransferQueued
+	if req.Paused {
+		state = models.TransferPaused
+	}
+
+	m.mu.Lock()
+	position, err := m.jobs.NextPosition()
+	if err != nil {
+		m.mu.Unlock()
+		return nil, err
+	}
+
+	now := time.Now()
+	job := models.TransferJob{
+		ID:             uuid.New().String(),
+		ConnectionID:   connection.ID,
+		ConnectionName: connection.Name,
+		Direction:      req.Direction,
+		LocalPath:      req.LocalPath,
+		RemotePath:     req.RemotePath,
+		Priority:       req.Priority,
+		Position:       position,
+		State:          state,
+		MaxAttempts:    maxAttempts,
+		RateLimit:      req.RateLimit,
+		Verify:         req.Verify,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	err = m.jobs.Save(job)
+	m.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	m.notify(job)
+	m.kick()
+	return &job, nil
+}
+
+// List returns the stored jobs in queue order, with the live progress of
+// running jobs.
+func (m *Manager) List(filter models.TransferQueueListRequest) []models.TransferJob {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	jobs := m.jobs.List(filter)
+	for i := range jobs {
+		if rj, ok := m.running[jobs[i].ID]; ok {
+			jobs[i] = rj.job
+		}
+	}
+	return jobs
+}
+
+// Pause stops a running job, keeping its partial file for a later resume,
+// or holds back a queued one.
+func (m *Manager) Pause(jobID string) (*models.TransferJob, error) {
+	m.mu.Lock()
+	if rj, ok := m.running[jobID]; ok {
+		job := rj.job
+		if !rj.paused && !rj.removed {
+			rj.paused = true
+			close(rj.cancel)
+		}
+		m.mu.Unlock()
+		return &job, nil
+	}
+
+	job, err := m.update(jobID, func(job *models.TransferJob) error {
+		if job.State != models.TransferQueued {
+			return fmt.Errorf("cannot pause a %s transfer", job.State)
+		}
+		job.State = models.TransferPaused
+		return nil
+	})
+	m.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	m.notify(*job)
+	return job, nil
+}
+
+// Resume queues a paused job again. It continues from its partial file.
+func (m *Manager) Resume(jobID string) (*models.TransferJob, error) {
+	return m.requeue(jobID, func(job *models.TransferJob) error {
+		if job.State != models.TransferPaused {
+			return fmt.Errorf("cannot resume a %s transfer", job.State)
+		}
+		return nil
+	})
+}
+
+// Retry queues a failed job again with a fresh set of attempts.
+func (m *Manager) Retry(jobID string) (*models.TransferJob, error) {
+	return m.requeue(jobID, func(job *models.TransferJob) error {
+		if job.State != models.TransferFailed {
+			return fmt.Errorf("cannot retry a %s transfer", job.State)
+		}
+		job.Attempts = 0
+		job.FinishedAt = time.Time{}
+		return nil
+	})
+}
+
+// Remove deletes a job, stopping it first if it is running. Files already
+// written are left in place.
+func (m *Manager) Remove(jobID string) error {
+	m.mu.Lock()
+	if rj, ok := m.running[jobID]; ok && !rj.removed {
+		rj.removed = true
+		if !rj.paused {
+			close(rj.cancel)
+		}
+	}
+	err := m.jobs.Delete(jobID)
+	m.mu.Unlock()
+
+	if err == nil {
+		m.kick()
+	}
+	return err
+}
+
+// Reorder moves the jobs in jobIDs, in that order, ahead of every other job
+// and returns the queue in its new order.
+func (m *Manager) Reorder(jobIDs []string) ([]models.TransferJob, error) {
+	m.mu.Lock()
+	jobs := m.jobs.List(models.TransferQueueListRequest{})
+	byID := make(map[string]models.TransferJob, len(jobs))
+	for _, job := range jobs {
+		byID[job.ID] = job
+	}
+
+	ordered := make([]models.TransferJob, 0, len(jobs))
+	listed := make(map[string]bool, len(jobIDs))
+	for _, id := range jobIDs {
+		job, ok := byID[id]
+		if !ok {
+			m.mu.Unlock()
+			return nil, fmt.Errorf("transfer job not found: %s", id)
+		}
+		if listed[id] {
+			continue
+		}
+		listed[id] = true
+		ordered = append(ordered, job)
+	}
+	// Positions alone decide the order among equal priorities, so walk the
+	// rest by position rather than by the priority-first list order.
+	rest := make([]models.TransferJob, 0, len(jobs))
+	for _, job := range jobs {
+		if !listed[job.ID] {
+			rest = append(rest, job)
+		}
+	}
+	sort.SliceStable(rest, func(i, j int) bool {
+		return rest[i].Position < rest[j].Position
+	})
+	ordered = append(ordered, rest...)
+
+	for i := range ordered {
+		position := int64(i + 1)
+		if ordered[i].Position == position {
+			continue
+		}
+		ordered[i].Position = position
+		if rj, ok := m.running[ordered[i].ID]; ok {
+			rj.job.Position = position
+			ordered[i] = rj.job
+		}
+		if err := m.jobs.Save(ordered[i]); err != nil {
+			m.mu.Unlock()
+			return nil, err
+		}
+	}
+	m.mu.Unlock()
+
+	m.kick()
+	return m.List(models.TransferQueueListRequest{}), nil
+}
+
+// SetPriority changes the priority of a job. Higher priorities start first.
+func (m *Manager) SetPriority(jobID string, priority int) (*models.TransferJob, error) {
+	m.mu.Lock()
+	var job *models.TransferJob
+	var err error
+	if rj, ok := m.running[jobID]; ok {
+		rj.job.Priority = priority
+		current := rj.job
+		job, err = &current, m.jobs.Save(current)
+	} else {
+		job, err = m.update(jobID, func(job *models.TransferJob) error {
+			job.Priority = priority
+			return nil
+		})
+	}
+	m.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	m.notify(*job)
+	m.kick()
+	return job, nil
+}
+
+func (m *Manager) requeue(jobID string, check func(job *models.TransferJob) error) (*models.TransferJob, error) {
+	m.mu.Lock()
+	if _, ok := m.running[jobID]; ok {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("transfer is still running: %s", jobID)
+	}
+	job, err := m.update(jobID, func(job *models.TransferJob) error {
+		if err := check(job); err != nil {
+			return err
+		}
+		job.State = models.TransferQueued
+		job.Error = ""
+		job.NextAttemptAt = time.Time{}
+		return nil
+	})
+	m.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	m.notify(*job)
+	m.kick()
+	return job, nil
+}
+
+// update loads a job that is not running, applies fn and saves it. The
+// caller holds m.mu.
+func (m *Manager) update(jobID string, fn func(job *models.TransferJob) error) (*models.TransferJob, error) {
+	job, err := m.jobs.Get(jobID)
+	if err != nil {
+		return nil, err
+	}
+	if err := fn(job); err != nil {
+		return nil, err
+	}
+	job.UpdatedAt = time.Now()
+	if err := m.jobs.Save(*job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// connection finds the saved connection id, falling back to an open
+// session's config for connections that were never saved.
+func (m *Manager) connection(id string) (*models.ConnectionConfig, error) {
+	if id == "" {
+		return nil, fmt.Errorf("connection ID is required")
+	}
+	if connections := m.sessions.GetConnectionStorage(); connections != nil {
+		if connection, err := connections.Get(id); err == nil {
+			return connection, nil
+		}
+	}
+	for _, active := range m.sessions.GetAllSessions() {
+		if active.Config.ID == id {
+			connection := active.Config
+			return &connection, nil
+		}
+	}
+	return nil, fmt.Errorf("connection not found: %s", id)
+}
+
+func (m *Manager) notify(job models.TransferJob) {
+	if m.listener != nil {
+		m.listener(job)
+	}
+}
+
+func (m *Manager) kick() {
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+}
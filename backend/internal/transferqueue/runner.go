@@ -0,0 +1,256 @@
+package transferqueue
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"freessh-backend/internal/config"
+	"freessh-backend/internal/models"
+	"freessh-backend/internal/sftp"
+	"freessh-backend/internal/throttle"
+	"os"
+	"time"
+)
+
+// loop starts queued jobs whenever something may have freed a slot or made a
+// job runnable, and wakes itself for the next scheduled retry.
+func (m *Manager) loop() {
+	for {
+		var retry <-chan time.Time
+		if wait := m.dispatch(); wait > 0 {
+			retry = time.After(wait)
+		}
+
+		select {
+		case <-m.wake:
+		case <-m.slots.Changed():
+		case <-retry:
+		}
+	}
+}
+
+// dispatch starts as many queued jobs as the limits allow and returns how
+// long until the earliest job waiting for a retry is due, or zero.
+func (m *Manager) dispatch() time.Duration {
+	now := time.Now()
+	var started []models.TransferJob
+	var wait time.Duration
+
+	m.mu.Lock()
+	for _, job := range m.jobs.Queued() {
+		if job.NextAttemptAt.After(now) {
+			if due := job.NextAttemptAt.Sub(now); wait == 0 || due < wait {
+				wait = due
+			}
+			continue
+		}
+		// The slots are shared with transfers started outside the queue
+		if !m.slots.TryAcquire(job.ConnectionID) {
+			continue
+		}
+
+		job.State = models.TransferRunning
+		job.Attempts++
+		job.Error = ""
+		job.Unverified = ""
+		job.NextAttemptAt = time.Time{}
+		job.StartedAt = now
+		job.UpdatedAt = now
+		if err := m.jobs.Save(job); err != nil {
+			m.slots.Release(job.ConnectionID)
+			continue
+		}
+
+		rj := &runningJob{job: job, cancel: make(chan struct{}), meter: throttle.NewMeter(), lastFlush: now, lastNotify: now}
+		m.running[job.ID] = rj
+		started = append(started, job)
+		go m.run(rj)
+	}
+	m.mu.Unlock()
+
+	for _, job := range started {
+		m.notify(job)
+	}
+	return wait
+}
+
+func (m *Manager) run(rj *runningJob) {
+	err := m.transfer(rj)
+
+	m.mu.Lock()
+	delete(m.running, rj.job.ID)
+	m.slots.Release(rj.job.ConnectionID)
+	job := rj.job
+	if !rj.removed {
+		settle(&job, err, rj.paused)
+		m.jobs.Save(job)
+	}
+	m.mu.Unlock()
+
+	if !rj.removed {
+		m.notify(job)
+	}
+	m.kick()
+}
+
+// settle records the outcome of an attempt. Failures are queued again with
+// a growing delay until the job runs out of attempts. A copy the server
+// cannot verify is done all the same, as verification is best effort.
+func settle(job *models.TransferJob, err error, paused bool) {
+	now := time.Now()
+	job.UpdatedAt = now
+	job.Rate = 0
+
+	switch {
+	case err == nil, errors.Is(err, sftp.ErrChecksumUnavailable):
+		job.State = models.TransferDone
+		job.Transferred = job.Size
+		job.FinishedAt = now
+		if err != nil {
+			job.Unverified = err.Error()
+		}
+	case paused:
+		// A pause is not a failed attempt
+		job.State = models.TransferPaused
+		job.Attempts--
+	case job.Attempts < job.MaxAttempts:
+		job.State = models.TransferQueued
+		job.Error = err.Error()
+		job.NextAttemptAt = now.Add(retryDelay(job.Attempts))
+	default:
+		job.State = models.TransferFailed
+		job.Error = err.Error()
+		job.FinishedAt = now
+	}
+}
+
+func retryDelay(attempts int) time.Duration {
+	delay := config.TransferRetryDelay
+	for i := 1; i < attempts && delay < config.TransferRetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > config.TransferRetryMaxDelay {
+		delay = config.TransferRetryMaxDelay
+	}
+	return delay
+}
+
+// transfer performs one attempt of a job over its own SFTP channel. A job
+// that has run before continues from its partial file as long as the source
+// has not changed since.
+func (m *Manager) transfer(rj *runningJob) error {
+	// The limiter is registered under the lock so SetRateLimit cannot slip
+	// in between reading the job's limit and registering it.
+	rates := m.sessions.Throttle()
+	m.mu.Lock()
+	job := rj.job
+	limit, err := rates.Begin(job.ID, job.RateLimit, job.ConnectionID)
+	m.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	defer rates.End(job.ID)
+
+	connection, err := m.connection(job.ConnectionID)
+	if err != nil {
+		return err
+	}
+
+	sshClient, release, err := m.sessions.OpenTransport(context.Background(), *connection)
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	defer release()
+
+	client := sftp.NewClient(sshClient)
+	if err := client.Connect(); err != nil {
+		return err
+	}
+	defer client.Close()
+
+	size, modTime, err := sourceInfo(client, job)
+	if err != nil {
+		return err
+	}
+
+	var offset int64
+	if job.Size == size && job.SourceModTime == modTime && job.Transferred > 0 {
+		if job.Direction == models.TransferUpload {
+			offset, err = client.UploadOffset(job.LocalPath, job.RemotePath)
+		} else {
+			offset, err = client.DownloadOffset(job.RemotePath, job.LocalPath)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	m.mu.Lock()
+	rj.job.Size = size
+	rj.job.SourceModTime = modTime
+	rj.job.Transferred = offset
+	m.mu.Unlock()
+
+	progress := func(transferred, total int64) {
+		m.progress(rj, transferred)
+	}
+
+	opts := sftp.TransferOptions{Offset: offset, Limit: limit}
+	if job.Verify {
+		opts.Hash = sha256.New()
+	}
+	if job.Direction == models.TransferUpload {
+		err = client.Upload(job.LocalPath, job.RemotePath, opts, progress, rj.cancel)
+	} else {
+		err = client.Download(job.RemotePath, job.LocalPath, opts, progress, rj.cancel)
+	}
+
+	// A mismatch fails the attempt. The destination is then as large as the
+	// source, so the next attempt finds nothing to resume and starts over.
+	if err == nil && job.Verify {
+		err = client.VerifyChecksum(job.RemotePath, opts.Hash.Sum(nil), rj.cancel)
+	}
+	return err
+}
+
+func (m *Manager) progress(rj *runningJob, transferred int64) {
+	now := time.Now()
+	rate := rj.meter.Update(transferred)
+
+	m.mu.Lock()
+	rj.job.Transferred = transferred
+	rj.job.Rate = rate
+	rj.job.UpdatedAt = now
+	if !rj.removed && now.Sub(rj.lastFlush) >= flushInterval {
+		m.jobs.Save(rj.job)
+		rj.lastFlush = now
+	}
+	report := now.Sub(rj.lastNotify) >= notifyInterval
+	if report {
+		rj.lastNotify = now
+	}
+	job := rj.job
+	m.mu.Unlock()
+
+	if report {
+		m.notify(job)
+	}
+}
+
+// sourceInfo returns the size and modification time of the file a job reads.
+func sourceInfo(client *sftp.Client, job models.TransferJob) (int64, int64, error) {
+	if job.Direction == models.TransferUpload {
+		info, err := os.Stat(job.LocalPath)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to stat local file: %w", err)
+		}
+		return info.Size(), info.ModTime().Unix(), nil
+	}
+
+	info, err := client.Stat(job.RemotePath)
+	if err != nil {
+		return 0, 0, err
+	}
+	return info.Size, info.ModTime, nil
+}
@@ -32,6 +32,7 @@ export function HostKeyVerificationDialog({
   }
 
   const isNewHost = verification.status === 'new'
+  const isNewKeyType = verification.status === 'new_key_type'
 
   return (
     <Dialog open={open} onOpenChange={(open) => !open && onCancel()}>
@@ -39,12 +40,14 @@ export function HostKeyVerificationDialog({
         <DialogHeader>
           <DialogTitle className="flex items-center gap-2">
             <AlertTriangle className="h-5 w-5 text-orange-500" />
-            {isNewHost ? 'New Host' : 'Host Key Changed'}
+            {isNewHost ? 'New Host' : isNewKeyType ? 'Unrecognised Host Key Type' : 'Host Key Changed'}
           </DialogTitle>
           <DialogDescription>
             {isNewHost
               ? 'The authenticity of this host cannot be established.'
-              : 'WARNING: REMOTE HOST IDENTIFICATION HAS CHANGED!'}
+              : isNewKeyType
+                ? `This host is known by a different key type (${verification.knownKeyTypes?.join(', ')}).`
+                : 'WARNING: REMOTE HOST IDENTIFICATION HAS CHANGED!'}
           </DialogDescription>
         </DialogHeader>
 
@@ -7,10 +7,11 @@ export interface KnownHost {
 }
 
 export interface HostKeyVerification {
-  status: 'new' | 'known' | 'changed'
+  status: 'new' | 'known' | 'new_key_type' | 'changed'
   hostname: string
   port: number
   fingerprint: string
   keyType: string
   oldFingerprint?: string
+  knownKeyTypes?: string[]
 }